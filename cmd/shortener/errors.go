@@ -0,0 +1,10 @@
+package main
+
+import "errors"
+
+var (
+	errUnknownStorageMode   = errors.New("unknown storage mode")
+	errStorageMisconfigured = errors.New("storage is misconfigured")
+	errUnexpectedStorage    = errors.New("storage does not match the configuration")
+	errDatabaseUnavailable  = errors.New("database is unavailable")
+)
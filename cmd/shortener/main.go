@@ -3,28 +3,67 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
+	"github.com/sviatilnik/url-shortener/internal/app/admin"
 	"github.com/sviatilnik/url-shortener/internal/app/audit"
 	"github.com/sviatilnik/url-shortener/internal/app/config"
 	"github.com/sviatilnik/url-shortener/internal/app/generators"
 	"github.com/sviatilnik/url-shortener/internal/app/handlers"
+	"github.com/sviatilnik/url-shortener/internal/app/health"
 	"github.com/sviatilnik/url-shortener/internal/app/logger"
+	"github.com/sviatilnik/url-shortener/internal/app/metrics"
 	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
 	"github.com/sviatilnik/url-shortener/internal/app/shortener"
 	"github.com/sviatilnik/url-shortener/internal/app/storages"
+	"github.com/sviatilnik/url-shortener/internal/app/tokens"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+	"github.com/sviatilnik/url-shortener/internal/app/users"
+	"github.com/sviatilnik/url-shortener/internal/app/validators"
 	"go.uber.org/zap"
 )
 
+// blocklistReloadInterval определяет, как часто проверяется изменение файла блок-листа.
+const blocklistReloadInterval = 10 * time.Second
+
+// serverShutdownTimeout ограничивает ожидание завершения активных запросов при остановке.
+const serverShutdownTimeout = 10 * time.Second
+
+// tracingShutdownTimeout ограничивает ожидание экспорта накопленных трасс при остановке.
+const tracingShutdownTimeout = 5 * time.Second
+
+// dbPingTimeout ограничивает одну попытку подключения к базе данных.
+const dbPingTimeout = time.Second
+
+// dbConnectMaxBackoff ограничивает паузу между попытками подключения к базе данных.
+const dbConnectMaxBackoff = 10 * time.Second
+
+// healthCheckTimeout ограничивает время проверки одного компонента в /readyz.
+const healthCheckTimeout = 2 * time.Second
+
+// tracingServiceName - имя сервиса в экспортируемых трассах.
+const tracingServiceName = "shortener"
+
+// clicksFlushInterval определяет, как часто накопленные счетчики переходов A/B-ссылок записываются в хранилище.
+const clicksFlushInterval = 10 * time.Second
+
+// rateLimitCleanupInterval определяет, как часто из базы данных удаляются восполненные корзины.
+const rateLimitCleanupInterval = 5 * time.Minute
+
 var (
 	buildVersion string
 	buildDate    string
@@ -32,115 +71,466 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == verifyCommand {
+		conf, err := getVerifyConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		os.Exit(runVerify(os.Args[2:], &conf, os.Stdout, os.Stderr))
+	}
+
 	printBuildInfo()
 
-	conf := getConfig()
-	zapLogger, err := logger.NewLogger()
+	conf, err := getConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	zapLogger, err := logger.Configure(getLoggerOptions(&conf))
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
+	defer logger.Close()
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer cancel()
 
-	connection, connErr := getDBConnection(&conf)
-	if connErr != nil {
-		zapLogger.Info("Failed to connect to database")
+	storageMode, connection, err := getStorageBackend(ctx, &conf, zapLogger)
+	if err != nil {
+		zapLogger.Fatalw("Failed to configure storage", "error", err)
+	}
+	zapLogger.Infow("Using storage", "storage", storageMode)
+
+	tracer, err := getTracer(&conf, zapLogger)
+	if err != nil {
+		zapLogger.Fatalw("Failed to configure tracing", "error", err)
 	}
 
-	storage := getStorage(ctx, connection, &conf)
-	shorter := getShortener(conf.ShortURLHost, storage)
-	auditService := getAuditService(&conf, zapLogger)
+	registry := metrics.NewRegistry()
+	metrics.RegisterRuntime(registry)
+
+	storage, err := getStorage(ctx, connection, storageMode, &conf, metrics.NewStorageMetrics(registry))
+	if err != nil {
+		zapLogger.Fatalw("Failed to initialize link storage", "error", err)
+	}
+	flushClicksPeriodically(ctx, storage, zapLogger)
+	userStorage, err := getUserStorage(ctx, connection, storageMode, &conf)
+	if err != nil {
+		zapLogger.Fatalw("Failed to initialize user storage", "error", err)
+	}
+	tokenStorage, err := getTokenStorage(ctx, connection, storageMode, &conf)
+	if err != nil {
+		zapLogger.Fatalw("Failed to initialize token storage", "error", err)
+	}
+	banStorage, err := getBanStorage(ctx, connection, storageMode, &conf)
+	if err != nil {
+		zapLogger.Fatalw("Failed to initialize ban storage", "error", err)
+	}
+	auditStorage := getAuditStorage(ctx, connection, storageMode, &conf, zapLogger)
+	auditService, err := getAuditService(&conf, auditStorage, zapLogger)
+	if err != nil {
+		zapLogger.Fatalw("Failed to configure audit", "error", err)
+	}
+	reopenAuditOnSIGHUP(ctx, auditService, zapLogger)
+	metrics.RegisterAudit(registry, auditService)
+	shorter, err := getShortener(&conf, storage, getURLValidator(ctx, &conf, zapLogger), auditService, metrics.NewShortenerMetrics(registry))
+	if err != nil {
+		zapLogger.Fatalw("Failed to configure shortener", "error", err)
+	}
+	userService := users.NewService(userStorage, shorter, auditService)
+	tokenService := tokens.NewService(tokenStorage)
+	adminService := admin.NewService(storage, banStorage, auditStorage, auditService, strings.Split(conf.AdminUserIDs, ","))
+	adminService.SetLogLevelControl(logger.Level())
+
+	keys, err := middlewares.NewKeySetFromConfig(&conf)
+	if err != nil {
+		zapLogger.Fatalw("Failed to load JWT signing keys", "error", err)
+	}
+	authMiddleware := middlewares.NewAuthMiddleware(&conf, zapLogger, keys, tokenService)
+
+	createLimit, err := middlewares.ParseRateLimit(conf.RateLimitCreate)
+	if err != nil {
+		zapLogger.Fatalw("Invalid creation rate limit", "value", conf.RateLimitCreate, "error", err)
+	}
+	redirectLimit, err := middlewares.ParseRateLimit(conf.RateLimitRedirect)
+	if err != nil {
+		zapLogger.Fatalw("Invalid redirect rate limit", "value", conf.RateLimitRedirect, "error", err)
+	}
+	rateLimiter := middlewares.NewRateLimiter(getRateLimitStorage(ctx, connection, &conf, zapLogger), zapLogger, conf.RateLimitTrustProxy)
+
+	healthChecker := getHealthChecker(storage, connection, auditService)
 
 	r := chi.NewRouter()
+	if tracer != nil {
+		r.Use(middlewares.NewTracingMiddleware(tracer).Trace)
+	}
+	r.Use(middlewares.RequestID(zapLogger))
+	r.Use(middlewares.NewMetricsMiddleware(registry).Measure)
 	r.Use(middlewares.Log)
 	r.Use(middlewares.Compress)
-	r.Use(middlewares.NewAuthMiddleware(&conf, zapLogger).Auth)
-	r.Use(middlewares.NewAuditMiddleware(auditService).Audit)
+	r.Use(authMiddleware.Auth)
+	r.Use(middlewares.NewAuditMiddleware(auditService, conf.RateLimitTrustProxy).Audit)
 
 	if connection != nil {
 		r.Get("/ping", handlers.PingDBHandler(connection))
 	}
-	r.Post("/", handlers.GetShortLinkHandler(shorter))
-	r.Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
-	r.Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
-	r.Post("/api/shorten/batch", handlers.BatchShortLinkHandler(shorter))
-	r.Get("/api/user/urls", handlers.UserURLsHandler(shorter))
-	r.Delete("/api/user/urls", handlers.DeleteUserURLsHandler(shorter))
+	r.Get("/healthz", handlers.LivenessHandler(healthChecker))
+	r.Get("/readyz", handlers.ReadinessHandler(healthChecker))
+	r.Get("/metrics", registry.Handler().ServeHTTP)
+	// Публичные маршруты: аутентификация не требуется
+	r.With(rateLimiter.Limit(middlewares.RateLimitRedirect, redirectLimit)).Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
+
+	// Создание ссылок: анонимным пользователям выдается идентификатор, если это разрешено
+	// Ограничение применяется до выдачи анонимного идентификатора; анонимные запросы
+	// учитываются по IP-адресу независимо от предъявленной cookie
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimiter.Limit(middlewares.RateLimitCreate, createLimit))
+		r.Use(authMiddleware.IssueAnonymous)
+		r.Use(middlewares.RejectBanned(adminService, zapLogger))
+		r.Use(middlewares.RequireScope(models.ScopeLinksWrite))
+		r.Post("/", handlers.GetShortLinkHandler(shorter))
+		r.Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+		r.Post("/api/shorten/batch", handlers.BatchShortLinkHandler(shorter))
+	})
+
+	// Регистрация и вход: текущий идентификатор необязателен и используется для привязки ссылок
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequireSession)
+		r.Post("/api/user/register", handlers.RegisterHandler(userService, authMiddleware))
+		r.Post("/api/user/login", handlers.LoginHandler(userService, authMiddleware))
+		r.Post("/api/user/logout", handlers.LogoutHandler(authMiddleware))
+	})
+
+	// Данные пользователя: требуется действительный токен
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.Require)
+		r.Use(middlewares.RejectBanned(adminService, zapLogger))
+		r.With(middlewares.RequireScope(models.ScopeLinksRead)).Get("/api/user/urls", handlers.UserURLsHandler(shorter))
+		r.With(middlewares.RequireScope(models.ScopeLinksWrite)).Delete("/api/user/urls", handlers.DeleteUserURLsHandler(shorter))
+		r.With(middlewares.RequireScope(models.ScopeLinksRead)).Get("/api/user/quota", handlers.UserQuotaHandler(shorter))
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.RequireSession)
+			r.Post("/api/user/tokens", handlers.CreateTokenHandler(tokenService, adminService))
+			r.Get("/api/user/tokens", handlers.UserTokensHandler(tokenService))
+			r.Delete("/api/user/tokens/{token_id}", handlers.RevokeTokenHandler(tokenService))
+		})
+	})
+
+	// Административный API: требуется роль администратора
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.Require)
+		r.Use(middlewares.RequireAdmin(adminService))
+		r.Get("/api/admin/links", handlers.AdminSearchLinksHandler(adminService))
+		r.Post("/api/admin/links/{short_code}/disable", handlers.AdminSetLinkDisabledHandler(adminService, true))
+		r.Post("/api/admin/links/{short_code}/enable", handlers.AdminSetLinkDisabledHandler(adminService, false))
+		r.Delete("/api/admin/links/{short_code}", handlers.AdminDeleteLinkHandler(adminService))
+		r.Post("/api/admin/users/{user_id}/ban", handlers.AdminBanUserHandler(adminService))
+		r.Delete("/api/admin/users/{user_id}/ban", handlers.AdminUnbanUserHandler(adminService))
+		r.Get("/api/admin/stats", handlers.AdminStatsHandler(adminService))
+		r.Get("/api/admin/audit", handlers.AdminSearchAuditHandler(adminService))
+		r.Get("/api/admin/log/level", handlers.AdminLogLevelHandler(adminService))
+		r.Put("/api/admin/log/level", handlers.AdminChangeLogLevelHandler(adminService))
+	})
 
 	server := &http.Server{
 		Addr:    conf.Host,
 		Handler: r,
 	}
 
+	listener, err := net.Listen("tcp", conf.Host)
+	if err != nil {
+		zapLogger.Fatalw("Error starting server", "error", err)
+	}
+
+	if err := serve(ctx, server, listener, conf.EnabledHTTPS); err != nil {
+		zapLogger.Errorw("Error serving HTTP", "error", err)
+	}
+
+	shutdown(server, healthChecker, storage, auditService, tracer, connection, &conf, zapLogger)
+}
+
+// serve обслуживает HTTP-запросы на listener до завершения ctx или ошибки сервера.
+func serve(ctx context.Context, server *http.Server, listener net.Listener, enableHTTPS bool) error {
+	errCh := make(chan error, 1)
 	go func() {
-		var err error
-		if conf.EnabledHTTPS {
-			err = server.ListenAndServeTLS("server.crt", "server.key")
+		if enableHTTPS {
+			errCh <- server.ServeTLS(listener, "server.crt", "server.key")
 		} else {
-			err = server.ListenAndServe()
-		}
-
-		if err != nil && err != http.ErrServerClosed {
-			zapLogger.Fatalw("Error starting server", "error", err)
+			errCh <- server.Serve(listener)
 		}
-
 	}()
 
-	<-ctx.Done()
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
 
-	zapLogger.Info("Shutting down server")
+// shutdown корректно останавливает сервис. Порядок важен: сначала сервис сообщает о неготовности
+// через /readyz и в течение ShutdownDelay продолжает обслуживать запросы, пока балансировщик
+// не исключит его; затем сервер перестает принимать соединения и дожидается активных запросов, затем доставляются события аудита, записанные
+// этими запросами, и трассы этих запросов. Только после этого закрывается соединение с базой данных.
+func shutdown(server *http.Server, healthChecker *health.Checker, storage storages.URLStorage, auditService *audit.AuditService, tracer *tracing.Tracer, connection *sql.DB, conf *config.Config, log *zap.SugaredLogger) {
+	log.Info("Shutting down server")
+
+	healthChecker.SetShuttingDown()
+	if conf.ShutdownDelay > 0 {
+		log.Infow("Waiting for readiness probes before stopping server", "delay", conf.ShutdownDelay)
+		time.Sleep(conf.ShutdownDelay)
+	}
 
 	// Останавливаем прием новых соединений
-	timeout := 10 * time.Second
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), timeout)
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
 	defer cancel()
 
 	// Останавливаем HTTP сервер, дожидаемся завершения всех активных запросов
 	if err := server.Shutdown(ctxShutdown); err != nil {
-		zapLogger.Errorw("Error shutting down server", "error", err)
+		log.Errorw("Error shutting down server", "error", err)
 	} else {
-		zapLogger.Info("HTTP server shut down successfully")
+		log.Info("HTTP server shut down successfully")
 	}
+
+	// Записываем счетчики переходов, накопленные после последней периодической записи
+	if flusher, ok := storage.(storages.ClickFlusher); ok {
+		if err := flusher.FlushClicks(context.Background()); err != nil {
+			log.Errorw("Error flushing variant clicks", "error", err)
+		}
+	}
+
+	// Доставляем события аудита, оставшиеся в очередях; отдельный срок не зависит от того,
+	// сколько времени заняла остановка сервера. Недоставленные события сохраняются в spool
+	ctxAudit, cancelAudit := context.WithTimeout(context.Background(), conf.AuditShutdownTimeout)
+	defer cancelAudit()
+
+	if err := auditService.Close(ctxAudit); err != nil {
+		log.Errorw("Error flushing audit events", "error", err)
+	}
+	log.Infow("Audit delivery stopped", "stats", auditService.DeliveryStats())
+
+	// Экспортируем накопленные трассы; недоэкспортированные к сроку трассы отбрасываются
+	if tracer != nil {
+		ctxTracing, cancelTracing := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancelTracing()
+
+		if err := tracer.Shutdown(ctxTracing); err != nil {
+			log.Errorw("Error flushing traces", "error", err)
+		}
+		log.Infow("Tracing stopped", "dropped_spans", tracer.Dropped())
+	}
+
 	// Закрываем соединение с базой данных
 	if connection != nil {
 		if err := connection.Close(); err != nil {
-			zapLogger.Errorw("Error closing database connection", "error", err)
+			log.Errorw("Error closing database connection", "error", err)
 		} else {
-			zapLogger.Info("Database connection closed successfully")
+			log.Info("Database connection closed successfully")
 		}
 	}
 
-	zapLogger.Info("Server shut down successfully")
+	log.Info("Server shut down successfully")
 }
 
-func getShortener(baseURL string, storage storages.URLStorage) *shortener.Shortener {
+// getShortener создает сервис сокращения ссылок по конфигурации.
+// Пустая политика параметров запроса оставляет политику по умолчанию, некорректная приводит к ошибке.
+func getShortener(config *config.Config, storage storages.URLStorage, validator validators.Validator, auditor shortener.Auditor, shortenerMetrics shortener.Metrics) (*shortener.Shortener, error) {
+	shortenerConfig := shortener.NewShortenerConfig(config.ShortURLHost)
+	if config.RedirectQueryPolicy != "" {
+		policy := models.QueryPolicy(config.RedirectQueryPolicy)
+		if !policy.IsValid() {
+			return nil, fmt.Errorf("%w: %q, expected append, override or drop", shortener.ErrInvalidQueryPolicy, config.RedirectQueryPolicy)
+		}
+		shortenerConfig.QueryPolicy = policy
+	}
+	shortenerConfig.Validator = validator
+	shortenerConfig.Normalize = shortener.NormalizeOptions{
+		Enabled:             config.NormalizeURLs,
+		SortQuery:           config.NormalizeSortQuery,
+		StripTrackingParams: config.NormalizeStripTracking,
+		PreserveOriginal:    config.PreserveOriginalURL,
+	}
+	shortenerConfig.Quota = shortener.QuotaOptions{
+		Limit:         config.LinkQuota,
+		ExemptUserIDs: strings.Split(config.LinkQuotaExemptUserIDs, ","),
+	}
+	shortenerConfig.Auditor = auditor
+	shortenerConfig.Metrics = shortenerMetrics
+
 	return shortener.NewShortener(
 		storage,
 		generators.NewRandomGenerator(10),
-		shortener.NewShortenerConfig(baseURL),
+		shortenerConfig,
+	), nil
+}
+
+func getURLValidator(ctx context.Context, config *config.Config, log *zap.SugaredLogger) validators.Validator {
+	chain := validators.NewChain(
+		validators.NewSchemeValidator(strings.Split(config.AllowedURLSchemes, ",")...),
+		validators.NewLoopValidator(config.ShortURLHost),
 	)
+
+	if !config.AllowPrivateNetworks {
+		var resolver validators.Resolver
+		if config.ResolveURLHosts {
+			resolver = net.DefaultResolver
+		}
+		chain = append(chain, validators.NewNetworkValidator(resolver))
+	}
+
+	if config.BlocklistFile != "" {
+		blocklist, err := validators.NewBlocklistValidator(config.BlocklistFile, log)
+		if err != nil {
+			log.Errorw("Failed to load blocklist", "file", config.BlocklistFile, "error", err)
+		}
+		go blocklist.Watch(ctx, blocklistReloadInterval)
+		chain = append(chain, blocklist)
+	}
+
+	return chain
 }
 
-func getStorage(ctx context.Context, db *sql.DB, config *config.Config) storages.URLStorage {
-	if db != nil {
+// getStorage возвращает хранилище ссылок mode, передающее длительность операций observer.
+// Возвращает ошибку, если хранилище не удалось инициализировать.
+func getStorage(ctx context.Context, db *sql.DB, mode string, conf *config.Config, observer storages.OperationObserver) (storages.URLStorage, error) {
+	switch mode {
+	case config.StorageModePostgres:
+		if db == nil {
+			return nil, errDatabaseUnavailable
+		}
+
 		storage := storages.NewPostgresStorageStorage(db, "links")
-		err := storage.Init(ctx)
-		if err != nil {
-			return nil
+		if err := storage.Init(ctx); err != nil {
+			return nil, err
 		}
 
-		return storage
+		return storages.NewObservedStorage(storage, mode, observer), nil
+	case config.StorageModeFile:
+		return storages.NewObservedStorage(storages.NewFileStorage(conf.FileStoragePath), mode, observer), nil
+	default:
+		return storages.NewObservedStorage(storages.NewInMemoryStorage(), config.StorageModeMemory, observer), nil
+	}
+}
+
+func getUserStorage(ctx context.Context, db *sql.DB, mode string, conf *config.Config) (storages.UserStorage, error) {
+	switch {
+	case mode == config.StorageModePostgres && db != nil:
+		storage := storages.NewPostgresUserStorage(db, "users")
+		if err := storage.Init(ctx); err != nil {
+			return nil, err
+		}
+
+		return storage, nil
+	case mode == config.StorageModePostgres:
+		return nil, errDatabaseUnavailable
+	case mode == config.StorageModeFile && conf.UserStoragePath != "":
+		return storages.NewFileUserStorage(conf.UserStoragePath), nil
+	default:
+		return storages.NewInMemoryUserStorage(), nil
+	}
+}
+
+func getTokenStorage(ctx context.Context, db *sql.DB, mode string, conf *config.Config) (storages.TokenStorage, error) {
+	switch {
+	case mode == config.StorageModePostgres && db != nil:
+		storage := storages.NewPostgresTokenStorage(db, "api_tokens")
+		if err := storage.Init(ctx); err != nil {
+			return nil, err
+		}
+
+		return storage, nil
+	case mode == config.StorageModePostgres:
+		return nil, errDatabaseUnavailable
+	case mode == config.StorageModeFile && conf.TokenStoragePath != "":
+		return storages.NewFileTokenStorage(conf.TokenStoragePath), nil
+	default:
+		return storages.NewInMemoryTokenStorage(), nil
+	}
+}
+
+func getBanStorage(ctx context.Context, db *sql.DB, mode string, conf *config.Config) (storages.BanStorage, error) {
+	switch {
+	case mode == config.StorageModePostgres && db != nil:
+		storage := storages.NewPostgresBanStorage(db, "user_bans")
+		if err := storage.Init(ctx); err != nil {
+			return nil, err
+		}
+
+		return storage, nil
+	case mode == config.StorageModePostgres:
+		return nil, errDatabaseUnavailable
+	case mode == config.StorageModeFile && conf.BanStoragePath != "":
+		return storages.NewFileBanStorage(conf.BanStoragePath), nil
+	default:
+		return storages.NewInMemoryBanStorage(), nil
+	}
+}
+
+// flushClicksPeriodically записывает накопленные счетчики переходов A/B-ссылок до отмены ctx,
+// если хранилище накапливает их в памяти.
+func flushClicksPeriodically(ctx context.Context, storage storages.URLStorage, log *zap.SugaredLogger) {
+	flusher, ok := storage.(storages.ClickFlusher)
+	if !ok {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(clicksFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := flusher.FlushClicks(ctx); err != nil {
+					log.Errorw("Failed to flush variant clicks", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func getRateLimitStorage(ctx context.Context, db *sql.DB, config *config.Config, log *zap.SugaredLogger) storages.RateLimitStorage {
+	if config.RateLimitStore != "postgres" {
+		return storages.NewInMemoryRateLimitStorage()
+	}
+
+	if db == nil {
+		log.Warn("Rate limit store is postgres but database is not available, using memory")
+		return storages.NewInMemoryRateLimitStorage()
 	}
 
-	if config.FileStoragePath != "" {
-		return storages.NewFileStorage(config.FileStoragePath)
+	storage := storages.NewPostgresRateLimitStorage(db, "rate_limits")
+	if err := storage.Init(ctx); err != nil {
+		log.Errorw("Failed to init rate limit storage, using memory", "error", err)
+		return storages.NewInMemoryRateLimitStorage()
 	}
 
-	return storages.NewInMemoryStorage()
+	go func() {
+		ticker := time.NewTicker(rateLimitCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := storage.Cleanup(ctx, now); err != nil {
+					log.Errorw("Failed to clean up rate limits", "error", err)
+				}
+			}
+		}
+	}()
+
+	return storage
 }
 
-func getConfig() config.Config {
+func getConfig() (config.Config, error) {
 	configFilePath := getConfigFilePath()
 
 	var jsonProvider config.Provider
@@ -150,7 +540,7 @@ func getConfig() config.Config {
 		jsonProvider = config.NewJSONConfigProvider("")
 	}
 
-	return config.NewConfig(
+	return config.LoadConfig(
 		&config.DefaultProvider{},
 		jsonProvider,
 		config.NewFlagProvider(),
@@ -183,30 +573,364 @@ func getConfigFilePath() string {
 	return ""
 }
 
-func getDBConnection(config *config.Config) (*sql.DB, error) {
-	conn, err := sql.Open("pgx", config.DatabaseDSN)
+// getStorageMode определяет хранилище данных по настройкам. Если StorageMode не указан,
+// используется postgres при заданном DatabaseDSN, иначе file при заданном FileStoragePath, иначе memory.
+// Возвращает ошибку, если хранилище неизвестно или для него не хватает настроек, а в строгом
+// режиме - также если настройки рассчитаны на базу данных, а выбрано другое хранилище.
+func getStorageMode(conf *config.Config) (string, error) {
+	mode := conf.StorageMode
+	if mode == "" {
+		switch {
+		case strings.TrimSpace(conf.DatabaseDSN) != "":
+			mode = config.StorageModePostgres
+		case strings.TrimSpace(conf.FileStoragePath) != "":
+			mode = config.StorageModeFile
+		default:
+			mode = config.StorageModeMemory
+		}
+	}
+
+	switch mode {
+	case config.StorageModePostgres:
+		if strings.TrimSpace(conf.DatabaseDSN) == "" {
+			return "", fmt.Errorf("%w: postgres storage requires a database DSN", errStorageMisconfigured)
+		}
+	case config.StorageModeFile:
+		if strings.TrimSpace(conf.FileStoragePath) == "" {
+			return "", fmt.Errorf("%w: file storage requires a file storage path", errStorageMisconfigured)
+		}
+	case config.StorageModeMemory:
+	default:
+		return "", fmt.Errorf("%w: %q, expected memory, file or postgres", errUnknownStorageMode, mode)
+	}
+
+	if conf.StorageStrict && mode != config.StorageModePostgres {
+		if strings.TrimSpace(conf.DatabaseDSN) != "" {
+			return "", fmt.Errorf("%w: database DSN is set, but storage is %s", errUnexpectedStorage, mode)
+		}
+		if conf.RateLimitStore == config.StorageModePostgres {
+			return "", fmt.Errorf("%w: rate limit store is postgres, but storage is %s", errUnexpectedStorage, mode)
+		}
+	}
+
+	return mode, nil
+}
+
+// getStorageBackend выбирает хранилище данных и, если это postgres, подключается к базе данных.
+// Если база данных недоступна, возвращается ошибка. Файловое хранилище или хранилище в памяти
+// используются вместо нее, только если это явно разрешено StorageFallback и не включен строгий режим.
+func getStorageBackend(ctx context.Context, conf *config.Config, log *zap.SugaredLogger) (string, *sql.DB, error) {
+	mode, err := getStorageMode(conf)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
+	if mode != config.StorageModePostgres {
+		return mode, nil, nil
+	}
+
+	connection, err := getDBConnection(ctx, conf, log)
+	if err == nil {
+		return mode, connection, nil
+	}
 
-	if err = conn.PingContext(ctx); err != nil {
+	if !conf.StorageFallback || conf.StorageStrict {
+		return "", nil, fmt.Errorf("%w: %w", errDatabaseUnavailable, err)
+	}
+
+	mode = config.StorageModeMemory
+	if strings.TrimSpace(conf.FileStoragePath) != "" {
+		mode = config.StorageModeFile
+	}
+	log.Errorw("Database is unavailable, falling back to another storage as storage fallback is enabled",
+		"storage", mode, "error", err)
+
+	return mode, nil, nil
+}
+
+// getDBConnection подключается к базе данных. Неудачная попытка повторяется DatabaseConnectRetries раз
+// с паузой, которая начинается с DatabaseConnectBackoff и удваивается, но не превышает dbConnectMaxBackoff.
+func getDBConnection(ctx context.Context, conf *config.Config, log *zap.SugaredLogger) (*sql.DB, error) {
+	conn, err := sql.Open("pgx", conf.DatabaseDSN)
+	if err != nil {
 		return nil, err
 	}
 
-	return conn, nil
+	backoff := conf.DatabaseConnectBackoff
+	for attempt := 0; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+		err = conn.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+
+		if attempt >= conf.DatabaseConnectRetries || ctx.Err() != nil {
+			break
+		}
+
+		log.Warnw("Failed to connect to database, retrying", "attempt", attempt+1, "retry_in", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+		backoff = min(backoff*2, dbConnectMaxBackoff)
+	}
+
+	_ = conn.Close()
+
+	return nil, err
+}
+
+// getHealthChecker возвращает проверку готовности хранилища ссылок, его схемы в базе данных
+// и получателей событий аудита.
+func getHealthChecker(storage storages.URLStorage, connection *sql.DB, auditService *audit.AuditService) *health.Checker {
+	checker := health.NewChecker(healthCheckTimeout)
+
+	if storageChecker, ok := storage.(storages.HealthChecker); ok {
+		checker.Add("storage", storageChecker.CheckHealth)
+	}
+	if migrationChecker, ok := storage.(storages.MigrationChecker); ok && connection != nil {
+		checker.Add("migrations", migrationChecker.CheckMigrations)
+	}
+
+	// Отказ получателя аудита не мешает обслуживать запросы: события сохраняются в spool
+	for name := range auditService.Health() {
+		checker.AddOptional("audit."+name, func(_ context.Context) error {
+			return auditService.Health()[name]
+		})
+	}
+
+	return checker
 }
 
-func getAuditService(config *config.Config, log *zap.SugaredLogger) *audit.AuditService {
-	auditService := audit.NewAuditService(log)
+// getLoggerOptions возвращает параметры журнала приложения из конфигурации.
+func getLoggerOptions(conf *config.Config) logger.Options {
+	return logger.Options{
+		Level:              conf.LogLevel,
+		Format:             conf.LogFormat,
+		SamplingInitial:    conf.LogSamplingInitial,
+		SamplingThereafter: conf.LogSamplingThereafter,
+		File:               conf.LogFile,
+		MaxSizeMB:          conf.LogMaxSizeMB,
+		MaxBackups:         conf.LogMaxBackups,
+		MaxAge:             conf.LogMaxAge,
+	}
+}
 
-	auditService.AddFileObserver(config.AuditFile)
+// getTracer создает трассировщик с настроенным экспортером.
+// Если экспортер не указан, трассировка отключена и возвращается nil.
+func getTracer(conf *config.Config, log *zap.SugaredLogger) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch conf.TracingExporter {
+	case "":
+		return nil, nil
+	case config.TracingExporterOTLP:
+		otlpExporter, err := tracing.NewOTLPExporter(conf.TracingEndpoint, tracing.OTLPOptions{Timeout: 10 * time.Second})
+		if err != nil {
+			return nil, err
+		}
+		exporter = otlpExporter
+	case config.TracingExporterStdout:
+		exporter = tracing.NewStdoutExporter()
+	case config.TracingExporterFile:
+		fileExporter, err := tracing.NewFileExporter(conf.TracingFile)
+		if err != nil {
+			return nil, err
+		}
+		exporter = fileExporter
+	default:
+		return nil, fmt.Errorf("%w: %q", tracing.ErrUnknownExporter, conf.TracingExporter)
+	}
 
-	auditService.AddHTTPObserver(config.AuditURL)
+	opts := tracing.DefaultOptions()
+	opts.SampleRatio = conf.TracingSampleRatio
 
-	return auditService
+	return tracing.NewTracer(tracingServiceName, exporter, opts, log)
+}
+
+// getAuditStorage возвращает хранилище событий аудита для поиска через административный API
+// или nil, если сохранение событий отключено.
+func getAuditStorage(ctx context.Context, db *sql.DB, mode string, conf *config.Config, log *zap.SugaredLogger) storages.AuditStorage {
+	if !conf.AuditStore {
+		return nil
+	}
+
+	retention := storages.AuditRetention{
+		MaxEvents: conf.AuditStoreMaxEvents,
+		MaxAge:    conf.AuditStoreMaxAge,
+	}
+
+	switch {
+	case mode == config.StorageModePostgres && db != nil:
+		storage := storages.NewPostgresAuditStorage(db, "audit_events", retention)
+		if err := storage.Init(ctx); err != nil {
+			log.Errorw("Failed to init audit storage, audit search is disabled", "error", err)
+			return nil
+		}
+
+		return storage
+	case mode == config.StorageModeFile && conf.AuditStorePath != "":
+		return storages.NewFileAuditStorage(conf.AuditStorePath, retention)
+	default:
+		return storages.NewInMemoryAuditStorage(retention)
+	}
+}
+
+func getAuditService(config *config.Config, store storages.AuditStorage, log *zap.SugaredLogger) (*audit.AuditService, error) {
+	options := audit.DefaultDeliveryOptions()
+	options.QueueSize = config.AuditQueueSize
+	options.BatchSize = config.AuditBatchSize
+	options.FlushInterval = config.AuditFlushInterval
+	options.MaxRetries = config.AuditMaxRetries
+	options.RetryBackoff = config.AuditRetryBackoff
+	options.SpoolDir = config.AuditSpoolDir
+	options.DeliveryTimeout = config.AuditDeliveryTimeout
+
+	auditService := audit.NewAuditService(log, options)
+
+	fileOptions := audit.FileOptions{
+		Chain:   config.AuditChain,
+		HMACKey: []byte(config.AuditHMACKey),
+		Rotation: audit.RotationOptions{
+			MaxSize:    int64(config.AuditMaxSizeMB) << 20,
+			Interval:   config.AuditRotateInterval,
+			Compress:   config.AuditCompress,
+			MaxBackups: config.AuditMaxBackups,
+			MaxAge:     config.AuditMaxAge,
+		},
+	}
+	if store != nil {
+		err := auditService.AddStoreObserver("store", store)
+		if actions := strings.FieldsFunc(config.AuditStoreActions, isListSeparator); err == nil && len(actions) > 0 {
+			var filter *audit.Filter
+			filter, err = audit.NewFilter(actions, nil, nil, 0)
+			if err == nil {
+				err = auditService.SetFilter("store", filter)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("audit store: %w", err)
+		}
+	}
+
+	for _, sink := range auditSinks(config) {
+		err := addAuditSink(auditService, sink, fileOptions, config)
+		if err == nil {
+			err = setAuditFilter(auditService, sink)
+		}
+		if err != nil {
+			_ = auditService.Close(context.Background())
+			return nil, fmt.Errorf("audit sink %q: %w", sink.Name, err)
+		}
+	}
+
+	return auditService, nil
+}
+
+// isListSeparator разделяет значения в списках настроек: запятые и пробелы.
+func isListSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
+}
+
+// auditSinks возвращает получателей аудита: AuditFile и AuditURL, затем список AuditSinks.
+// Имя получателя по умолчанию совпадает с его типом.
+func auditSinks(conf *config.Config) []config.AuditSink {
+	var sinks []config.AuditSink
+	if conf.AuditFile != "" {
+		sinks = append(sinks, config.AuditSink{Type: config.AuditSinkFile, Path: conf.AuditFile})
+	}
+	if conf.AuditURL != "" {
+		sinks = append(sinks, config.AuditSink{Type: config.AuditSinkHTTP, URL: conf.AuditURL})
+	}
+	sinks = append(sinks, conf.AuditSinks...)
+
+	for i := range sinks {
+		if sinks[i].Name == "" {
+			sinks[i].Name = sinks[i].Type
+		}
+	}
+
+	return sinks
+}
+
+func addAuditSink(auditService *audit.AuditService, sink config.AuditSink, fileOptions audit.FileOptions, conf *config.Config) error {
+	switch sink.Type {
+	case config.AuditSinkFile:
+		if sink.Path == "" {
+			return errors.New("path is required")
+		}
+		return auditService.AddFileObserver(sink.Name, sink.Path, fileOptions)
+	case config.AuditSinkHTTP:
+		if sink.URL == "" {
+			return errors.New("url is required")
+		}
+		timeout := sink.Timeout
+		if timeout == 0 {
+			timeout = conf.AuditHTTPTimeout
+		}
+		return auditService.AddHTTPObserver(sink.Name, sink.URL, audit.HTTPOptions{
+			Timeout: timeout,
+			HMACKey: []byte(sink.HMACKey),
+			Headers: sink.Headers,
+		})
+	case config.AuditSinkSyslog:
+		if sink.Address == "" {
+			return errors.New("address is required")
+		}
+		return auditService.AddSyslogObserver(sink.Name, sink.Address, audit.SyslogOptions{
+			Network:  sink.Network,
+			Facility: sink.Facility,
+			AppName:  sink.AppName,
+			Timeout:  sink.Timeout,
+		})
+	case config.AuditSinkTCP:
+		if sink.Address == "" {
+			return errors.New("address is required")
+		}
+		return auditService.AddTCPObserver(sink.Name, sink.Address, sink.Timeout)
+	default:
+		return fmt.Errorf("unknown sink type %q", sink.Type)
+	}
+}
+
+// setAuditFilter задает фильтр событий получателя, если в его настройках есть условия отбора.
+func setAuditFilter(auditService *audit.AuditService, sink config.AuditSink) error {
+	if len(sink.Actions) == 0 && len(sink.UserIDs) == 0 && len(sink.URLPatterns) == 0 && sink.SampleRate == 0 {
+		return nil
+	}
+
+	filter, err := audit.NewFilter(sink.Actions, sink.UserIDs, sink.URLPatterns, sink.SampleRate)
+	if err != nil {
+		return err
+	}
+
+	return auditService.SetFilter(sink.Name, filter)
+}
+
+// reopenAuditOnSIGHUP заново открывает файл аудита по сигналу SIGHUP,
+// чтобы внешняя ротация (logrotate) не требовала перезапуска сервиса.
+func reopenAuditOnSIGHUP(ctx context.Context, auditService *audit.AuditService, log *zap.SugaredLogger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := auditService.Reopen(); err != nil {
+					log.Errorw("Failed to reopen audit file", "error", err)
+				} else {
+					log.Info("Audit file reopened")
+				}
+			}
+		}
+	}()
 }
 
 func printBuildInfo() {
@@ -3,18 +3,43 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/sviatilnik/url-shortener/internal/app/admin"
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/config"
 	"github.com/sviatilnik/url-shortener/internal/app/generators"
 	"github.com/sviatilnik/url-shortener/internal/app/handlers"
+	"github.com/sviatilnik/url-shortener/internal/app/health"
+	"github.com/sviatilnik/url-shortener/internal/app/metrics"
+	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
 	"github.com/sviatilnik/url-shortener/internal/app/shortener"
 	"github.com/sviatilnik/url-shortener/internal/app/storages"
+	"github.com/sviatilnik/url-shortener/internal/app/tokens"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+	"github.com/sviatilnik/url-shortener/internal/app/users"
 )
 
 var testBaseURL = "http://my-awesome-shotener.com/"
@@ -214,4 +239,1261 @@ func TestRedirectToFullLinkHandler(t *testing.T) {
 
 func Test_getShortener(t *testing.T) {
 	assert.IsType(t, &shortener.Shortener{}, getTestShortener())
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr error
+	}{
+		{name: "#1 default policy", policy: ""},
+		{name: "#2 valid policy", policy: "append"},
+		{name: "#3 invalid policy", policy: "apend", wantErr: shortener.ErrInvalidQueryPolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &config.Config{ShortURLHost: testBaseURL, RedirectQueryPolicy: tt.policy}
+			got, err := getShortener(conf, storages.NewInMemoryStorage(), nil, nil, nil)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestRedirectToFullLinkHandler_Variants(t *testing.T) {
+	shorter := getTestShortener()
+
+	short, err := shorter.CreateLink(context.Background(), models.Link{
+		Destinations: []models.Destination{
+			{URL: "http://a.example.com", Weight: 1},
+			{URL: "http://b.example.com", Weight: 1},
+		},
+	})
+	assert.NoError(t, err)
+	shortCode := strings.Replace(short, testBaseURL, "", 1)
+
+	handler := handlers.RedirectToFullLinkHandler(shorter)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, short, nil)
+	r.SetPathValue("short_code", shortCode)
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	cookies := resp.Cookies()
+	assert.Len(t, cookies, 1)
+	location := resp.Header.Get("Location")
+
+	// Повторный переход с cookie ведет на тот же вариант
+	for i := 0; i < 10; i++ {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, short, nil)
+		r.SetPathValue("short_code", shortCode)
+		r.AddCookie(cookies[0])
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, location, w.Header().Get("Location"))
+		assert.Empty(t, w.Result().Cookies())
+	}
+
+	link, err := shorter.GetFullLinkByShortCode(context.Background(), shortCode)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), link.Destinations[0].Clicks+link.Destinations[1].Clicks)
+}
+
+func TestUserAccountHandlers(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	shorter := getTestShortener()
+	userService := users.NewService(storages.NewInMemoryUserStorage(), shorter, nil)
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+
+	r := chi.NewRouter()
+	r.Use(authMiddleware.Auth)
+	r.With(authMiddleware.IssueAnonymous).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+	r.With(authMiddleware.Require).Get("/api/user/urls", handlers.UserURLsHandler(shorter))
+	r.Post("/api/user/register", handlers.RegisterHandler(userService, authMiddleware))
+	r.Post("/api/user/login", handlers.LoginHandler(userService, authMiddleware))
+	r.Post("/api/user/logout", handlers.LogoutHandler(authMiddleware))
+
+	do := func(method, target, body, token string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		r.ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	// Анонимный пользователь создает ссылку
+	resp := do(http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	anonymousToken := resp.Header.Get("Authorization")
+	assert.NotEmpty(t, anonymousToken)
+
+	// Регистрация выдает новый токен и привязывает анонимные ссылки
+	credentials := `{"email":"user@example.com","password":"password123"}`
+	resp = do(http.MethodPost, "/api/user/register", credentials, anonymousToken)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	userToken := resp.Header.Get("Authorization")
+	assert.NotEmpty(t, userToken)
+	assert.NotEqual(t, anonymousToken, userToken)
+
+	resp = do(http.MethodGet, "/api/user/urls", "", userToken)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "http://google.com")
+
+	resp = do(http.MethodGet, "/api/user/urls", "", anonymousToken)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/api/user/register", credentials, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/api/user/login", `{"email":"user@example.com","password":"wrong-password"}`, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Вход с другого устройства возвращает тот же идентификатор пользователя
+	resp = do(http.MethodPost, "/api/user/login", credentials, "")
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/api/user/urls", "", resp.Header.Get("Authorization"))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	account := struct {
+		Email string `json:"email"`
+	}{}
+	assert.NoError(t, json.Unmarshal(body, &account))
+	assert.Equal(t, "user@example.com", account.Email)
+
+	resp = do(http.MethodPost, "/api/user/logout", "", userToken)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Len(t, resp.Cookies(), 1)
+	assert.Empty(t, resp.Cookies()[0].Value)
+}
+
+func TestAPITokenHandlers(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	shorter := getTestShortener()
+	tokenService := tokens.NewService(storages.NewInMemoryTokenStorage())
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, tokenService)
+
+	r := chi.NewRouter()
+	r.Use(authMiddleware.Auth)
+	r.With(authMiddleware.IssueAnonymous, middlewares.RequireScope(models.ScopeLinksWrite)).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+	r.With(authMiddleware.Require, middlewares.RequireScope(models.ScopeLinksRead)).Get("/api/user/urls", handlers.UserURLsHandler(shorter))
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.Require, middlewares.RequireSession)
+		r.Post("/api/user/tokens", handlers.CreateTokenHandler(tokenService, nil))
+		r.Get("/api/user/tokens", handlers.UserTokensHandler(tokenService))
+		r.Delete("/api/user/tokens/{token_id}", handlers.RevokeTokenHandler(tokenService))
+	})
+
+	do := func(method, target, body, authorization string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	resp := do(http.MethodGet, "/api/user/tokens", "", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	session := resp.Header.Get("Authorization")
+
+	// Создаем токен только на чтение
+	resp = do(http.MethodPost, "/api/user/tokens", `{"name":"ci","scopes":["links:read"]}`, session)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	created := struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}{}
+	assert.NoError(t, json.Unmarshal(body, &created))
+	bearer := "Bearer " + created.Token
+
+	resp = do(http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`, session)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/api/user/urls", "", bearer)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "http://google.com")
+
+	resp = do(http.MethodPost, "/api/shorten", `{"url":"http://ya.ru"}`, bearer)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Токен не может управлять токенами
+	resp = do(http.MethodPost, "/api/user/tokens", `{"name":"other","scopes":["links:write"]}`, bearer)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/api/user/tokens", "", session)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"last_used_at"`)
+	assert.NotContains(t, string(body), created.Token)
+
+	resp = do(http.MethodDelete, "/api/user/tokens/"+created.ID, "", session)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/api/user/urls", "", bearer)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStrictAuth(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true, AuthStrict: true}
+	shorter := getTestShortener()
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+
+	r := chi.NewRouter()
+	r.Use(authMiddleware.Auth)
+	r.Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
+	r.With(authMiddleware.IssueAnonymous).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+	r.With(authMiddleware.Require).Get("/api/user/urls", handlers.UserURLsHandler(shorter))
+
+	expiredKeys, err := middlewares.NewKeySet(middlewares.NewHMACKey("", []byte("secret")))
+	assert.NoError(t, err)
+	expired, err := expiredKeys.Sign(middlewares.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))},
+		UserID:           "user",
+	})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		target        string
+		body          string
+		authorization string
+		cookie        string
+		expectedCode  int
+		expectedError string
+		expectIssued  bool
+	}{
+		{
+			name:         "#1 anonymous identity is issued on link creation",
+			method:       http.MethodPost,
+			target:       "/api/shorten",
+			body:         `{"url":"http://google.com"}`,
+			expectedCode: http.StatusCreated,
+			expectIssued: true,
+		},
+		{
+			name:          "#2 invalid header on link creation",
+			method:        http.MethodPost,
+			target:        "/api/shorten",
+			body:          `{"url":"http://google.com"}`,
+			authorization: "garbage",
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: middlewares.AuthErrorInvalidToken,
+		},
+		{
+			name:          "#3 expired cookie on link creation",
+			method:        http.MethodPost,
+			target:        "/api/shorten",
+			body:          `{"url":"http://google.com"}`,
+			cookie:        expired,
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: middlewares.AuthErrorTokenExpired,
+		},
+		{
+			name:          "#4 protected route without token",
+			method:        http.MethodGet,
+			target:        "/api/user/urls",
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: middlewares.AuthErrorMissingToken,
+		},
+		{
+			name:          "#5 protected route with expired token",
+			method:        http.MethodGet,
+			target:        "/api/user/urls",
+			authorization: "Bearer " + expired,
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: middlewares.AuthErrorTokenExpired,
+		},
+		{
+			name:          "#6 public route ignores invalid token",
+			method:        http.MethodGet,
+			target:        "/unknown",
+			authorization: "garbage",
+			expectedCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "Authorization", Value: test.cookie})
+			}
+			r.ServeHTTP(w, req)
+
+			resp := w.Result()
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			assert.Equal(t, test.expectedCode, resp.StatusCode)
+			assert.Equal(t, test.expectIssued, resp.Header.Get("Authorization") != "")
+
+			if test.expectedError != "" {
+				authErr := middlewares.AuthError{}
+				assert.NoError(t, json.Unmarshal(body, &authErr))
+				assert.Equal(t, test.expectedError, authErr.Code)
+				assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	storage := storages.NewInMemoryStorage()
+	shorter := shortener.NewShortener(storage, generators.NewRandomGenerator(10), shortener.NewShortenerConfig(testBaseURL))
+	tokenService := tokens.NewService(storages.NewInMemoryTokenStorage())
+	adminService := admin.NewService(storage, storages.NewInMemoryBanStorage(), storages.NewInMemoryAuditStorage(storages.AuditRetention{}), nil, []string{"admin"})
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, tokenService)
+
+	r := chi.NewRouter()
+	r.Use(authMiddleware.Auth)
+	r.Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
+	r.With(authMiddleware.IssueAnonymous, middlewares.RejectBanned(adminService, zap.NewNop().Sugar())).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+	r.With(authMiddleware.Require, middlewares.RequireSession).Post("/api/user/tokens", handlers.CreateTokenHandler(tokenService, adminService))
+	r.Group(func(r chi.Router) {
+		r.Use(authMiddleware.Require, middlewares.RequireAdmin(adminService))
+		r.Get("/api/admin/links", handlers.AdminSearchLinksHandler(adminService))
+		r.Post("/api/admin/links/{short_code}/disable", handlers.AdminSetLinkDisabledHandler(adminService, true))
+		r.Delete("/api/admin/links/{short_code}", handlers.AdminDeleteLinkHandler(adminService))
+		r.Post("/api/admin/users/{user_id}/ban", handlers.AdminBanUserHandler(adminService))
+		r.Get("/api/admin/stats", handlers.AdminStatsHandler(adminService))
+	})
+
+	keys, err := middlewares.NewKeySet(middlewares.NewHMACKey("", []byte("secret")))
+	assert.NoError(t, err)
+	sign := func(userID string) string {
+		token, err := keys.Sign(middlewares.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			UserID:           userID,
+		})
+		assert.NoError(t, err)
+
+		return token
+	}
+	adminToken, userToken := sign("admin"), sign("user")
+
+	do := func(method, target, body, authorization string) (*http.Response, string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+
+		resp := w.Result()
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		return resp, string(respBody)
+	}
+
+	resp, body := do(http.MethodPost, "/api/shorten", `{"url":"http://spam.example.com/offer"}`, userToken)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	created := struct {
+		Result string `json:"result"`
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &created))
+	shortCode := created.Result[strings.LastIndex(created.Result, "/")+1:]
+
+	// Обычный пользователь не имеет доступа к административному API
+	resp, _ = do(http.MethodGet, "/api/admin/stats", "", userToken)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, body = do(http.MethodGet, "/api/admin/links?domain=example.com&user_id=user", "", adminToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"short_code":"`+shortCode+`"`)
+
+	resp, _ = do(http.MethodGet, "/api/admin/links?limit=-1", "", adminToken)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, _ = do(http.MethodPost, "/api/admin/links/"+shortCode+"/disable", "", adminToken)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/"+shortCode, "", "")
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+
+	resp, _ = do(http.MethodPost, "/api/admin/links/unknown/disable", "", adminToken)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = do(http.MethodPost, "/api/admin/users/admin/ban", "", adminToken)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, _ = do(http.MethodPost, "/api/admin/users/user/ban", `{"reason":"spam"}`, adminToken)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, body = do(http.MethodPost, "/api/shorten", `{"url":"http://spam.example.com/other"}`, userToken)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, body, middlewares.AuthErrorUserBanned)
+
+	resp, _ = do(http.MethodDelete, "/api/admin/links/"+shortCode, "", adminToken)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, body = do(http.MethodGet, "/api/admin/stats", "", adminToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	stats := admin.Stats{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &stats))
+	assert.Equal(t, &models.LinkStats{Total: 1, Deleted: 1, Users: 1}, stats.Links)
+	assert.Equal(t, 1, stats.BannedUsers)
+
+	// Разрешение admin выдается только администраторам
+	resp, _ = do(http.MethodPost, "/api/user/tokens", `{"name":"ops","scopes":["admin"]}`, sign("other"))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, body = do(http.MethodPost, "/api/user/tokens", `{"name":"ops","scopes":["admin"]}`, adminToken)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	apiToken := struct {
+		Token string `json:"token"`
+	}{}
+	assert.NoError(t, json.Unmarshal([]byte(body), &apiToken))
+
+	resp, _ = do(http.MethodGet, "/api/admin/stats", "", "Bearer "+apiToken.Token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// После исключения пользователя из списка администраторов его токен с разрешением admin не действует
+	withoutAdmin := admin.NewService(storage, storages.NewInMemoryBanStorage(), nil, nil, nil)
+	r = chi.NewRouter()
+	r.Use(authMiddleware.Auth, authMiddleware.Require, middlewares.RequireAdmin(withoutAdmin))
+	r.Get("/api/admin/stats", handlers.AdminStatsHandler(withoutAdmin))
+
+	resp, _ = do(http.MethodGet, "/api/admin/stats", "", "Bearer "+apiToken.Token)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAdminAuditAPI(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	auditStorage := storages.NewInMemoryAuditStorage(storages.AuditRetention{})
+	auditService := audit.NewAuditService(zap.NewNop().Sugar(), audit.DefaultDeliveryOptions())
+	auditService.AddObserver(audit.NewStoreObserver(auditStorage))
+
+	storage := storages.NewInMemoryStorage()
+	shortenerConf := shortener.NewShortenerConfig(testBaseURL)
+	shortenerConf.Auditor = auditService
+	shorter := shortener.NewShortener(storage, generators.NewRandomGenerator(10), shortenerConf)
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+
+	newRouter := func(events storages.AuditStorage) http.Handler {
+		adminService := admin.NewService(storage, storages.NewInMemoryBanStorage(), events, auditService, []string{"admin"})
+
+		r := chi.NewRouter()
+		r.Use(authMiddleware.Auth, middlewares.NewAuditMiddleware(auditService, false).Audit)
+		r.With(authMiddleware.IssueAnonymous).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+		r.With(authMiddleware.Require, middlewares.RequireAdmin(adminService)).Get("/api/admin/audit", handlers.AdminSearchAuditHandler(adminService))
+
+		return r
+	}
+	r := newRouter(auditStorage)
+
+	keys, err := middlewares.NewKeySet(middlewares.NewHMACKey("", []byte("secret")))
+	require.NoError(t, err)
+	sign := func(userID string) string {
+		token, err := keys.Sign(middlewares.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			UserID:           userID,
+		})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	do := func(r http.Handler, target, body, authorization string) (*http.Response, string) {
+		w := httptest.NewRecorder()
+		method := http.MethodGet
+		if body != "" {
+			method = http.MethodPost
+		}
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		req.Header.Set(middlewares.RequestIDHeader, "req-1")
+		r.ServeHTTP(w, req)
+
+		resp := w.Result()
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		return resp, string(respBody)
+	}
+
+	for _, url := range []string{"http://example.com/a", "http://example.com/b", "http://other.example.org/"} {
+		resp, _ := do(r, "/api/shorten", `{"url":"`+url+`"}`, sign("user"))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	resp, _ := do(r, "/api/shorten", `{"url":"http://example.com/c"}`, sign("someone"))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantURLs   []string
+	}{
+		{name: "#1 by user and action", query: "?user_id=user&action=shorten", wantStatus: http.StatusOK, wantURLs: []string{"http://other.example.org/", "http://example.com/b", "http://example.com/a"}},
+		{name: "#2 by url", query: "?url=EXAMPLE.COM/", wantStatus: http.StatusOK, wantURLs: []string{"http://example.com/c", "http://example.com/b", "http://example.com/a"}},
+		{name: "#3 page", query: "?user_id=user&limit=1&offset=1", wantStatus: http.StatusOK, wantURLs: []string{"http://example.com/b"}},
+		{name: "#4 future period", query: "?from=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339), wantStatus: http.StatusOK, wantURLs: []string{}},
+		{name: "#5 invalid time", query: "?from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "#6 inverted period", query: "?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", wantStatus: http.StatusBadRequest},
+		{name: "#7 invalid limit", query: "?limit=5000", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(r, "/api/admin/audit"+tt.query, "", sign("admin"))
+			require.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var events []*audit.AuditEvent
+			require.NoError(t, json.Unmarshal([]byte(body), &events))
+			urls := make([]string, 0, len(events))
+			for _, event := range events {
+				urls = append(urls, event.URL)
+				assert.Equal(t, "req-1", event.RequestID)
+			}
+			assert.Equal(t, tt.wantURLs, urls)
+		})
+	}
+
+	// Поиск по аудиту доступен только администраторам и сам записывается в аудит
+	resp, _ = do(r, "/api/admin/audit", "", sign("user"))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	events, err := auditStorage.SearchEvents(context.Background(), audit.Query{Action: audit.ActionAdmin, UserID: "admin"})
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, audit.AdminSearchAudit, events[0].Operation)
+
+	resp, _ = do(newRouter(nil), "/api/admin/audit", "", sign("admin"))
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestAdminLogLevelAPI(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret"}
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
+
+	newRouter := func(withControl bool) http.Handler {
+		adminService := admin.NewService(storages.NewInMemoryStorage(), storages.NewInMemoryBanStorage(), nil, nil, []string{"admin"})
+		if withControl {
+			adminService.SetLogLevelControl(&level)
+		}
+
+		r := chi.NewRouter()
+		r.Use(authMiddleware.Auth, authMiddleware.Require, middlewares.RequireAdmin(adminService))
+		r.Get("/api/admin/log/level", handlers.AdminLogLevelHandler(adminService))
+		r.Put("/api/admin/log/level", handlers.AdminChangeLogLevelHandler(adminService))
+
+		return r
+	}
+
+	keys, err := middlewares.NewKeySet(middlewares.NewHMACKey("", []byte("secret")))
+	require.NoError(t, err)
+	sign := func(userID string) string {
+		token, err := keys.Sign(middlewares.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			UserID:           userID,
+		})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	tests := []struct {
+		name        string
+		withControl bool
+		method      string
+		body        string
+		userID      string
+		wantStatus  int
+		wantBody    string
+		wantLevel   zapcore.Level
+	}{
+		{name: "#1 get level", withControl: true, method: http.MethodGet, userID: "admin", wantStatus: http.StatusOK, wantBody: `{"level":"info"}`, wantLevel: zap.InfoLevel},
+		{name: "#2 change level", withControl: true, method: http.MethodPut, body: `{"level":"debug"}`, userID: "admin", wantStatus: http.StatusOK, wantBody: `{"level":"debug"}`, wantLevel: zap.DebugLevel},
+		{name: "#3 unknown level", withControl: true, method: http.MethodPut, body: `{"level":"verbose"}`, userID: "admin", wantStatus: http.StatusBadRequest, wantLevel: zap.DebugLevel},
+		{name: "#4 invalid body", withControl: true, method: http.MethodPut, body: `level=warn`, userID: "admin", wantStatus: http.StatusBadRequest, wantLevel: zap.DebugLevel},
+		{name: "#5 not admin", withControl: true, method: http.MethodPut, body: `{"level":"error"}`, userID: "user", wantStatus: http.StatusForbidden, wantLevel: zap.DebugLevel},
+		{name: "#6 control unavailable", method: http.MethodPut, body: `{"level":"error"}`, userID: "admin", wantStatus: http.StatusNotImplemented, wantLevel: zap.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/api/admin/log/level", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", sign(tt.userID))
+			newRouter(tt.withControl).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+			assert.Equal(t, tt.wantLevel, level.Level())
+		})
+	}
+}
+
+func TestUserQuotaHandler(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+
+	newRouter := func(limit int) http.Handler {
+		shortenerConf := shortener.NewShortenerConfig(testBaseURL)
+		shortenerConf.Quota.Limit = limit
+		shorter := shortener.NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), shortenerConf)
+
+		r := chi.NewRouter()
+		r.Use(authMiddleware.Auth)
+		r.With(authMiddleware.IssueAnonymous).Post("/", handlers.GetShortLinkHandler(shorter))
+		r.With(authMiddleware.IssueAnonymous).Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+		r.With(authMiddleware.IssueAnonymous).Post("/api/shorten/batch", handlers.BatchShortLinkHandler(shorter))
+		r.With(authMiddleware.Require).Get("/api/user/quota", handlers.UserQuotaHandler(shorter))
+
+		return r
+	}
+
+	do := func(r http.Handler, method, target, body, authorization string) (*http.Response, string) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+
+		resp := w.Result()
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		return resp, string(respBody)
+	}
+
+	r := newRouter(1)
+
+	resp, _ := do(r, http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`, "")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	token := resp.Header.Get("Authorization")
+
+	resp, _ = do(r, http.MethodPost, "/api/shorten", `{"url":"http://ya.ru"}`, token)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = do(r, http.MethodPost, "/", "http://ya.ru", token)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = do(r, http.MethodPost, "/api/shorten/batch", `[{"correlation_id":"1","original_url":"http://ya.ru"}]`, token)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, body := do(r, http.MethodGet, "/api/user/quota", "", token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"limit":1,"used":1,"remaining":0}`, body)
+
+	// Без квоты лимит не указывается
+	resp, body = do(newRouter(0), http.MethodGet, "/api/user/quota", "", token)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"limit":null,"used":0,"remaining":null}`, body)
+}
+
+func TestCreateRateLimit_AnonymousCookies(t *testing.T) {
+	conf := &config.Config{AuthSecret: "secret", AuthIssueAnonymous: true}
+	authMiddleware := middlewares.NewAuthMiddleware(conf, zap.NewNop().Sugar(), nil, nil)
+	userStorage := storages.NewInMemoryUserStorage()
+	userService := users.NewService(userStorage, nil, nil)
+	shorter := getTestShortener()
+	rateLimiter := middlewares.NewRateLimiter(storages.NewInMemoryRateLimitStorage(), zap.NewNop().Sugar(), false)
+
+	r := chi.NewRouter()
+	r.Use(authMiddleware.Auth)
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimiter.Limit(middlewares.RateLimitCreate, models.RateLimit{Limit: 3, Period: time.Minute}))
+		r.Use(authMiddleware.IssueAnonymous)
+		r.Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+	})
+	r.Post("/api/user/register", handlers.RegisterHandler(userService, authMiddleware))
+	r.With(authMiddleware.Require).Get("/api/user/urls", handlers.UserURLsHandler(shorter))
+
+	do := func(method, target, body, remoteAddr, authorization string) *http.Response {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		r.ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	// Каждый запрос предъявляет новый анонимный токен, полученный через GET /api/user/urls
+	statuses := make([]int, 0, 5)
+	for i := range 5 {
+		token := do(http.MethodGet, "/api/user/urls", "", "203.0.113.1:1234", "").Header.Get("Authorization")
+		require.NotEmpty(t, token)
+
+		resp := do(http.MethodPost, "/api/shorten", fmt.Sprintf(`{"url":"http://example.com/%d"}`, i), "203.0.113.1:1234", token)
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests, http.StatusTooManyRequests}, statuses)
+
+	// Другой IP-адрес имеет собственную корзину
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"http://example.com/other"}`, "203.0.113.2:1234", "").StatusCode)
+
+	// Зарегистрированный пользователь учитывается по идентификатору, а не по IP-адресу
+	resp := do(http.MethodPost, "/api/user/register", `{"email":"user@example.com","password":"password123"}`, "203.0.113.1:1234", "")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	token := resp.Header.Get("Authorization")
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/shorten", `{"url":"http://example.com/registered"}`, "203.0.113.1:1234", token).StatusCode)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.RegisterRuntime(registry)
+
+	conf := &config.Config{ShortURLHost: testBaseURL}
+	storage, err := getStorage(context.Background(), nil, config.StorageModeMemory, conf, metrics.NewStorageMetrics(registry))
+	require.NoError(t, err)
+	shorter, err := getShortener(conf, storage, nil, nil, metrics.NewShortenerMetrics(registry))
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(middlewares.NewMetricsMiddleware(registry).Measure)
+	r.Get("/metrics", registry.Handler().ServeHTTP)
+	r.Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
+	r.Post("/api/shorten", handlers.APIShortLinkHandler(shorter))
+
+	do := func(method, target, body string) *http.Response {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return w.Result()
+	}
+
+	resp := do(http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`)
+	var result struct {
+		Result string `json:"result"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = do(http.MethodPost, "/api/shorten", `{"url":"not a url"}`)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = do(http.MethodGet, strings.TrimPrefix(result.Result, strings.TrimSuffix(testBaseURL, "/")), "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/metrics", "")
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, metrics.ContentType, resp.Header.Get("Content-Type"))
+
+	lines := strings.Split(string(body), "\n")
+	assert.Contains(t, lines, `shortener_links_created_total{result="success"} 1`)
+	assert.Contains(t, lines, `shortener_links_created_total{result="error"} 1`)
+	assert.Contains(t, lines, `shortener_redirects_total 1`)
+	assert.Contains(t, lines, `shortener_http_requests_total{method="POST",route="/api/shorten",code="201"} 1`)
+	assert.Contains(t, lines, `shortener_http_requests_total{method="POST",route="/api/shorten",code="400"} 1`)
+	assert.Contains(t, lines, `shortener_http_requests_total{method="GET",route="/{short_code}",code="307"} 1`)
+	assert.Contains(t, lines, `shortener_storage_operation_duration_seconds_count{backend="memory",operation="get"} 1`)
+	assert.Contains(t, string(body), "# TYPE go_goroutines gauge")
+}
+
+func TestTracing(t *testing.T) {
+	tracesFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	conf := &config.Config{
+		ShortURLHost:       testBaseURL,
+		TracingExporter:    config.TracingExporterFile,
+		TracingFile:        tracesFile,
+		TracingSampleRatio: 1,
+	}
+	tracer, err := getTracer(conf, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	storage, err := getStorage(context.Background(), nil, config.StorageModeMemory, conf, nil)
+	require.NoError(t, err)
+	shorter, err := getShortener(conf, storage, nil, nil, nil)
+	require.NoError(t, err)
+	link, err := shorter.CreateLink(context.Background(), models.Link{OriginalURL: "http://google.com"})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(middlewares.NewTracingMiddleware(tracer).Trace)
+	r.Get("/{short_code}", handlers.RedirectToFullLinkHandler(shorter))
+
+	req := httptest.NewRequest(http.MethodGet, strings.TrimPrefix(link, strings.TrimSuffix(testBaseURL, "/")), nil)
+	req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	type span struct {
+		TraceID      string         `json:"trace_id"`
+		SpanID       string         `json:"span_id"`
+		ParentSpanID string         `json:"parent_span_id"`
+		Kind         string         `json:"kind"`
+		Attributes   map[string]any `json:"attributes"`
+	}
+	data, err := os.ReadFile(tracesFile)
+	require.NoError(t, err)
+	spans := make(map[string]span)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record struct {
+			span
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		spans[record.Name] = record.span
+	}
+
+	server, ok := spans["GET /{short_code}"]
+	require.True(t, ok, spans)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID)
+	assert.Equal(t, "server", server.Kind)
+	assert.Equal(t, float64(http.StatusTemporaryRedirect), server.Attributes["http.response.status_code"])
+
+	get, ok := spans["Shortener.GetFullLinkByShortCode"]
+	require.True(t, ok, spans)
+	assert.Equal(t, server.SpanID, get.ParentSpanID)
+
+	storageGet, ok := spans["storage.get"]
+	require.True(t, ok, spans)
+	assert.Equal(t, get.SpanID, storageGet.ParentSpanID)
+	assert.Equal(t, "memory", storageGet.Attributes["storage.backend"])
+
+	follow, ok := spans["Shortener.RegisterFollow"]
+	require.True(t, ok, spans)
+	assert.Equal(t, server.SpanID, follow.ParentSpanID)
+
+	// Ссылка создана вне запроса, поэтому ее операции не трассируются
+	assert.NotContains(t, spans, "Shortener.CreateLink")
+
+	_, err = getTracer(&config.Config{TracingExporter: "zipkin"}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, err, tracing.ErrUnknownExporter)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	conf := config.NewConfig(&config.DefaultProvider{})
+	conf.FileStoragePath = filepath.Join(t.TempDir(), "links.jsonl")
+	conf.AuditFile = ""
+	conf.AuditURL = collector.URL
+	conf.AuditSpoolDir = t.TempDir()
+	conf.AuditBatchSize = 1
+	conf.AuditMaxRetries = 0
+	log := zap.NewNop().Sugar()
+
+	auditService, err := getAuditService(&conf, nil, log)
+	require.NoError(t, err)
+	defer auditService.Close(context.Background())
+	storage, err := getStorage(context.Background(), nil, config.StorageModeFile, &conf, nil)
+	require.NoError(t, err)
+	checker := getHealthChecker(storage, nil, auditService)
+
+	r := chi.NewRouter()
+	r.Get("/healthz", handlers.LivenessHandler(checker))
+	r.Get("/readyz", handlers.ReadinessHandler(checker))
+
+	get := func(target string) (int, health.Report) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+
+		return w.Code, report
+	}
+	failed := func(report health.Report) []string {
+		var names []string
+		for name, status := range report.Components {
+			if status.Status != health.StatusOK {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		return names
+	}
+
+	status, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Contains(t, report.Components, "storage")
+	assert.Contains(t, report.Components, "audit.http")
+	assert.NotContains(t, report.Components, "migrations")
+
+	// Получатель аудита не принимает события: сервис работает с ограничениями, но остается готовым
+	auditService.Emit(context.Background(), audit.NewAuditEvent(audit.ActionShorten, "user", "http://example.com"))
+	require.Eventually(t, func() bool {
+		_, report := get("/readyz")
+		return report.Status == health.StatusDegraded
+	}, time.Second, 5*time.Millisecond)
+
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"audit.http"}, failed(report))
+	assert.Equal(t, health.StatusDegraded, report.Components["audit.http"].Status)
+	assert.NotEmpty(t, report.Components["audit.http"].Error)
+
+	status, report = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+
+	// Во время остановки сервис сообщает о неготовности
+	shutdown(&http.Server{}, checker, storages.NewInMemoryStorage(), auditService, nil, nil, &conf, log)
+	status, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, failed(report), health.ShutdownComponent)
+}
+
+func TestShutdown_DeliversAuditEventsOnSIGTERM(t *testing.T) {
+	const requests = 20
+
+	var mutex sync.Mutex
+	received := make(map[string]struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Медленный получатель: к моменту остановки в очереди остаются недоставленные события
+		time.Sleep(20 * time.Millisecond)
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var events []*audit.AuditEvent
+		if json.Unmarshal(body, &events) != nil {
+			event := new(audit.AuditEvent)
+			assert.NoError(t, json.Unmarshal(body, event))
+			events = []*audit.AuditEvent{event}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, event := range events {
+			received[event.ID] = struct{}{}
+		}
+	}))
+	defer collector.Close()
+
+	conf := config.NewConfig(&config.DefaultProvider{})
+	conf.AuditFile = ""
+	conf.AuditURL = collector.URL
+	conf.AuditSpoolDir = t.TempDir()
+	conf.AuditBatchSize = 3
+	log := zap.NewNop().Sugar()
+
+	auditService, err := getAuditService(&conf, nil, log)
+	require.NoError(t, err)
+	shorter, err := getShortener(&conf, storages.NewInMemoryStorage(), nil, auditService, nil)
+	require.NoError(t, err)
+
+	started := make(chan struct{}, requests)
+	release := make(chan struct{})
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuditMiddleware(auditService, false).Audit)
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		handlers.GetShortLinkHandler(shorter)(w, r)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http.Server{Handler: r}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(ctx, server, listener, false)
+	}()
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post("http://"+listener.Addr().String()+"/", "text/plain", strings.NewReader(fmt.Sprintf("http://example.com/%d", i)))
+			if assert.NoError(t, err) {
+				statuses <- resp.StatusCode
+				resp.Body.Close()
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		<-started
+	}
+
+	// Все запросы находятся в обработке, когда процесс получает SIGTERM
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	require.NoError(t, <-served)
+
+	// Обработчики завершаются уже во время остановки сервера
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	shutdown(server, health.NewChecker(0), storages.NewInMemoryStorage(), auditService, nil, nil, &conf, log)
+
+	wg.Wait()
+	close(statuses)
+	assert.Len(t, statuses, requests)
+	for status := range statuses {
+		assert.Equal(t, http.StatusCreated, status)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Len(t, received, requests)
+
+	stats := auditService.DeliveryStats()["http"]
+	assert.Equal(t, uint64(requests), stats.Delivered)
+	assert.Equal(t, uint64(0), stats.Spooled)
+	assert.Equal(t, uint64(0), stats.Dropped)
+}
+
+func TestRunVerify(t *testing.T) {
+	dir := t.TempDir()
+	conf := config.NewConfig(&config.DefaultProvider{})
+	conf.AuditFile = filepath.Join(dir, "audit.log")
+	conf.AuditHMACKey = "secret"
+
+	observer, err := audit.NewFileAuditObserver(conf.AuditFile, audit.FileOptions{Chain: true, HMACKey: []byte(conf.AuditHMACKey)}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, observer.Notify(context.Background(), audit.NewAuditEvent(audit.ActionShorten, "user", "http://example.com")))
+	}
+	require.NoError(t, observer.Close())
+
+	// Цепочка, разбитая ротацией на несколько сжатых файлов
+	rotatedFile := filepath.Join(dir, "rotated.log")
+	observer, err = audit.NewFileAuditObserver(rotatedFile, audit.FileOptions{
+		Chain:    true,
+		HMACKey:  []byte(conf.AuditHMACKey),
+		Rotation: audit.RotationOptions{MaxSize: 1, Compress: true},
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, observer.Notify(context.Background(), audit.NewAuditEvent(audit.ActionShorten, "user", "http://example.com")))
+	}
+	require.NoError(t, observer.Close())
+
+	data, err := os.ReadFile(conf.AuditFile)
+	require.NoError(t, err)
+	tampered := filepath.Join(dir, "tampered.log")
+	require.NoError(t, os.WriteFile(tampered, []byte(strings.Replace(string(data), "example.com", "evil.com", 1)), 0644))
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOutput string
+	}{
+		{name: "#1 configured file", wantCode: 0, wantOutput: "3 records, chain intact"},
+		{name: "#2 tampered file", args: []string{tampered}, wantCode: 1, wantOutput: "chain broken at line 1"},
+		{name: "#3 wrong key", args: []string{"-hmac-key", "other"}, wantCode: 1, wantOutput: "hmac"},
+		{name: "#4 missing file", args: []string{filepath.Join(dir, "missing.log")}, wantCode: 2},
+		{name: "#5 rotated files", args: []string{"-all", rotatedFile}, wantCode: 0, wantOutput: "3 records, chain intact"},
+		{name: "#6 last segment only", args: []string{rotatedFile}, wantCode: 1, wantOutput: "previous hash"},
+		{name: "#7 partial segment", args: []string{"-partial", rotatedFile}, wantCode: 0, wantOutput: "1 records, chain intact"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := runVerify(tt.args, &conf, &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code)
+			assert.Contains(t, stdout.String(), tt.wantOutput)
+		})
+	}
+}
+
+func TestGetAuditService_Sinks(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		auditFile string
+		sinks     []config.AuditSink
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "#1 legacy file and sinks list",
+			auditFile: filepath.Join(dir, "audit.log"),
+			sinks: []config.AuditSink{
+				{Type: config.AuditSinkSyslog, Address: "127.0.0.1:514"},
+				{Name: "collector", Type: config.AuditSinkTCP, Address: "127.0.0.1:5170"},
+				{Name: "siem", Type: config.AuditSinkHTTP, URL: "http://127.0.0.1:1", HMACKey: "key"},
+			},
+			wantNames: []string{"file", "syslog", "collector", "siem"},
+		},
+		{
+			name:    "#2 unknown type",
+			sinks:   []config.AuditSink{{Type: "kafka", Address: "127.0.0.1:9092"}},
+			wantErr: "unknown sink type",
+		},
+		{
+			name:    "#3 missing address",
+			sinks:   []config.AuditSink{{Type: config.AuditSinkTCP}},
+			wantErr: "address is required",
+		},
+		{
+			name:      "#4 duplicate name",
+			auditFile: filepath.Join(dir, "duplicate.log"),
+			sinks:     []config.AuditSink{{Type: config.AuditSinkFile, Path: filepath.Join(dir, "other.log")}},
+			wantErr:   audit.ErrDuplicateObserver.Error(),
+		},
+		{
+			name:    "#5 invalid url pattern",
+			sinks:   []config.AuditSink{{Type: config.AuditSinkTCP, Address: "127.0.0.1:5170", URLPatterns: []string{"("}}},
+			wantErr: "некорректный шаблон URL",
+		},
+		{
+			name:    "#6 invalid syslog facility",
+			sinks:   []config.AuditSink{{Type: config.AuditSinkSyslog, Address: "127.0.0.1:514", Facility: "local9"}},
+			wantErr: audit.ErrUnknownFacility.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.NewConfig(&config.DefaultProvider{})
+			conf.AuditFile = tt.auditFile
+			conf.AuditSinks = tt.sinks
+			conf.AuditSpoolDir = ""
+
+			auditService, err := getAuditService(&conf, nil, zap.NewNop().Sugar())
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer auditService.Close(context.Background())
+
+			stats := auditService.DeliveryStats()
+			assert.Len(t, stats, len(tt.wantNames))
+			for _, name := range tt.wantNames {
+				assert.Contains(t, stats, name)
+			}
+		})
+	}
+}
+
+func TestGetStorageMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     config.Config
+		wantMode string
+		wantErr  error
+	}{
+		{name: "#1 auto postgres", conf: config.Config{DatabaseDSN: "postgres://db", FileStoragePath: "store"}, wantMode: config.StorageModePostgres},
+		{name: "#2 auto file", conf: config.Config{FileStoragePath: "store"}, wantMode: config.StorageModeFile},
+		{name: "#3 auto memory", conf: config.Config{}, wantMode: config.StorageModeMemory},
+		{name: "#4 explicit memory", conf: config.Config{StorageMode: config.StorageModeMemory, FileStoragePath: "store"}, wantMode: config.StorageModeMemory},
+		{name: "#5 unknown mode", conf: config.Config{StorageMode: "redis"}, wantErr: errUnknownStorageMode},
+		{name: "#6 postgres without DSN", conf: config.Config{StorageMode: config.StorageModePostgres}, wantErr: errStorageMisconfigured},
+		{name: "#7 file without path", conf: config.Config{StorageMode: config.StorageModeFile}, wantErr: errStorageMisconfigured},
+		{name: "#8 DSN ignored", conf: config.Config{StorageMode: config.StorageModeFile, FileStoragePath: "store", DatabaseDSN: "postgres://db"}, wantMode: config.StorageModeFile},
+		{name: "#9 strict DSN ignored", conf: config.Config{StorageMode: config.StorageModeFile, FileStoragePath: "store", DatabaseDSN: "postgres://db", StorageStrict: true}, wantErr: errUnexpectedStorage},
+		{name: "#10 strict postgres rate limits", conf: config.Config{StorageMode: config.StorageModeMemory, RateLimitStore: "postgres", StorageStrict: true}, wantErr: errUnexpectedStorage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := getStorageMode(&tt.conf)
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+func TestGetStorageBackend_DatabaseUnavailable(t *testing.T) {
+	// На этом адресе никто не принимает соединения
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dsn := "postgres://user:password@" + listener.Addr().String() + "/db?sslmode=disable&connect_timeout=1"
+	require.NoError(t, listener.Close())
+
+	tests := []struct {
+		name     string
+		mode     string
+		strict   bool
+		fallback bool
+		wantMode string
+		wantErr  error
+	}{
+		{name: "#1 DSN set", wantErr: errDatabaseUnavailable},
+		{name: "#2 explicit postgres", mode: config.StorageModePostgres, wantErr: errDatabaseUnavailable},
+		{name: "#3 fallback to file", fallback: true, wantMode: config.StorageModeFile},
+		{name: "#4 explicit postgres with fallback", mode: config.StorageModePostgres, fallback: true, wantMode: config.StorageModeFile},
+		{name: "#5 strict overrides fallback", strict: true, fallback: true, wantErr: errDatabaseUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{
+				StorageMode:            tt.mode,
+				StorageStrict:          tt.strict,
+				StorageFallback:        tt.fallback,
+				DatabaseDSN:            dsn,
+				FileStoragePath:        filepath.Join(t.TempDir(), "links.jsonl"),
+				DatabaseConnectRetries: 2,
+				DatabaseConnectBackoff: time.Millisecond,
+			}
+			core, logs := observer.New(zap.WarnLevel)
+
+			mode, connection, err := getStorageBackend(context.Background(), &conf, zap.New(core).Sugar())
+			require.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantMode, mode)
+			assert.Nil(t, connection)
+			assert.Equal(t, 2, logs.FilterMessage("Failed to connect to database, retrying").Len())
+		})
+	}
+
+	_, err = getStorage(context.Background(), nil, config.StorageModePostgres, &config.Config{}, nil)
+	assert.ErrorIs(t, err, errDatabaseUnavailable)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/config"
+	"github.com/sviatilnik/url-shortener/internal/app/rotation"
+)
+
+// verifyCommand - имя подкоманды проверки цепочки хешей файла аудита.
+const verifyCommand = "verify"
+
+// getVerifyConfig собирает конфигурацию для подкоманды verify.
+// Флаги сервера не разбираются: у подкоманды собственный набор флагов.
+func getVerifyConfig() (config.Config, error) {
+	return config.LoadConfig(
+		&config.DefaultProvider{},
+		config.NewJSONConfigProvider(getConfigFilePath()),
+		config.NewEnvProvider(&config.OSEnvGetter{}),
+	)
+}
+
+// runVerify проверяет цепочку хешей файлов аудита и сообщает о первой нарушенной записи.
+// Использование: shortener verify [-hmac-key KEY] [-all] [-partial] [FILE...].
+// Файлы проверяются как одна цепочка в переданном порядке; по умолчанию проверяется файл
+// и ключ из конфигурации. С флагом -all перед файлом проверяются его ротированные файлы.
+// Возвращает код завершения: 0 - цепочка не нарушена, 1 - цепочка нарушена,
+// 2 - проверку выполнить не удалось.
+func runVerify(args []string, conf *config.Config, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(verifyCommand, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	key := flags.String("hmac-key", conf.AuditHMACKey, "HMAC key of audit records")
+	all := flags.Bool("all", false, "Verify rotated files before the file")
+	partial := flags.Bool("partial", false, "Accept prev_hash of the first record (older files were removed)")
+	// Путь к файлу конфигурации уже учтен в conf
+	flags.String("c", "", "Path to config file")
+	flags.String("config", "", "Path to config file")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{conf.AuditFile}
+	}
+
+	if *all {
+		rotated, err := rotation.Files(paths[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", paths[0], err)
+			return 2
+		}
+		paths = append(rotated, paths...)
+	}
+
+	verifier := audit.NewChainVerifier(audit.NewChain([]byte(*key)))
+	verifier.Partial = *partial
+
+	for _, path := range paths {
+		err := verifyFile(verifier, path)
+
+		var chainErr *audit.ChainError
+		if errors.As(err, &chainErr) {
+			fmt.Fprintf(stdout, "%s: chain broken at line %d: %v (%d valid records before it)\n", path, chainErr.Line, chainErr.Err, verifier.Records)
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			return 2
+		}
+	}
+
+	fmt.Fprintf(stdout, "%s: %d records, chain intact\n", strings.Join(paths, ", "), verifier.Records)
+	return 0
+}
+
+// verifyFile проверяет записи одного файла; сжатые gzip файлы распаковываются.
+func verifyFile(verifier *audit.ChainVerifier, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	return verifier.Verify(r)
+}
@@ -0,0 +1,14 @@
+package admin
+
+import "errors"
+
+var (
+	ErrInvalidFilter  = errors.New("invalid link filter")
+	ErrInvalidUser    = errors.New("invalid user id")
+	ErrCannotBanAdmin = errors.New("administrators cannot be banned")
+	ErrNotFound       = errors.New("not found")
+	ErrAuditDisabled  = errors.New("audit store is disabled")
+
+	ErrInvalidLogLevel     = errors.New("invalid log level")
+	ErrLogLevelUnavailable = errors.New("log level control is unavailable")
+)
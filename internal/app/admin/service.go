@@ -0,0 +1,408 @@
+// Package admin реализует административные операции: поиск и модерацию ссылок,
+// блокировку пользователей и общую статистику. Все операции записываются в аудит.
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+const (
+	// DefaultSearchLimit задает размер страницы поиска, если он не указан.
+	DefaultSearchLimit = 100
+	// MaxSearchLimit задает максимальный размер страницы поиска.
+	MaxSearchLimit = 1000
+)
+
+// Auditor записывает события аудита.
+type Auditor interface {
+	Emit(ctx context.Context, event *audit.AuditEvent)
+}
+
+// DeliveryStatsProvider возвращает показатели доставки событий аудита.
+// Если Auditor реализует этот интерфейс, показатели включаются в Stats.
+type DeliveryStatsProvider interface {
+	DeliveryStats() map[string]audit.DeliveryStats
+}
+
+// LogLevel управляет уровнем журнала приложения во время работы.
+// Ему соответствует *zap.AtomicLevel.
+type LogLevel interface {
+	String() string
+	UnmarshalText(text []byte) error
+}
+
+// Stats содержит общие показатели сервиса.
+type Stats struct {
+	Links       *models.LinkStats              `json:"links"`           // Показатели ссылок
+	BannedUsers int                            `json:"banned_users"`    // Количество заблокированных пользователей
+	Audit       map[string]audit.DeliveryStats `json:"audit,omitempty"` // Показатели доставки аудита по получателям
+}
+
+// Service выполняет административные операции.
+type Service struct {
+	links    storages.URLStorage
+	bans     storages.BanStorage
+	events   storages.AuditStorage
+	auditor  Auditor
+	logLevel LogLevel
+	adminIDs map[string]struct{}
+	now      func() time.Time
+}
+
+// NewService создает административный сервис.
+// adminIDs содержит идентификаторы пользователей с ролью администратора.
+// auditor может быть nil, тогда операции не записываются.
+// events может быть nil, тогда поиск событий аудита недоступен.
+func NewService(links storages.URLStorage, bans storages.BanStorage, events storages.AuditStorage, auditor Auditor, adminIDs []string) *Service {
+	ids := make(map[string]struct{}, len(adminIDs))
+	for _, id := range adminIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = struct{}{}
+		}
+	}
+
+	return &Service{
+		links:    links,
+		bans:     bans,
+		events:   events,
+		auditor:  auditor,
+		adminIDs: ids,
+		now:      time.Now,
+	}
+}
+
+// SetLogLevelControl подключает управление уровнем журнала.
+// Без него LogLevel и ChangeLogLevel возвращают ErrLogLevelUnavailable.
+func (s *Service) SetLogLevelControl(level LogLevel) {
+	s.logLevel = level
+}
+
+// IsAdmin проверяет, выполняется ли запрос администратором.
+// Пользователь должен быть указан в настройках; запрос с API-токеном, кроме того,
+// должен иметь разрешение models.ScopeAdmin. Поэтому исключение пользователя из списка
+// администраторов сразу лишает прав и выпущенные им токены.
+func (s *Service) IsAdmin(ctx context.Context) bool {
+	if token, ok := ctx.Value(models.ContextAPIToken).(*models.APIToken); ok {
+		return token.HasScope(models.ScopeAdmin) && s.isAdminID(token.UserID)
+	}
+
+	userID, _ := ctx.Value(models.ContextUserID).(string)
+
+	return s.isAdminID(userID)
+}
+
+func (s *Service) isAdminID(userID string) bool {
+	_, ok := s.adminIDs[userID]
+
+	return ok && userID != ""
+}
+
+// IsBanned проверяет, заблокирован ли пользователь.
+func (s *Service) IsBanned(ctx context.Context, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	_, err := s.bans.GetBan(ctx, userID)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SearchLinks ищет ссылки всех пользователей.
+// Если размер страницы не указан, используется DefaultSearchLimit.
+// Возвращает ErrInvalidFilter при отрицательных или слишком больших Limit и Offset.
+func (s *Service) SearchLinks(ctx context.Context, adminID string, filter models.LinkFilter) ([]*models.Link, error) {
+	if filter.Limit < 0 || filter.Limit > MaxSearchLimit || filter.Offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultSearchLimit
+	}
+
+	links, err := s.links.SearchLinks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx, adminID, audit.AdminSearchLinks, searchTarget(filter))
+
+	return links, nil
+}
+
+// SetLinkDisabled блокирует или разблокирует ссылку любого пользователя.
+// Заблокированная ссылка не перенаправляет посетителей, но остается у владельца.
+// Возвращает ErrNotFound, если ссылка не найдена.
+func (s *Service) SetLinkDisabled(ctx context.Context, adminID, shortCode string, disabled bool) error {
+	err := s.links.SetLinkDisabled(ctx, shortCode, disabled)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	operation := audit.AdminEnableLink
+	if disabled {
+		operation = audit.AdminDisableLink
+	}
+	s.logLink(ctx, adminID, operation, shortCode, "")
+
+	return nil
+}
+
+// DeleteLink удаляет ссылку любого пользователя (soft delete).
+// Возвращает ErrNotFound, если ссылка не найдена.
+func (s *Service) DeleteLink(ctx context.Context, adminID, shortCode string) error {
+	err := s.links.DeleteLink(ctx, shortCode)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logLink(ctx, adminID, audit.AdminDeleteLink, shortCode, "")
+
+	return nil
+}
+
+// BanUser блокирует пользователя. Если disableLinks равен true,
+// блокируются также все его ссылки.
+// Возможные ошибки:
+//   - ErrInvalidUser - не указан идентификатор пользователя
+//   - ErrCannotBanAdmin - пользователь является администратором
+func (s *Service) BanUser(ctx context.Context, adminID, userID, reason string, disableLinks bool) error {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return ErrInvalidUser
+	}
+
+	if s.isAdminID(userID) {
+		return ErrCannotBanAdmin
+	}
+
+	err := s.bans.BanUser(ctx, &models.Ban{
+		UserID:    userID,
+		Reason:    strings.TrimSpace(reason),
+		BannedBy:  adminID,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log(ctx, adminID, audit.AdminBanUser, userID)
+
+	if !disableLinks {
+		return nil
+	}
+
+	links, err := s.links.SearchLinks(ctx, models.LinkFilter{UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if link.IsDeleted || link.IsDisabled {
+			continue
+		}
+
+		if err = s.links.SetLinkDisabled(ctx, link.ShortCode, true); err != nil {
+			return err
+		}
+
+		s.logLink(ctx, adminID, audit.AdminDisableLink, link.ShortCode, link.OriginalURL)
+	}
+
+	return nil
+}
+
+// UnbanUser снимает блокировку пользователя. Ссылки пользователя остаются заблокированными.
+// Возвращает ErrNotFound, если пользователь не заблокирован.
+func (s *Service) UnbanUser(ctx context.Context, adminID, userID string) error {
+	err := s.bans.UnbanUser(ctx, userID)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.log(ctx, adminID, audit.AdminUnbanUser, userID)
+
+	return nil
+}
+
+// Stats возвращает общие показатели сервиса.
+func (s *Service) Stats(ctx context.Context, adminID string) (*Stats, error) {
+	links, err := s.links.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	banned, err := s.bans.CountBans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx, adminID, audit.AdminViewStats, "")
+
+	stats := &Stats{
+		Links:       links,
+		BannedUsers: banned,
+	}
+	if provider, ok := s.auditor.(DeliveryStatsProvider); ok {
+		stats.Audit = provider.DeliveryStats()
+	}
+
+	return stats, nil
+}
+
+// SearchAudit ищет сохраненные события аудита, от новых к старым.
+// Если размер страницы не указан, используется DefaultSearchLimit.
+// Возможные ошибки:
+//   - ErrInvalidFilter - неверные Limit и Offset или начало периода позже его конца
+//   - ErrAuditDisabled - хранилище событий аудита не настроено
+func (s *Service) SearchAudit(ctx context.Context, adminID string, query audit.Query) ([]*audit.AuditEvent, error) {
+	if query.Limit < 0 || query.Limit > MaxSearchLimit || query.Offset < 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return nil, ErrInvalidFilter
+	}
+
+	if s.events == nil {
+		return nil, ErrAuditDisabled
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultSearchLimit
+	}
+
+	events, err := s.events.SearchEvents(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx, adminID, audit.AdminSearchAudit, auditTarget(query))
+
+	return events, nil
+}
+
+// LogLevel возвращает текущий уровень журнала приложения.
+// Возвращает ErrLogLevelUnavailable, если управление уровнем не подключено.
+func (s *Service) LogLevel(_ context.Context, _ string) (string, error) {
+	if s.logLevel == nil {
+		return "", ErrLogLevelUnavailable
+	}
+
+	return s.logLevel.String(), nil
+}
+
+// ChangeLogLevel меняет уровень журнала приложения без перезапуска.
+// Новый уровень сразу действует на все запросы, в том числе уже выполняющиеся.
+// Возможные ошибки:
+//   - ErrInvalidLogLevel - неизвестный уровень
+//   - ErrLogLevelUnavailable - управление уровнем не подключено
+func (s *Service) ChangeLogLevel(ctx context.Context, adminID, level string) error {
+	if s.logLevel == nil {
+		return ErrLogLevelUnavailable
+	}
+
+	level = strings.ToLower(strings.TrimSpace(level))
+	if level == "" {
+		return ErrInvalidLogLevel
+	}
+
+	previous := s.logLevel.String()
+	if err := s.logLevel.UnmarshalText([]byte(level)); err != nil {
+		return ErrInvalidLogLevel
+	}
+
+	s.log(ctx, adminID, audit.AdminChangeLogLevel, previous+"->"+s.logLevel.String())
+
+	return nil
+}
+
+// log записывает административную операцию adminID над объектом target.
+func (s *Service) log(ctx context.Context, adminID, operation, target string) {
+	s.emit(ctx, adminEvent(adminID, operation, target, ""))
+}
+
+// logLink записывает административную операцию adminID над ссылкой shortCode.
+// url содержит оригинальный URL ссылки, если он известен.
+func (s *Service) logLink(ctx context.Context, adminID, operation, shortCode, url string) {
+	event := adminEvent(adminID, operation, shortCode, url)
+	event.ShortCode = shortCode
+	s.emit(ctx, event)
+}
+
+func (s *Service) emit(ctx context.Context, event *audit.AuditEvent) {
+	if s.auditor != nil {
+		s.auditor.Emit(ctx, event)
+	}
+}
+
+func adminEvent(adminID, operation, target, url string) *audit.AuditEvent {
+	event := audit.NewAuditEvent(audit.ActionAdmin, adminID, url)
+	event.Operation = operation
+	event.Target = target
+
+	return event
+}
+
+// searchTarget описывает условия поиска для записи в аудит.
+func searchTarget(filter models.LinkFilter) string {
+	var parts []string
+	if filter.URL != "" {
+		parts = append(parts, "url="+filter.URL)
+	}
+	if filter.Domain != "" {
+		parts = append(parts, "domain="+filter.Domain)
+	}
+	if filter.UserID != "" {
+		parts = append(parts, "user_id="+filter.UserID)
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// auditTarget описывает условия поиска событий аудита для записи в аудит.
+func auditTarget(query audit.Query) string {
+	var parts []string
+	if query.UserID != "" {
+		parts = append(parts, "user_id="+query.UserID)
+	}
+	if query.Action != "" {
+		parts = append(parts, "action="+query.Action)
+	}
+	if query.ShortCode != "" {
+		parts = append(parts, "short_code="+query.ShortCode)
+	}
+	if query.URL != "" {
+		parts = append(parts, "url="+query.URL)
+	}
+	if !query.From.IsZero() {
+		parts = append(parts, "from="+query.From.UTC().Format(time.RFC3339))
+	}
+	if !query.To.IsZero() {
+		parts = append(parts, "to="+query.To.UTC().Format(time.RFC3339))
+	}
+
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,232 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+type recordedEvent struct {
+	adminID, operation, target string
+}
+
+type fakeAuditor struct {
+	events []recordedEvent
+}
+
+func (f *fakeAuditor) Emit(_ context.Context, event *audit.AuditEvent) {
+	f.events = append(f.events, recordedEvent{adminID: event.UserID, operation: event.Operation, target: event.Target})
+}
+
+func TestService_IsAdmin(t *testing.T) {
+	s := NewService(storages.NewInMemoryStorage(), storages.NewInMemoryBanStorage(), nil, nil, []string{"admin", " "})
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		admin bool
+	}{
+		{
+			name:  "#1 configured user",
+			ctx:   context.WithValue(context.Background(), models.ContextUserID, "admin"),
+			admin: true,
+		},
+		{
+			name:  "#2 regular user",
+			ctx:   context.WithValue(context.Background(), models.ContextUserID, "user"),
+			admin: false,
+		},
+		{
+			name:  "#3 anonymous",
+			ctx:   context.WithValue(context.Background(), models.ContextUserID, ""),
+			admin: false,
+		},
+		{
+			name: "#4 token with admin scope",
+			ctx: context.WithValue(context.Background(), models.ContextAPIToken,
+				&models.APIToken{UserID: "admin", Scopes: []string{models.ScopeAdmin}}),
+			admin: true,
+		},
+		{
+			name: "#5 admin scope token of user removed from admins",
+			ctx: context.WithValue(context.Background(), models.ContextAPIToken,
+				&models.APIToken{UserID: "ops", Scopes: []string{models.ScopeAdmin}}),
+			admin: false,
+		},
+		{
+			name: "#6 admin token without admin scope",
+			ctx: context.WithValue(context.WithValue(context.Background(), models.ContextUserID, "admin"),
+				models.ContextAPIToken, &models.APIToken{UserID: "admin", Scopes: []string{models.ScopeLinksRead}}),
+			admin: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.admin, s.IsAdmin(tt.ctx))
+		})
+	}
+}
+
+func TestService_BanUser(t *testing.T) {
+	ctx := context.Background()
+	links := storages.NewInMemoryStorage()
+	require.NoError(t, links.BatchSave(ctx, []*models.Link{
+		{ID: "a", ShortCode: "a", OriginalURL: "http://spam.example.com/1", UserID: "spammer"},
+		{ID: "b", ShortCode: "b", OriginalURL: "http://spam.example.com/2", UserID: "spammer"},
+		{ID: "c", ShortCode: "c", OriginalURL: "http://example.org", UserID: "user"},
+	}))
+
+	auditor := &fakeAuditor{}
+	s := NewService(links, storages.NewInMemoryBanStorage(), nil, auditor, []string{"admin"})
+
+	assert.ErrorIs(t, s.BanUser(ctx, "admin", " ", "", false), ErrInvalidUser)
+	assert.ErrorIs(t, s.BanUser(ctx, "admin", "admin", "", false), ErrCannotBanAdmin)
+	require.NoError(t, s.BanUser(ctx, "admin", "spammer", "spam", true))
+
+	banned, err := s.IsBanned(ctx, "spammer")
+	require.NoError(t, err)
+	assert.True(t, banned)
+
+	banned, err = s.IsBanned(ctx, "user")
+	require.NoError(t, err)
+	assert.False(t, banned)
+
+	found, err := s.SearchLinks(ctx, "admin", models.LinkFilter{Domain: "example.com"})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	for _, link := range found {
+		assert.True(t, link.IsDisabled)
+	}
+
+	stats, err := s.Stats(ctx, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, &models.LinkStats{Total: 3, Active: 1, Disabled: 2, Users: 2}, stats.Links)
+	assert.Equal(t, 1, stats.BannedUsers)
+
+	require.NoError(t, s.UnbanUser(ctx, "admin", "spammer"))
+	assert.ErrorIs(t, s.UnbanUser(ctx, "admin", "spammer"), ErrNotFound)
+
+	assert.Equal(t, []recordedEvent{
+		{adminID: "admin", operation: audit.AdminBanUser, target: "spammer"},
+		{adminID: "admin", operation: audit.AdminDisableLink, target: "a"},
+		{adminID: "admin", operation: audit.AdminDisableLink, target: "b"},
+		{adminID: "admin", operation: audit.AdminSearchLinks, target: "domain=example.com"},
+		{adminID: "admin", operation: audit.AdminViewStats, target: ""},
+		{adminID: "admin", operation: audit.AdminUnbanUser, target: "spammer"},
+	}, auditor.events)
+}
+
+func TestService_SearchLinks(t *testing.T) {
+	ctx := context.Background()
+	links := storages.NewInMemoryStorage()
+	for _, code := range []string{"d", "b", "c", "a"} {
+		_, err := links.Save(ctx, &models.Link{ID: code, ShortCode: code, OriginalURL: "http://" + code + ".example.com", UserID: "user"})
+		require.NoError(t, err)
+	}
+	s := NewService(links, storages.NewInMemoryBanStorage(), nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		filter  models.LinkFilter
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "#1 all links ordered",
+			filter: models.LinkFilter{},
+			want:   []string{"a", "b", "c", "d"},
+		},
+		{
+			name:   "#2 page",
+			filter: models.LinkFilter{Limit: 2, Offset: 1},
+			want:   []string{"b", "c"},
+		},
+		{
+			name:   "#3 offset past the end",
+			filter: models.LinkFilter{Offset: 10},
+			want:   []string{},
+		},
+		{
+			name:   "#4 url substring",
+			filter: models.LinkFilter{URL: "C.EXAMPLE"},
+			want:   []string{"c"},
+		},
+		{
+			name:   "#5 exact domain",
+			filter: models.LinkFilter{Domain: "d.example.com"},
+			want:   []string{"d"},
+		},
+		{
+			name:   "#6 domain is not a suffix match",
+			filter: models.LinkFilter{Domain: "ample.com"},
+			want:   []string{},
+		},
+		{
+			name:    "#7 limit too large",
+			filter:  models.LinkFilter{Limit: MaxSearchLimit + 1},
+			wantErr: ErrInvalidFilter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := s.SearchLinks(ctx, "admin", tt.filter)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			codes := make([]string, 0, len(found))
+			for _, link := range found {
+				codes = append(codes, link.ShortCode)
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
+
+func TestService_ChangeLogLevel(t *testing.T) {
+	auditor := &fakeAuditor{}
+	level := zap.NewAtomicLevel()
+	s := NewService(storages.NewInMemoryStorage(), storages.NewInMemoryBanStorage(), nil, auditor, []string{"admin"})
+	s.SetLogLevelControl(&level)
+
+	tests := []struct {
+		name      string
+		level     string
+		wantErr   error
+		wantLevel string
+	}{
+		{name: "#1 debug", level: "debug", wantLevel: "debug"},
+		{name: "#2 case and spaces", level: " WARN ", wantLevel: "warn"},
+		{name: "#3 unknown level", level: "verbose", wantErr: ErrInvalidLogLevel, wantLevel: "warn"},
+		{name: "#4 empty level", level: "", wantErr: ErrInvalidLogLevel, wantLevel: "warn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ChangeLogLevel(context.Background(), "admin", tt.level)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			current, err := s.LogLevel(context.Background(), "admin")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLevel, current)
+		})
+	}
+
+	assert.Equal(t, []recordedEvent{
+		{adminID: "admin", operation: audit.AdminChangeLogLevel, target: "info->debug"},
+		{adminID: "admin", operation: audit.AdminChangeLogLevel, target: "debug->warn"},
+	}, auditor.events)
+
+	withoutControl := NewService(storages.NewInMemoryStorage(), storages.NewInMemoryBanStorage(), nil, nil, nil)
+	_, err := withoutControl.LogLevel(context.Background(), "admin")
+	assert.ErrorIs(t, err, ErrLogLevelUnavailable)
+	assert.ErrorIs(t, withoutControl.ChangeLogLevel(context.Background(), "admin", "debug"), ErrLogLevelUnavailable)
+}
@@ -0,0 +1,196 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ChainRecord - запись файла аудита в режиме цепочки хешей.
+// Hash вычисляется как SHA-256 от PrevHash и JSON-представления события, поэтому изменение,
+// удаление или перестановка любой записи нарушает цепочку начиная с этой записи.
+// Если задан ключ, HMAC содержит HMAC-SHA256 от Hash: без ключа нельзя пересчитать
+// цепочку после изменения записей.
+type ChainRecord struct {
+	AuditEvent
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+	HMAC     string `json:"hmac,omitempty"`
+}
+
+// Chain формирует записи цепочки хешей.
+type Chain struct {
+	key []byte
+}
+
+// NewChain создает цепочку; key задает ключ HMAC (пусто - без HMAC).
+func NewChain(key []byte) *Chain {
+	return &Chain{key: key}
+}
+
+// Seal создает запись события, продолжающую цепочку после записи с хешем prevHash.
+// Первая запись цепочки имеет пустой prevHash.
+func (c *Chain) Seal(event *AuditEvent, prevHash string) (*ChainRecord, error) {
+	hash, err := chainHash(event, prevHash)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &ChainRecord{
+		AuditEvent: *event,
+		PrevHash:   prevHash,
+		Hash:       hash,
+	}
+	if len(c.key) > 0 {
+		record.HMAC = c.sign(hash)
+	}
+
+	return record, nil
+}
+
+// Check проверяет, что запись продолжает цепочку после prevHash и не изменена.
+func (c *Chain) Check(record *ChainRecord, prevHash string) error {
+	if record.PrevHash != prevHash {
+		return ErrPrevHashMismatch
+	}
+
+	hash, err := chainHash(&record.AuditEvent, record.PrevHash)
+	if err != nil {
+		return err
+	}
+	if hash != record.Hash {
+		return ErrHashMismatch
+	}
+
+	if len(c.key) > 0 && !hmac.Equal([]byte(record.HMAC), []byte(c.sign(hash))) {
+		return ErrHMACMismatch
+	}
+
+	return nil
+}
+
+func (c *Chain) sign(hash string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(hash))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func chainHash(event *AuditEvent, prevHash string) (string, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.New()
+	sum.Write([]byte(prevHash))
+	sum.Write(data)
+
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// ChainError сообщает о первой записи, нарушающей цепочку.
+type ChainError struct {
+	Line int   // Номер строки файла, начиная с 1
+	Err  error // Причина
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// Verify проходит по записям цепочки из r и возвращает количество проверенных записей.
+// При первом нарушении возвращается *ChainError с номером строки.
+func (c *Chain) Verify(r io.Reader) (int, error) {
+	verifier := NewChainVerifier(c)
+	err := verifier.Verify(r)
+
+	return verifier.Records, err
+}
+
+// ChainVerifier проверяет цепочку, записанную в один или несколько файлов.
+// Файлы передаются в Verify по порядку, от старых к новым.
+type ChainVerifier struct {
+	Partial bool // Принимать prev_hash первой записи: предыдущие файлы удалены по правилам хранения
+	Records int  // Количество проверенных записей
+
+	chain    *Chain
+	prevHash string
+}
+
+// NewChainVerifier создает проверку цепочки с параметрами chain.
+func NewChainVerifier(chain *Chain) *ChainVerifier {
+	return &ChainVerifier{chain: chain}
+}
+
+// Verify проверяет записи из r, продолжающие ранее проверенные записи.
+// При первом нарушении возвращается *ChainError с номером строки в r.
+func (v *ChainVerifier) Verify(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		record := new(ChainRecord)
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil || record.Hash == "" {
+			return &ChainError{Line: line, Err: ErrMalformedRecord}
+		}
+
+		if v.Partial && v.Records == 0 {
+			v.prevHash = record.PrevHash
+		}
+
+		if err := v.chain.Check(record, v.prevHash); err != nil {
+			return &ChainError{Line: line, Err: err}
+		}
+		v.prevHash = record.Hash
+		v.Records++
+	}
+
+	return scanner.Err()
+}
+
+// lastChainHash возвращает хеш последней записи файла или пустую строку,
+// если файл пуст или не существует.
+func lastChainHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		last = append(last[:0], scanner.Bytes()...)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if len(last) == 0 {
+		return "", nil
+	}
+
+	record := new(ChainRecord)
+	if err := json.Unmarshal(last, record); err != nil || record.Hash == "" {
+		return "", fmt.Errorf("%w: последняя запись %s не входит в цепочку", ErrMalformedRecord, path)
+	}
+
+	return record.Hash, nil
+}
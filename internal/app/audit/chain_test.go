@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func writeChain(t *testing.T, path string, key []byte, count int) {
+	observer, err := NewFileAuditObserver(path, FileOptions{Chain: true, HMACKey: key}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	defer observer.Close()
+
+	for i := 0; i < count; i++ {
+		require.NoError(t, observer.Notify(context.Background(), NewAuditEvent(ActionShorten, "user", "http://example.com")))
+	}
+}
+
+func TestChain_Verify(t *testing.T) {
+	key := []byte("secret")
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// Цепочка продолжается после перезапуска
+	writeChain(t, path, key, 2)
+	writeChain(t, path, key, 2)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+
+	tests := []struct {
+		name     string
+		content  string
+		key      []byte
+		want     int
+		wantLine int
+		wantErr  error
+	}{
+		{name: "#1 intact", content: string(data), key: key, want: 4},
+		{name: "#2 empty", content: "", key: key, want: 0},
+		{
+			name:     "#3 edited record",
+			content:  lines[0] + strings.Replace(lines[1], `"user_id":"user"`, `"user_id":"other"`, 1) + lines[2],
+			key:      key,
+			want:     1,
+			wantLine: 2,
+			wantErr:  ErrHashMismatch,
+		},
+		{
+			name:     "#4 removed record",
+			content:  lines[0] + lines[2] + lines[3],
+			key:      key,
+			want:     1,
+			wantLine: 2,
+			wantErr:  ErrPrevHashMismatch,
+		},
+		{
+			name:     "#5 wrong key",
+			content:  string(data),
+			key:      []byte("other"),
+			wantLine: 1,
+			wantErr:  ErrHMACMismatch,
+		},
+		{
+			name:     "#6 plain record",
+			content:  lines[0] + `{"action":"shorten"}` + "\n",
+			key:      key,
+			want:     1,
+			wantLine: 2,
+			wantErr:  ErrMalformedRecord,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := NewChain(tt.key).Verify(bytes.NewBufferString(tt.content))
+			assert.Equal(t, tt.want, count)
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var chainErr *ChainError
+			require.ErrorAs(t, err, &chainErr)
+			assert.Equal(t, tt.wantLine, chainErr.Line)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewFileAuditObserver_ChainOnPlainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	observer, err := NewFileAuditObserver(path, FileOptions{}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	require.NoError(t, observer.Notify(context.Background(), NewAuditEvent(ActionShorten, "user", "")))
+	require.NoError(t, observer.Close())
+
+	_, err = NewFileAuditObserver(path, FileOptions{Chain: true}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// netConn - сетевое соединение с получателем событий.
+// Соединение устанавливается при первой отправке и заново после ошибки записи.
+type netConn struct {
+	network string
+	address string
+	timeout time.Duration
+	mutex   sync.Mutex
+	conn    net.Conn
+}
+
+func newNetConn(network, address string, timeout time.Duration) *netConn {
+	return &netConn{
+		network: network,
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// write отправляет пакеты по одному вызову Write на каждый.
+// Для UDP каждый пакет становится отдельной датаграммой.
+func (c *netConn) write(ctx context.Context, packets ...[]byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn == nil {
+		dialer := net.Dialer{Timeout: c.timeout}
+		conn, err := dialer.DialContext(ctx, c.network, c.address)
+		if err != nil {
+			return fmt.Errorf("ошибка подключения к %s: %w", c.address, err)
+		}
+		c.conn = conn
+	}
+
+	if err := c.conn.SetWriteDeadline(c.deadline(ctx)); err != nil {
+		c.reset()
+		return fmt.Errorf("ошибка отправки на %s: %w", c.address, err)
+	}
+
+	for _, packet := range packets {
+		if _, err := c.conn.Write(packet); err != nil {
+			c.reset()
+			return fmt.Errorf("ошибка отправки на %s: %w", c.address, err)
+		}
+	}
+
+	return nil
+}
+
+// deadline возвращает ближайший из сроков timeout и ctx (нулевое время - без ограничения).
+func (c *netConn) deadline(ctx context.Context) time.Time {
+	var deadline time.Time
+	if c.timeout > 0 {
+		deadline = time.Now().Add(c.timeout)
+	}
+
+	if ctxDeadline, ok := ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+
+	return deadline
+}
+
+func (c *netConn) reset() {
+	_ = c.conn.Close()
+	c.conn = nil
+}
+
+// Close закрывает соединение, если оно установлено.
+func (c *netConn) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey string
+
+const (
+	requestInfoKey contextKey = "audit_request_info"
+	recorderKey    contextKey = "audit_recorder"
+)
+
+// RequestInfo содержит сведения о HTTP-запросе, которые добавляются ко всем его событиям.
+type RequestInfo struct {
+	RequestID string
+	ClientIP  string
+	UserAgent string
+}
+
+// WithRequestInfo сохраняет сведения о запросе в контексте.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey, info)
+}
+
+// RequestInfoFromContext возвращает сведения о запросе из контекста.
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey).(RequestInfo)
+	return info, ok
+}
+
+// Recorder накапливает события одного запроса до получения статуса ответа.
+type Recorder struct {
+	mutex  sync.Mutex
+	events []*AuditEvent
+}
+
+// WithRecorder создает накопитель событий и сохраняет его в контексте.
+// События, переданные в AuditService.Emit с этим контекстом, не отправляются сразу,
+// а собираются в накопителе.
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+	return context.WithValue(ctx, recorderKey, recorder), recorder
+}
+
+// Events возвращает накопленные события и очищает накопитель.
+func (r *Recorder) Events() []*AuditEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	events := r.events
+	r.events = nil
+
+	return events
+}
+
+func (r *Recorder) add(event *AuditEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func recorderFromContext(ctx context.Context) (*Recorder, bool) {
+	recorder, ok := ctx.Value(recorderKey).(*Recorder)
+	return recorder, ok
+}
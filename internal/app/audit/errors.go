@@ -0,0 +1,17 @@
+package audit
+
+import "errors"
+
+var (
+	ErrMalformedRecord  = errors.New("malformed audit record")
+	ErrPrevHashMismatch = errors.New("previous hash does not match")
+	ErrHashMismatch     = errors.New("record hash does not match its content")
+	ErrHMACMismatch     = errors.New("record hmac is missing or invalid")
+
+	ErrUnknownFacility    = errors.New("unknown syslog facility")
+	ErrUnsupportedNetwork = errors.New("unsupported syslog network")
+	ErrDuplicateObserver  = errors.New("audit observer name is already used")
+	ErrObserverNotFound   = errors.New("audit observer not found")
+	ErrObserverClosed     = errors.New("audit observer is closed")
+	ErrInvalidSampleRate  = errors.New("audit sample rate must be between 0 and 1")
+)
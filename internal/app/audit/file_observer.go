@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FileOptions задает режим записи файла аудита.
+type FileOptions struct {
+	Chain    bool            // Записывать события в виде цепочки хешей (см. ChainRecord)
+	HMACKey  []byte          // Ключ HMAC записей цепочки (пусто - без HMAC)
+	Rotation RotationOptions // Ротация и хранение старых файлов
+}
+
+// FileAuditObserver записывает события в файл по строке JSON на событие.
+// Файл остается открытым, а записи буферизуются и сбрасываются на диск после каждого пакета.
+type FileAuditObserver struct {
+	filePath string
+	opts     FileOptions
+	mutex    sync.Mutex
+	log      *zap.SugaredLogger
+	now      func() time.Time
+
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64     // Размер текущего файла
+	openedAt time.Time // Время открытия текущего файла для ротации по времени
+
+	chain    *Chain // Цепочка хешей (nil - обычные строки JSON)
+	lastHash string // Хеш последней записанной записи цепочки
+}
+
+// NewFileAuditObserver открывает файл аудита filePath для дописывания.
+// В режиме цепочки, если файл уже содержит записи, цепочка продолжается от последней из них;
+// файл с записями без хеша (например, записанный без режима цепочки) не подходит.
+// После ротации цепочка продолжается в новом файле: первая запись ссылается на последнюю
+// запись предыдущего файла.
+func NewFileAuditObserver(filePath string, opts FileOptions, log *zap.SugaredLogger) (*FileAuditObserver, error) {
+	f := &FileAuditObserver{
+		filePath: filePath,
+		opts:     opts,
+		log:      log,
+		now:      time.Now,
+	}
+
+	if opts.Chain {
+		lastHash, err := lastChainHash(filePath)
+		if err != nil {
+			return nil, err
+		}
+		f.chain = NewChain(opts.HMACKey)
+		f.lastHash = lastHash
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *FileAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	return f.NotifyBatch(ctx, []*AuditEvent{event})
+}
+
+// NotifyBatch дописывает события в файл аудита и сбрасывает буфер на диск.
+// Перед записью файл при необходимости ротируется.
+func (f *FileAuditObserver) NotifyBatch(_ context.Context, events []*AuditEvent) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, lastHash, err := f.encode(events)
+	if err != nil {
+		return err
+	}
+
+	// После неудачного открытия (например, при ротации) файл открывается заново
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	if f.needRotation(int64(len(data))) {
+		if err := f.rotate(); err != nil {
+			f.log.Errorw("Ошибка ротации файла аудита", "error", err)
+			if f.file == nil {
+				return err
+			}
+		}
+	}
+
+	written, err := f.writer.Write(data)
+	f.size += int64(written)
+	if err == nil {
+		err = f.writer.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи в файл аудита: %w", err)
+	}
+
+	// Цепочка продолжается только после успешной записи
+	f.lastHash = lastHash
+
+	return nil
+}
+
+// Reopen закрывает и заново открывает файл аудита.
+// Используется после того, как внешняя утилита (logrotate) переименовала файл.
+func (f *FileAuditObserver) Reopen() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.close(); err != nil {
+		f.log.Errorw("Ошибка закрытия файла аудита", "error", err)
+	}
+
+	return f.open()
+}
+
+// Close сбрасывает буфер и закрывает файл аудита.
+func (f *FileAuditObserver) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.close()
+}
+
+func (f *FileAuditObserver) open() error {
+	file, err := os.OpenFile(f.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла аудита: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка открытия файла аудита: %w", err)
+	}
+
+	f.file = file
+	f.writer = bufio.NewWriter(file)
+	f.size = info.Size()
+	f.openedAt = f.now()
+
+	return nil
+}
+
+func (f *FileAuditObserver) close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.writer.Flush()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.file = nil
+	f.writer = nil
+
+	return err
+}
+
+// encode сериализует события и возвращает хеш последней записи цепочки.
+func (f *FileAuditObserver) encode(events []*AuditEvent) ([]byte, string, error) {
+	if f.chain == nil {
+		data, err := marshalLines(events)
+		return data, "", err
+	}
+
+	var buf bytes.Buffer
+	lastHash := f.lastHash
+	for _, event := range events {
+		record, err := f.chain.Seal(event, lastHash)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка сериализации события аудита: %w", err)
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка сериализации события аудита: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+		lastHash = record.Hash
+	}
+
+	return buf.Bytes(), lastHash, nil
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// Filter отбирает события для одного наблюдателя.
+// Пустое условие пропускает все события. Событие передается наблюдателю,
+// только если подходит под все заданные условия.
+type Filter struct {
+	actions     []string
+	userIDs     []string
+	urlPatterns []*regexp.Regexp
+	sampleRate  float64
+}
+
+// NewFilter создает фильтр событий.
+// urlPatterns - регулярные выражения; событие подходит, если его URL совпадает
+// хотя бы с одним из них (события без URL при заданных шаблонах отбрасываются).
+// sampleRate - доля пропускаемых событий от 0 до 1 (0 - без выборки, все события).
+// Выборка детерминирована по идентификатору события, неудачные события не прореживаются.
+func NewFilter(actions, userIDs, urlPatterns []string, sampleRate float64) (*Filter, error) {
+	if sampleRate < 0 || sampleRate > 1 || math.IsNaN(sampleRate) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSampleRate, sampleRate)
+	}
+
+	filter := &Filter{
+		actions:    actions,
+		userIDs:    userIDs,
+		sampleRate: sampleRate,
+	}
+
+	for _, pattern := range urlPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный шаблон URL %q: %w", pattern, err)
+		}
+		filter.urlPatterns = append(filter.urlPatterns, re)
+	}
+
+	return filter, nil
+}
+
+// Match сообщает, нужно ли передать событие наблюдателю.
+// nil-фильтр пропускает все события.
+func (f *Filter) Match(event *AuditEvent) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.actions) > 0 && !slices.Contains(f.actions, event.Action) {
+		return false
+	}
+
+	if len(f.userIDs) > 0 && !slices.Contains(f.userIDs, event.UserID) {
+		return false
+	}
+
+	if len(f.urlPatterns) > 0 && !f.matchURL(event.URL) {
+		return false
+	}
+
+	return f.sampled(event)
+}
+
+func (f *Filter) matchURL(url string) bool {
+	if url == "" {
+		return false
+	}
+
+	for _, re := range f.urlPatterns {
+		if re.MatchString(url) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sampled решает, попадает ли событие в выборку.
+// Решение зависит только от идентификатора события, поэтому повторная доставка
+// того же события дает тот же результат.
+func (f *Filter) sampled(event *AuditEvent) bool {
+	if f.sampleRate == 0 || f.sampleRate == 1 || event.Result == ResultFailure {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(event.ID))
+
+	return float64(hash.Sum32()) < f.sampleRate*(1<<32)
+}
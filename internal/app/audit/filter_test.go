@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFilter_Match(t *testing.T) {
+	event := func(action, userID, url string) *AuditEvent {
+		return NewAuditEvent(action, userID, url)
+	}
+
+	tests := []struct {
+		name        string
+		actions     []string
+		userIDs     []string
+		urlPatterns []string
+		event       *AuditEvent
+		want        bool
+	}{
+		{name: "#1 empty filter", event: event(ActionFollow, "user", "http://example.com"), want: true},
+		{name: "#2 action matches", actions: []string{ActionShorten, ActionDelete}, event: event(ActionDelete, "user", ""), want: true},
+		{name: "#3 action does not match", actions: []string{ActionShorten, ActionDelete}, event: event(ActionFollow, "user", ""), want: false},
+		{name: "#4 user does not match", userIDs: []string{"admin"}, event: event(ActionShorten, "user", ""), want: false},
+		{name: "#5 url matches", urlPatterns: []string{`^https://internal\.`, `example\.com`}, event: event(ActionShorten, "user", "http://example.com/a"), want: true},
+		{name: "#6 url does not match", urlPatterns: []string{`^https://`}, event: event(ActionShorten, "user", "http://example.com/a"), want: false},
+		{name: "#7 event without url", urlPatterns: []string{`.*`}, event: event(ActionLogin, "user", ""), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewFilter(tt.actions, tt.userIDs, tt.urlPatterns, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter.Match(tt.event))
+		})
+	}
+}
+
+func TestFilter_Sampling(t *testing.T) {
+	filter, err := NewFilter(nil, nil, nil, 0.1)
+	require.NoError(t, err)
+
+	passed := 0
+	for i := 0; i < 10000; i++ {
+		event := NewAuditEvent(ActionFollow, "user", "http://example.com")
+		if filter.Match(event) {
+			passed++
+			// Решение для события не меняется при повторной проверке
+			assert.True(t, filter.Match(event))
+		}
+	}
+	assert.InDelta(t, 1000, passed, 200)
+
+	// Неудачные события не прореживаются
+	for i := 0; i < 100; i++ {
+		assert.True(t, filter.Match(NewAuditEvent(ActionFollow, "user", "").Fail(assert.AnError)))
+	}
+
+	_, err = NewFilter(nil, nil, nil, 1.5)
+	assert.ErrorIs(t, err, ErrInvalidSampleRate)
+}
+
+func TestAuditService_SetFilter(t *testing.T) {
+	service := NewAuditService(zap.NewNop().Sugar(), testOptions(t))
+	all := &recordingObserver{}
+	shortenOnly := &recordingObserver{}
+	require.NoError(t, service.AddQueuedObserver("file", all))
+	require.NoError(t, service.AddQueuedObserver("http", shortenOnly))
+
+	filter, err := NewFilter([]string{ActionShorten, ActionDelete}, nil, nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, service.SetFilter("http", filter))
+	assert.ErrorIs(t, service.SetFilter("missing", filter), ErrObserverNotFound)
+
+	for i := 0; i < 5; i++ {
+		url := fmt.Sprintf("http://example.com/%d", i)
+		service.Publish(context.Background(), NewAuditEvent(ActionShorten, "user", url), NewAuditEvent(ActionFollow, "user", url))
+	}
+	require.NoError(t, service.Close(context.Background()))
+
+	assert.Len(t, all.delivered(), 10)
+	assert.Len(t, shortenOnly.delivered(), 5)
+	for _, event := range shortenOnly.delivered() {
+		assert.Equal(t, ActionShorten, event.Action)
+	}
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Заголовки подписи запросов HTTPAuditObserver.
+// Получатель проверяет подпись, вычисляя HMAC-SHA256 от строки "<timestamp>.<тело запроса>".
+const (
+	SignatureHeader = "X-Audit-Signature" // Подпись в виде "sha256=<hex>"
+	TimestampHeader = "X-Audit-Timestamp" // Время отправки в секундах Unix
+)
+
+// HTTPOptions задает параметры отправки событий по HTTP.
+type HTTPOptions struct {
+	Timeout time.Duration     // Ограничение времени одного запроса (0 - без ограничения)
+	HMACKey []byte            // Ключ подписи запросов (пусто - без подписи)
+	Headers map[string]string // Дополнительные заголовки запроса
+}
+
+type HTTPAuditObserver struct {
+	url    string
+	opts   HTTPOptions
+	client *http.Client
+	now    func() time.Time
+	log    *zap.SugaredLogger
+}
+
+// NewHTTPAuditObserver создает наблюдателя, отправляющего события POST-запросом на url.
+func NewHTTPAuditObserver(url string, opts HTTPOptions, log *zap.SugaredLogger) *HTTPAuditObserver {
+	return &HTTPAuditObserver{
+		url:    url,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		now:    time.Now,
+		log:    log,
+	}
+}
+
+// Notify отправляет одно событие JSON-объектом.
+func (h *HTTPAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации события аудита: %w", err)
+	}
+
+	return h.post(ctx, data)
+}
+
+// NotifyBatch отправляет несколько событий JSON-массивом одним запросом.
+// Одно событие отправляется объектом, как в Notify.
+func (h *HTTPAuditObserver) NotifyBatch(ctx context.Context, events []*AuditEvent) error {
+	if len(events) == 1 {
+		return h.Notify(ctx, events[0])
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации событий аудита: %w", err)
+	}
+
+	return h.post(ctx, data)
+}
+
+func (h *HTTPAuditObserver) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("ошибка создания HTTP запроса: %w", err)
+	}
+
+	for name, value := range h.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(h.opts.HMACKey) > 0 {
+		timestamp := strconv.FormatInt(h.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+signPayload(h.opts.HMACKey, timestamp, data))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки HTTP запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("сервер вернул ошибку: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// signPayload вычисляет HMAC-SHA256 от времени отправки и тела запроса.
+// Время входит в подпись, чтобы перехваченный запрос нельзя было повторить позже.
+func signPayload(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,24 +1,99 @@
 package audit
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// SchemaVersion задает версию формата событий аудита.
+// Версия увеличивается при любом несовместимом изменении полей AuditEvent.
+const SchemaVersion = 2
 
+// AuditEvent описывает одно событие аудита.
 type AuditEvent struct {
-	Timestamp int64  `json:"ts"`
-	Action    string `json:"action"`
-	UserID    string `json:"user_id"`
-	URL       string `json:"url"`
+	SchemaVersion int    `json:"schema_version"`       // Версия формата события
+	ID            string `json:"id"`                   // Уникальный идентификатор события
+	Timestamp     int64  `json:"ts"`                   // Время события в миллисекундах Unix
+	Action        string `json:"action"`               // Действие
+	UserID        string `json:"user_id"`              // Пользователь, выполнивший действие
+	ShortCode     string `json:"short_code,omitempty"` // Короткий код ссылки
+	URL           string `json:"url,omitempty"`        // Оригинальный URL ссылки
+	ClientIP      string `json:"client_ip,omitempty"`  // IP-адрес клиента
+	UserAgent     string `json:"user_agent,omitempty"` // User-Agent клиента
+	RequestID     string `json:"request_id,omitempty"` // Идентификатор HTTP-запроса
+	Result        string `json:"result"`               // Результат действия: ResultSuccess или ResultFailure
+	Status        int    `json:"status,omitempty"`     // HTTP-статус ответа на запрос
+	Error         string `json:"error,omitempty"`      // Причина неудачи
+	Operation     string `json:"operation,omitempty"`  // Уточнение действия, например административная операция
+	Target        string `json:"target,omitempty"`     // Объект действия: короткий код, пользователь или запрос
 }
 
+// NewAuditEvent создает успешное событие с новым идентификатором и текущим временем.
 func NewAuditEvent(action, userID, url string) *AuditEvent {
 	return &AuditEvent{
-		Timestamp: time.Now().Unix(),
-		Action:    action,
-		UserID:    userID,
-		URL:       url,
+		SchemaVersion: SchemaVersion,
+		ID:            NewEventID(),
+		Timestamp:     time.Now().UnixMilli(),
+		Action:        action,
+		UserID:        userID,
+		URL:           url,
+		Result:        ResultSuccess,
+	}
+}
+
+// Fail помечает событие как неудачное с указанной причиной.
+func (e *AuditEvent) Fail(err error) *AuditEvent {
+	e.Result = ResultFailure
+	if err != nil {
+		e.Error = err.Error()
 	}
+
+	return e
+}
+
+// NewEventID создает случайный идентификатор в формате UUID версии 4.
+func NewEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	id := hex.EncodeToString(b[:])
+
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
 }
 
 const (
 	ActionShorten = "shorten"
 	ActionFollow  = "follow"
+	ActionDelete  = "delete"
+	ActionUpdate  = "update"
+	ActionLogin   = "login"
+	ActionAdmin   = "admin"
+)
+
+// Результаты действий.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Операции, уточняющие действие ActionUpdate.
+const (
+	UpdateClaimLinks = "claim_links"
+)
+
+// Административные операции, записываемые с действием ActionAdmin.
+const (
+	AdminSearchLinks = "search_links"
+	AdminDisableLink = "disable_link"
+	AdminEnableLink  = "enable_link"
+	AdminDeleteLink  = "delete_link"
+	AdminBanUser     = "ban_user"
+	AdminUnbanUser   = "unban_user"
+	AdminViewStats   = "view_stats"
+	AdminSearchAudit = "search_audit"
+
+	AdminChangeLogLevel = "change_log_level"
 )
@@ -5,32 +5,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
 	"sync"
 
 	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
 )
 
 type Observer interface {
 	Notify(ctx context.Context, event *AuditEvent) error
 }
 
+// BatchObserver принимает несколько событий за один вызов.
+type BatchObserver interface {
+	Observer
+	NotifyBatch(ctx context.Context, events []*AuditEvent) error
+}
+
 type Subject interface {
 	Attach(observer Observer)
 	Detach(observer Observer)
+	SetFilter(observer Observer, filter *Filter) bool
 	NotifyObservers(ctx context.Context, event *AuditEvent)
 }
 
+// subscription - подключенный наблюдатель и его фильтр событий.
+type subscription struct {
+	observer Observer
+	filter   *Filter
+}
+
 type AuditSubject struct {
-	observers []Observer
+	observers []subscription
 	mutex     sync.RWMutex
 	log       *zap.SugaredLogger
 }
 
 func NewAuditSubject(log *zap.SugaredLogger) *AuditSubject {
 	return &AuditSubject{
-		observers: make([]Observer, 0),
+		observers: make([]subscription, 0),
 		log:       log,
 	}
 }
@@ -38,112 +51,65 @@ func NewAuditSubject(log *zap.SugaredLogger) *AuditSubject {
 func (s *AuditSubject) Attach(observer Observer) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	s.observers = append(s.observers, observer)
+	s.observers = append(s.observers, subscription{observer: observer})
 }
 
 func (s *AuditSubject) Detach(observer Observer) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	for i, obs := range s.observers {
-		if obs == observer {
+		if obs.observer == observer {
 			s.observers = append(s.observers[:i], s.observers[i+1:]...)
 			break
 		}
 	}
 }
 
+// SetFilter задает фильтр событий для подключенного наблюдателя (nil - все события).
+// Возвращает false, если наблюдатель не подключен.
+func (s *AuditSubject) SetFilter(observer Observer, filter *Filter) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, obs := range s.observers {
+		if obs.observer == observer {
+			s.observers[i].filter = filter
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotifyObservers передает событие наблюдателям, фильтр которых его пропускает.
+// Наблюдатели вызываются последовательно, поэтому медленные получатели
+// должны быть обернуты в QueuedObserver.
 func (s *AuditSubject) NotifyObservers(ctx context.Context, event *AuditEvent) {
 	s.mutex.RLock()
-	observers := make([]Observer, len(s.observers))
+	observers := make([]subscription, len(s.observers))
 	copy(observers, s.observers)
 	s.mutex.RUnlock()
 
 	for _, observer := range observers {
-		go func(obs Observer) {
-			if err := obs.Notify(ctx, event); err != nil {
-				s.log.Errorw("Ошибка уведомления наблюдателя", "error", err)
-			}
-		}(observer)
-	}
-}
-
-type FileAuditObserver struct {
-	filePath string
-	mutex    sync.Mutex
-	log      *zap.SugaredLogger
-}
-
-func NewFileAuditObserver(filePath string, log *zap.SugaredLogger) *FileAuditObserver {
-	return &FileAuditObserver{
-		filePath: filePath,
-		log:      log,
-	}
-}
-
-func (f *FileAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
-	f.mutex.Lock()
-	defer f.mutex.Unlock()
-
-	file, err := os.OpenFile(f.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		f.log.Errorw("Ошибка открытия файла аудита", "error", err)
-		return err
-	}
-	defer file.Close()
-
-	data, err := json.Marshal(event)
-	if err != nil {
-		f.log.Errorw("Ошибка сериализации события аудита", "error", err)
-		return err
-	}
-
-	if _, err := file.Write(append(data, '\n')); err != nil {
-		f.log.Errorw("Ошибка записи в файл аудита", "error", err)
-		return err
-	}
-
-	return nil
-}
-
-type HTTPAuditObserver struct {
-	url string
-	log *zap.SugaredLogger
-}
-
-func NewHTTPAuditObserver(url string, log *zap.SugaredLogger) *HTTPAuditObserver {
-	return &HTTPAuditObserver{
-		url: url,
-		log: log,
+		if !observer.filter.Match(event) {
+			continue
+		}
+		if err := observer.observer.Notify(ctx, event); err != nil {
+			logger.FromContextOr(ctx, s.log).Errorw("Ошибка уведомления наблюдателя", "error", err)
+		}
 	}
 }
 
-func (h *HTTPAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
-	data, err := json.Marshal(event)
-	if err != nil {
-		h.log.Errorw("Ошибка сериализации события аудита", "error", err)
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewBuffer(data))
-	if err != nil {
-		h.log.Errorw("Ошибка создания HTTP запроса", "error", err)
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		h.log.Errorw("Ошибка отправки HTTP запроса", "error", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		h.log.Errorw("Сервер вернул ошибку", "status_code", resp.StatusCode)
-		return fmt.Errorf("сервер вернул ошибку: %d", resp.StatusCode)
+// marshalLines сериализует события в формат JSON Lines.
+func marshalLines(events []*AuditEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации события аудита: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
 	}
 
-	return nil
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// Query задает условия поиска сохраненных событий аудита.
+// Пустые поля не ограничивают выборку.
+type Query struct {
+	UserID    string    // Идентификатор пользователя
+	Action    string    // Действие
+	URL       string    // Подстрока URL (без учета регистра)
+	ShortCode string    // Короткий код ссылки
+	From      time.Time // Начало периода включительно (нулевое время - без ограничения)
+	To        time.Time // Конец периода не включительно (нулевое время - без ограничения)
+	Limit     int       // Максимальное количество событий (0 - без ограничения)
+	Offset    int       // Количество пропускаемых событий
+}
+
+// Match проверяет, удовлетворяет ли событие условиям запроса. Limit и Offset не учитываются.
+func (q Query) Match(event *AuditEvent) bool {
+	if q.UserID != "" && event.UserID != q.UserID {
+		return false
+	}
+
+	if q.Action != "" && event.Action != q.Action {
+		return false
+	}
+
+	if q.ShortCode != "" && event.ShortCode != q.ShortCode {
+		return false
+	}
+
+	if q.URL != "" && !strings.Contains(strings.ToLower(event.URL), strings.ToLower(q.URL)) {
+		return false
+	}
+
+	if !q.From.IsZero() && event.Timestamp < q.From.UnixMilli() {
+		return false
+	}
+
+	if !q.To.IsZero() && event.Timestamp >= q.To.UnixMilli() {
+		return false
+	}
+
+	return true
+}
+
+// Page возвращает часть событий согласно Limit и Offset запроса.
+func (q Query) Page(events []*AuditEvent) []*AuditEvent {
+	if q.Offset >= len(events) {
+		return events[:0]
+	}
+
+	events = events[max(q.Offset, 0):]
+	if q.Limit > 0 && q.Limit < len(events) {
+		events = events[:q.Limit]
+	}
+
+	return events
+}
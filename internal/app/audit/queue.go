@@ -0,0 +1,364 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DeliveryOptions задает параметры доставки событий наблюдателям.
+type DeliveryOptions struct {
+	QueueSize     int           // Размер очереди событий наблюдателя
+	BatchSize     int           // Максимальное количество событий в одной доставке
+	FlushInterval time.Duration // Как часто доставляется неполный пакет и повторяется доставка из spool
+	MaxRetries    int           // Количество повторов доставки пакета перед сохранением в spool
+	RetryBackoff  time.Duration // Пауза перед первым повтором; каждая следующая пауза вдвое больше
+	MaxBackoff    time.Duration // Максимальная пауза между повторами
+	SpoolDir      string        // Каталог для недоставленных событий (пусто - события отбрасываются)
+
+	DeliveryTimeout time.Duration // Ограничение времени одной попытки доставки (0 - без ограничения)
+}
+
+// DefaultDeliveryOptions возвращает параметры доставки по умолчанию.
+func DefaultDeliveryOptions() DeliveryOptions {
+	return DeliveryOptions{
+		QueueSize:     1000,
+		BatchSize:     50,
+		FlushInterval: time.Second,
+		MaxRetries:    5,
+		RetryBackoff:  200 * time.Millisecond,
+		MaxBackoff:    30 * time.Second,
+
+		DeliveryTimeout: 10 * time.Second,
+	}
+}
+
+// DeliveryStats содержит показатели доставки событий наблюдателю.
+type DeliveryStats struct {
+	Queued    int    `json:"queued"`    // Событий в очереди сейчас
+	Enqueued  uint64 `json:"enqueued"`  // Принято в очередь
+	Delivered uint64 `json:"delivered"` // Доставлено, включая повторно доставленные из spool
+	Retries   uint64 `json:"retries"`   // Повторных попыток доставки
+	Spooled   uint64 `json:"spooled"`   // Сохранено в spool после неудачной доставки или переполнения очереди
+	Replayed  uint64 `json:"replayed"`  // Доставлено из spool
+	Dropped   uint64 `json:"dropped"`   // Потеряно: не удалось ни доставить, ни сохранить в spool
+}
+
+// QueuedObserver доставляет события наблюдателю в фоне.
+// События накапливаются в ограниченной очереди и доставляются пакетами; при ошибке доставка
+// повторяется с экспоненциальной паузой, а после исчерпания попыток события сохраняются
+// в spool и доставляются повторно позже. При переполнении очереди события сразу
+// сохраняются в spool, чтобы не задерживать обработку запросов.
+// Доставка выполняется по принципу "хотя бы один раз": при частичной доставке пакета
+// из spool получатель может повторно получить часть событий.
+type QueuedObserver struct {
+	name   string
+	target Observer
+	opts   DeliveryOptions
+	spool  *Spool
+	log    *zap.SugaredLogger
+
+	queue  chan *AuditEvent
+	mutex  sync.RWMutex
+	closed bool
+	abort  chan struct{}
+	done   chan struct{}
+
+	enqueued  atomic.Uint64
+	delivered atomic.Uint64
+	retries   atomic.Uint64
+	spooled   atomic.Uint64
+	replayed  atomic.Uint64
+	dropped   atomic.Uint64
+
+	// lastErr - ошибка последней попытки доставки (nil - доставка удалась)
+	lastErr atomic.Pointer[error]
+}
+
+// NewQueuedObserver создает очередь доставки для target и запускает ее обработку.
+// name используется в журнале, показателях и имени файла spool.
+func NewQueuedObserver(name string, target Observer, opts DeliveryOptions, log *zap.SugaredLogger) *QueuedObserver {
+	defaults := DefaultDeliveryOptions()
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.MaxBackoff < opts.RetryBackoff {
+		opts.MaxBackoff = opts.RetryBackoff
+	}
+
+	q := &QueuedObserver{
+		name:   name,
+		target: target,
+		opts:   opts,
+		log:    log,
+		queue:  make(chan *AuditEvent, opts.QueueSize),
+		abort:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	if opts.SpoolDir != "" {
+		spool, err := NewSpool(filepath.Join(opts.SpoolDir, name+".jsonl"))
+		if err != nil {
+			log.Errorw("Не удалось создать spool аудита", "observer", name, "error", err)
+		}
+		q.spool = spool
+	}
+
+	go q.run()
+
+	return q
+}
+
+// Notify ставит событие в очередь и не ждет доставки.
+// Если очередь переполнена или закрыта, событие сохраняется в spool.
+func (q *QueuedObserver) Notify(_ context.Context, event *AuditEvent) error {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if !q.closed {
+		select {
+		case q.queue <- event:
+			q.enqueued.Add(1)
+			return nil
+		default:
+		}
+	}
+
+	q.save([]*AuditEvent{event})
+
+	return nil
+}
+
+// Stats возвращает показатели доставки.
+func (q *QueuedObserver) Stats() DeliveryStats {
+	return DeliveryStats{
+		Queued:    len(q.queue),
+		Enqueued:  q.enqueued.Load(),
+		Delivered: q.delivered.Load(),
+		Retries:   q.retries.Load(),
+		Spooled:   q.spooled.Load(),
+		Replayed:  q.replayed.Load(),
+		Dropped:   q.dropped.Load(),
+	}
+}
+
+// Health возвращает ошибку последней попытки доставки или nil, если она удалась.
+// После Close возвращает ErrObserverClosed.
+func (q *QueuedObserver) Health() error {
+	q.mutex.RLock()
+	closed := q.closed
+	q.mutex.RUnlock()
+	if closed {
+		return ErrObserverClosed
+	}
+
+	if err := q.lastErr.Load(); err != nil {
+		return *err
+	}
+
+	return nil
+}
+
+// Close прекращает прием событий и доставляет события, оставшиеся в очереди.
+// Если ctx завершается раньше, повторы прекращаются, а недоставленные события
+// сохраняются в spool.
+func (q *QueuedObserver) Close(ctx context.Context) error {
+	q.mutex.Lock()
+	if q.closed {
+		q.mutex.Unlock()
+		<-q.done
+		return nil
+	}
+	q.closed = true
+	close(q.queue)
+	q.mutex.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		close(q.abort)
+		<-q.done
+		return ctx.Err()
+	}
+}
+
+func (q *QueuedObserver) run() {
+	defer close(q.done)
+	defer q.closeTarget()
+
+	ticker := time.NewTicker(q.opts.FlushInterval)
+	defer ticker.Stop()
+
+	// При запуске доставляются события, сохраненные в spool предыдущим процессом
+	q.replay()
+
+	batch := make([]*AuditEvent, 0, q.opts.BatchSize)
+	for {
+		select {
+		case event, ok := <-q.queue:
+			if !ok {
+				q.deliver(batch)
+				q.replay()
+				return
+			}
+
+			batch = append(batch, event)
+			if len(batch) >= q.opts.BatchSize {
+				q.deliver(batch)
+				batch = batch[:0:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				q.deliver(batch)
+				batch = batch[:0:0]
+			}
+			q.replay()
+		}
+	}
+}
+
+// deliver доставляет пакет с повторами, а при неудаче сохраняет его в spool.
+func (q *QueuedObserver) deliver(batch []*AuditEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	backoff := q.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		sent, err := q.send(batch)
+		q.delivered.Add(uint64(sent))
+		// Доставленные события не повторяются
+		batch = batch[sent:]
+		if err == nil {
+			return
+		}
+
+		if attempt >= q.opts.MaxRetries || q.aborted() {
+			q.log.Errorw("Не удалось доставить события аудита", "observer", q.name, "events", len(batch), "error", err)
+			q.save(batch)
+			return
+		}
+
+		q.retries.Add(1)
+		if !q.sleep(backoff) {
+			q.save(batch)
+			return
+		}
+		backoff = min(backoff*2, q.opts.MaxBackoff)
+	}
+}
+
+// replay пытается один раз доставить события из spool.
+func (q *QueuedObserver) replay() {
+	if q.spool == nil || q.aborted() {
+		return
+	}
+
+	count, err := q.spool.Replay(func(events []*AuditEvent) error {
+		_, err := q.send(events)
+		return err
+	})
+	if err != nil {
+		q.log.Debugw("Повторная доставка событий аудита не удалась", "observer", q.name, "error", err)
+		return
+	}
+
+	q.delivered.Add(uint64(count))
+	q.replayed.Add(uint64(count))
+}
+
+// send передает пакет наблюдателю и возвращает количество доставленных событий.
+// Доставка не связана с контекстом запроса, в котором возникло событие,
+// и ограничена только DeliveryTimeout.
+func (q *QueuedObserver) send(batch []*AuditEvent) (sent int, err error) {
+	defer func() {
+		if err != nil {
+			q.lastErr.Store(&err)
+		} else {
+			q.lastErr.Store(nil)
+		}
+	}()
+
+	ctx := context.Background()
+	if q.opts.DeliveryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.opts.DeliveryTimeout)
+		defer cancel()
+	}
+
+	if target, ok := q.target.(BatchObserver); ok {
+		if err = target.NotifyBatch(ctx, batch); err != nil {
+			return 0, err
+		}
+		return len(batch), nil
+	}
+
+	for i, event := range batch {
+		if err = q.target.Notify(ctx, event); err != nil {
+			return i, err
+		}
+	}
+
+	return len(batch), nil
+}
+
+func (q *QueuedObserver) save(events []*AuditEvent) {
+	if q.spool == nil {
+		q.dropped.Add(uint64(len(events)))
+		return
+	}
+
+	if err := q.spool.Append(events); err != nil {
+		q.log.Errorw("Не удалось сохранить события аудита в spool", "observer", q.name, "events", len(events), "error", err)
+		q.dropped.Add(uint64(len(events)))
+		return
+	}
+
+	q.spooled.Add(uint64(len(events)))
+}
+
+// closeTarget освобождает ресурсы наблюдателя, например закрывает файл.
+func (q *QueuedObserver) closeTarget() {
+	if closer, ok := q.target.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			q.log.Errorw("Ошибка закрытия наблюдателя аудита", "observer", q.name, "error", err)
+		}
+	}
+}
+
+// sleep ждет d; возвращает false, если ожидание прервано из-за завершения Close.
+func (q *QueuedObserver) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-q.abort:
+		return false
+	}
+}
+
+func (q *QueuedObserver) aborted() bool {
+	select {
+	case <-q.abort:
+		return true
+	default:
+		return false
+	}
+}
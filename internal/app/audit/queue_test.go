@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recordingObserver запоминает доставленные пакеты и возвращает ошибку первые failures раз
+// (при отрицательном failures - всегда).
+type recordingObserver struct {
+	mutex    sync.Mutex
+	failures int
+	batches  [][]*AuditEvent
+	release  chan struct{}
+}
+
+func (o *recordingObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	return o.NotifyBatch(ctx, []*AuditEvent{event})
+}
+
+func (o *recordingObserver) NotifyBatch(_ context.Context, events []*AuditEvent) error {
+	if o.release != nil {
+		<-o.release
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.failures != 0 {
+		o.failures--
+		return errors.New("unavailable")
+	}
+	o.batches = append(o.batches, append([]*AuditEvent(nil), events...))
+
+	return nil
+}
+
+func (o *recordingObserver) delivered() []*AuditEvent {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var events []*AuditEvent
+	for _, batch := range o.batches {
+		events = append(events, batch...)
+	}
+
+	return events
+}
+
+func testOptions(t *testing.T) DeliveryOptions {
+	return DeliveryOptions{
+		QueueSize:     100,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+		RetryBackoff:  time.Millisecond,
+		MaxBackoff:    time.Millisecond,
+		SpoolDir:      t.TempDir(),
+	}
+}
+
+func notify(t *testing.T, observer Observer, count int) {
+	for i := 0; i < count; i++ {
+		require.NoError(t, observer.Notify(context.Background(), NewAuditEvent(ActionShorten, "user", "http://example.com")))
+	}
+}
+
+func TestQueuedObserver_Batching(t *testing.T) {
+	target := &recordingObserver{}
+	queue := NewQueuedObserver("test", target, testOptions(t), zap.NewNop().Sugar())
+
+	notify(t, queue, 7)
+	require.NoError(t, queue.Close(context.Background()))
+
+	assert.Len(t, target.delivered(), 7)
+	for _, batch := range target.batches {
+		assert.LessOrEqual(t, len(batch), 3)
+	}
+
+	stats := queue.Stats()
+	assert.Equal(t, uint64(7), stats.Enqueued)
+	assert.Equal(t, uint64(7), stats.Delivered)
+	assert.Equal(t, uint64(0), stats.Spooled)
+}
+
+func TestQueuedObserver_Retry(t *testing.T) {
+	target := &recordingObserver{failures: 2}
+	queue := NewQueuedObserver("test", target, testOptions(t), zap.NewNop().Sugar())
+
+	notify(t, queue, 1)
+	require.NoError(t, queue.Close(context.Background()))
+
+	assert.Len(t, target.delivered(), 1)
+	assert.Equal(t, uint64(2), queue.Stats().Retries)
+}
+
+func TestQueuedObserver_Health(t *testing.T) {
+	options := testOptions(t)
+	options.MaxRetries = 0
+	target := &recordingObserver{failures: 1}
+	queue := NewQueuedObserver("test", target, options, zap.NewNop().Sugar())
+
+	assert.NoError(t, queue.Health())
+
+	// Пакет из трех событий не доставлен: получатель неработоспособен до следующей удачной доставки
+	notify(t, queue, 3)
+	require.Eventually(t, func() bool { return queue.Health() != nil }, time.Second, time.Millisecond)
+
+	notify(t, queue, 3)
+	require.Eventually(t, func() bool { return queue.Health() == nil }, time.Second, time.Millisecond)
+
+	require.NoError(t, queue.Close(context.Background()))
+	assert.ErrorIs(t, queue.Health(), ErrObserverClosed)
+}
+
+func TestQueuedObserver_Spool(t *testing.T) {
+	options := testOptions(t)
+	options.MaxRetries = 1
+
+	// Получатель недоступен: события сохраняются в spool
+	failing := &recordingObserver{failures: -1}
+	queue := NewQueuedObserver("test", failing, options, zap.NewNop().Sugar())
+	notify(t, queue, 4)
+	require.NoError(t, queue.Close(context.Background()))
+
+	assert.Empty(t, failing.delivered())
+	assert.Equal(t, uint64(4), queue.Stats().Spooled)
+
+	// После перезапуска события из spool доставляются повторно
+	target := &recordingObserver{}
+	queue = NewQueuedObserver("test", target, options, zap.NewNop().Sugar())
+	notify(t, queue, 1)
+	require.NoError(t, queue.Close(context.Background()))
+
+	assert.Len(t, target.delivered(), 5)
+	assert.Equal(t, uint64(4), queue.Stats().Replayed)
+	assert.Equal(t, uint64(5), queue.Stats().Delivered)
+}
+
+func TestQueuedObserver_Overflow(t *testing.T) {
+	options := testOptions(t)
+	options.QueueSize = 1
+	options.BatchSize = 1
+
+	target := &recordingObserver{release: make(chan struct{})}
+	queue := NewQueuedObserver("test", target, options, zap.NewNop().Sugar())
+
+	// Первое событие забирает обработчик очереди, второе занимает очередь, остальные идут в spool
+	notify(t, queue, 1)
+	require.Eventually(t, func() bool { return queue.Stats().Queued == 0 }, time.Second, time.Millisecond)
+	notify(t, queue, 3)
+
+	assert.Equal(t, uint64(2), queue.Stats().Spooled)
+
+	close(target.release)
+	require.NoError(t, queue.Close(context.Background()))
+
+	// При закрытии события из spool также доставляются
+	assert.Len(t, target.delivered(), 4)
+	assert.Equal(t, uint64(0), queue.Stats().Dropped)
+}
+
+func TestQueuedObserver_CloseTimeout(t *testing.T) {
+	options := testOptions(t)
+	options.MaxRetries = 100
+	options.RetryBackoff = time.Hour
+	options.MaxBackoff = time.Hour
+
+	target := &recordingObserver{failures: -1}
+	queue := NewQueuedObserver("test", target, options, zap.NewNop().Sugar())
+	notify(t, queue, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, queue.Close(ctx), context.DeadlineExceeded)
+	assert.Equal(t, uint64(2), queue.Stats().Spooled)
+
+	// События, поступившие после закрытия, не теряются
+	notify(t, queue, 1)
+	assert.Equal(t, uint64(3), queue.Stats().Spooled)
+}
+
+func TestHTTPAuditObserver_NotifyBatch(t *testing.T) {
+	var received [][]*AuditEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*AuditEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&events))
+		received = append(received, events)
+	}))
+	defer server.Close()
+
+	observer := NewHTTPAuditObserver(server.URL, HTTPOptions{Timeout: time.Second}, zap.NewNop().Sugar())
+	err := observer.NotifyBatch(context.Background(), []*AuditEvent{
+		NewAuditEvent(ActionShorten, "user", "http://a.example.com"),
+		NewAuditEvent(ActionFollow, "user", "http://a.example.com"),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, received, 1)
+	assert.Len(t, received[0], 2)
+	assert.Equal(t, ActionFollow, received[0][1].Action)
+}
+
+// ctxObserver запоминает ошибку контекста, с которым был вызван.
+type ctxObserver struct {
+	mutex sync.Mutex
+	errs  []error
+	block bool
+}
+
+func (o *ctxObserver) Notify(ctx context.Context, _ *AuditEvent) error {
+	if o.block {
+		<-ctx.Done()
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.errs = append(o.errs, ctx.Err())
+
+	return ctx.Err()
+}
+
+func TestAuditService_PublishOutlivesRequest(t *testing.T) {
+	observer := &ctxObserver{}
+	service := NewAuditService(zap.NewNop().Sugar(), DefaultDeliveryOptions())
+	service.AddObserver(observer)
+
+	// Запрос уже завершен, но событие доставляется с действующим контекстом
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.Emit(ctx, NewAuditEvent(ActionFollow, "user", "http://example.com"))
+
+	require.Len(t, observer.errs, 1)
+	assert.NoError(t, observer.errs[0])
+}
+
+func TestQueuedObserver_DeliveryTimeout(t *testing.T) {
+	options := testOptions(t)
+	options.MaxRetries = 1
+	options.DeliveryTimeout = 10 * time.Millisecond
+
+	observer := &ctxObserver{block: true}
+	queue := NewQueuedObserver("test", observer, options, zap.NewNop().Sugar())
+	notify(t, queue, 1)
+	require.NoError(t, queue.Close(context.Background()))
+
+	// Каждая попытка (две доставки и повтор из spool при закрытии) прерывается
+	// по таймауту доставки, событие остается в spool
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	require.Len(t, observer.errs, 3)
+	assert.ErrorIs(t, observer.errs[0], context.DeadlineExceeded)
+	assert.Equal(t, uint64(1), queue.Stats().Spooled)
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/rotation"
+)
+
+// RotationOptions задает ротацию файла аудита и хранение ротированных файлов.
+type RotationOptions struct {
+	MaxSize    int64         // Ротировать, когда файл превысит размер в байтах (0 - не ротировать по размеру)
+	Interval   time.Duration // Ротировать файл не реже указанного интервала (0 - не ротировать по времени)
+	Compress   bool          // Сжимать ротированные файлы gzip
+	MaxBackups int           // Сколько ротированных файлов хранить (0 - без ограничения)
+	MaxAge     time.Duration // Сколько хранить ротированные файлы (0 - без ограничения)
+}
+
+// needRotation сообщает, нужно ли ротировать файл перед записью pending байт.
+// Пустой файл не ротируется.
+func (f *FileAuditObserver) needRotation(pending int64) bool {
+	rotation := f.opts.Rotation
+	if f.size == 0 {
+		return false
+	}
+
+	if rotation.MaxSize > 0 && f.size+pending > rotation.MaxSize {
+		return true
+	}
+
+	return rotation.Interval > 0 && f.now().Sub(f.openedAt) >= rotation.Interval
+}
+
+// rotate переименовывает текущий файл, открывает новый и применяет правила хранения.
+// Ошибки сжатия и удаления старых файлов не мешают записи и только возвращаются.
+func (f *FileAuditObserver) rotate() error {
+	if err := f.close(); err != nil {
+		return err
+	}
+
+	now := f.now()
+	rotated := rotation.Name(f.filePath, now)
+	renameErr := os.Rename(f.filePath, rotated)
+
+	if err := f.open(); err != nil {
+		return errors.Join(renameErr, err)
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+
+	var errs []error
+	if f.opts.Rotation.Compress {
+		errs = append(errs, compressFile(rotated))
+	}
+	errs = append(errs, rotation.RemoveExpired(f.filePath, f.opts.Rotation.MaxBackups, f.opts.Rotation.MaxAge, now))
+
+	return errors.Join(errs...)
+}
+
+// compressFile сжимает файл в path.gz и удаляет исходный файл.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if syncErr := dst.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/rotation"
+)
+
+func newRotatingObserver(t *testing.T, path string, opts FileOptions, now *time.Time) *FileAuditObserver {
+	observer, err := NewFileAuditObserver(path, opts, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	t.Cleanup(func() { observer.Close() })
+
+	observer.now = func() time.Time { return *now }
+	observer.openedAt = *now
+
+	return observer
+}
+
+func writeEvents(t *testing.T, observer *FileAuditObserver, count int, now *time.Time) {
+	for i := 0; i < count; i++ {
+		*now = now.Add(time.Second)
+		require.NoError(t, observer.Notify(context.Background(), NewAuditEvent(ActionShorten, "user", "http://example.com")))
+	}
+}
+
+func TestFileAuditObserver_RotateBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	observer := newRotatingObserver(t, path, FileOptions{
+		Chain:    true,
+		HMACKey:  []byte("key"),
+		Rotation: RotationOptions{MaxSize: 1000, Compress: true},
+	}, &now)
+
+	writeEvents(t, observer, 10, &now)
+	require.NoError(t, observer.Close())
+
+	rotated, err := rotation.Files(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, rotated)
+
+	// Ротированные файлы сжаты, а цепочка продолжается через все файлы
+	verifier := NewChainVerifier(NewChain([]byte("key")))
+	for _, file := range append(rotated, path) {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(1000))
+
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		if strings.HasSuffix(file, ".gz") {
+			zr, err := gzip.NewReader(f)
+			require.NoError(t, err)
+			require.NoError(t, verifier.Verify(zr))
+		} else {
+			assert.Equal(t, path, file)
+			require.NoError(t, verifier.Verify(f))
+		}
+		f.Close()
+	}
+	assert.Equal(t, 10, verifier.Records)
+}
+
+func TestFileAuditObserver_RotateByTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	observer := newRotatingObserver(t, path, FileOptions{Rotation: RotationOptions{Interval: time.Hour}}, &now)
+
+	writeEvents(t, observer, 3, &now)
+	now = now.Add(2 * time.Hour)
+	writeEvents(t, observer, 1, &now)
+
+	rotated, err := rotation.Files(path)
+	require.NoError(t, err)
+	require.Len(t, rotated, 1)
+	assert.Equal(t, path+".20250101T020004.000", rotated[0])
+
+	data, err := os.ReadFile(rotated[0])
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
+
+func TestFileAuditObserver_Retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rotation  RotationOptions
+		rotations int
+		want      int
+	}{
+		{name: "#1 unlimited", rotation: RotationOptions{Interval: time.Minute}, rotations: 4, want: 4},
+		{name: "#2 max backups", rotation: RotationOptions{Interval: time.Minute, MaxBackups: 2}, rotations: 4, want: 2},
+		// Остается только файл, ротированный последним: остальные старше MaxAge
+		{name: "#3 max age", rotation: RotationOptions{Interval: time.Minute, MaxAge: time.Hour}, rotations: 4, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, _ := rotation.Files(path)
+			for _, file := range files {
+				require.NoError(t, os.Remove(file))
+			}
+
+			observer := newRotatingObserver(t, path, FileOptions{Rotation: tt.rotation}, &now)
+			for i := 0; i < tt.rotations; i++ {
+				writeEvents(t, observer, 1, &now)
+				now = now.Add(time.Minute)
+
+				// Ротированные файлы "стареют" вместе с часами наблюдателя
+				files, _ := rotation.Files(path)
+				for _, file := range files {
+					require.NoError(t, os.Chtimes(file, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+				}
+			}
+			writeEvents(t, observer, 1, &now)
+
+			files, err := rotation.Files(path)
+			require.NoError(t, err)
+			assert.Len(t, files, tt.want)
+		})
+	}
+}
+
+func TestFileAuditObserver_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	now := time.Now()
+	observer := newRotatingObserver(t, path, FileOptions{}, &now)
+
+	writeEvents(t, observer, 2, &now)
+
+	// Внешняя ротация переименовывает файл и отправляет SIGHUP
+	moved := filepath.Join(dir, "audit.log.1")
+	require.NoError(t, os.Rename(path, moved))
+	require.NoError(t, observer.Reopen())
+	writeEvents(t, observer, 1, &now)
+
+	data, err := os.ReadFile(moved)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
@@ -2,51 +2,215 @@ package audit
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
 )
 
 type AuditService struct {
 	subject Subject
+	opts    DeliveryOptions
+	queues  []*QueuedObserver
 	log     *zap.SugaredLogger
 }
 
-func NewAuditService(log *zap.SugaredLogger) *AuditService {
+// NewAuditService создает сервис аудита.
+// opts задает параметры доставки событий для наблюдателей, добавленных
+// через AddQueuedObserver и другие методы Add*Observer, кроме AddObserver.
+func NewAuditService(log *zap.SugaredLogger, opts DeliveryOptions) *AuditService {
 	return &AuditService{
 		subject: NewAuditSubject(log),
+		opts:    opts,
 		log:     log,
 	}
 }
 
-func (s *AuditService) AddFileObserver(filePath string) error {
+// AddObserver подключает произвольного наблюдателя.
+// Наблюдатель вызывается синхронно при записи события.
+func (s *AuditService) AddObserver(observer Observer) {
+	s.subject.Attach(observer)
+}
+
+// AddQueuedObserver подключает наблюдателя через очередь доставки с именем name.
+// Имя используется в статистике доставки и имени файла spool, поэтому должно быть уникальным.
+func (s *AuditService) AddQueuedObserver(name string, observer Observer) error {
+	for _, queue := range s.queues {
+		if queue.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicateObserver, name)
+		}
+	}
+
+	queue := NewQueuedObserver(name, observer, s.opts, s.log)
+	s.queues = append(s.queues, queue)
+	s.subject.Attach(queue)
+	return nil
+}
+
+// AddFileObserver подключает запись событий в файл filePath.
+func (s *AuditService) AddFileObserver(name, filePath string, opts FileOptions) error {
 	if filePath == "" {
 		return nil
 	}
 
-	observer := NewFileAuditObserver(filePath, s.log)
-	s.subject.Attach(observer)
+	observer, err := NewFileAuditObserver(filePath, opts, s.log)
+	if err != nil {
+		return err
+	}
+
+	if err := s.AddQueuedObserver(name, observer); err != nil {
+		_ = observer.Close()
+		return err
+	}
 	return nil
 }
 
-func (s *AuditService) AddHTTPObserver(url string) error {
+// AddHTTPObserver подключает отправку событий на url.
+func (s *AuditService) AddHTTPObserver(name, url string, opts HTTPOptions) error {
 	if url == "" {
 		return nil
 	}
 
-	observer := NewHTTPAuditObserver(url, s.log)
-	s.subject.Attach(observer)
-	return nil
+	return s.AddQueuedObserver(name, NewHTTPAuditObserver(url, opts, s.log))
 }
 
-func (s *AuditService) LogEvent(ctx context.Context, action, userID, url string) {
-	event := NewAuditEvent(action, userID, url)
-	s.subject.NotifyObservers(ctx, event)
+// AddSyslogObserver подключает отправку событий в syslog на address.
+func (s *AuditService) AddSyslogObserver(name, address string, opts SyslogOptions) error {
+	if address == "" {
+		return nil
+	}
+
+	observer, err := NewSyslogAuditObserver(address, opts, s.log)
+	if err != nil {
+		return err
+	}
+
+	return s.AddQueuedObserver(name, observer)
 }
 
-func (s *AuditService) LogShortenEvent(ctx context.Context, userID, url string) {
-	s.LogEvent(ctx, ActionShorten, userID, url)
+// AddTCPObserver подключает отправку событий в формате JSON Lines по TCP на address.
+// timeout ограничивает время подключения и одной отправки.
+func (s *AuditService) AddTCPObserver(name, address string, timeout time.Duration) error {
+	if address == "" {
+		return nil
+	}
+
+	return s.AddQueuedObserver(name, NewTCPAuditObserver(address, timeout, s.log))
 }
 
-func (s *AuditService) LogFollowEvent(ctx context.Context, userID, url string) {
-	s.LogEvent(ctx, ActionFollow, userID, url)
+// AddStoreObserver подключает сохранение событий в хранилище для поиска.
+func (s *AuditService) AddStoreObserver(name string, store EventStore) error {
+	if store == nil {
+		return nil
+	}
+
+	return s.AddQueuedObserver(name, NewStoreObserver(store))
+}
+
+// SetFilter задает фильтр событий для наблюдателя с именем name (nil - все события).
+func (s *AuditService) SetFilter(name string, filter *Filter) error {
+	for _, queue := range s.queues {
+		if queue.name == name {
+			s.subject.SetFilter(queue, filter)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrObserverNotFound, name)
+}
+
+// Emit записывает событие аудита.
+// Событие дополняется сведениями о запросе и пользователе из контекста.
+// Если в контексте есть накопитель (WithRecorder), событие откладывается до завершения
+// запроса, иначе сразу передается наблюдателям.
+func (s *AuditService) Emit(ctx context.Context, event *AuditEvent) {
+	if event.UserID == "" {
+		event.UserID, _ = ctx.Value(models.ContextUserID).(string)
+	}
+
+	if info, ok := RequestInfoFromContext(ctx); ok {
+		event.RequestID = info.RequestID
+		event.ClientIP = info.ClientIP
+		event.UserAgent = info.UserAgent
+	}
+	if event.RequestID == "" {
+		event.RequestID, _ = ctx.Value(models.ContextRequestID).(string)
+	}
+
+	if recorder, ok := recorderFromContext(ctx); ok {
+		recorder.add(event)
+		return
+	}
+
+	s.Publish(ctx, event)
+}
+
+// Publish передает события наблюдателям без дополнительной обработки.
+// Отмена ctx не передается наблюдателям: событие, записанное в конце HTTP-запроса,
+// должно быть доставлено и после того, как запрос завершен.
+func (s *AuditService) Publish(ctx context.Context, events ...*AuditEvent) {
+	ctx = context.WithoutCancel(ctx)
+	for _, event := range events {
+		s.subject.NotifyObservers(ctx, event)
+	}
+}
+
+// DeliveryStats возвращает показатели доставки по именам наблюдателей.
+func (s *AuditService) DeliveryStats() map[string]DeliveryStats {
+	stats := make(map[string]DeliveryStats, len(s.queues))
+	for _, queue := range s.queues {
+		stats[queue.name] = queue.Stats()
+	}
+
+	return stats
+}
+
+// Health возвращает состояние получателей, подключенных через очередь доставки:
+// ошибку последней попытки доставки или nil, если она удалась.
+func (s *AuditService) Health() map[string]error {
+	health := make(map[string]error, len(s.queues))
+	for _, queue := range s.queues {
+		health[queue.name] = queue.Health()
+	}
+
+	return health
+}
+
+// Reopener - наблюдатель, который умеет заново открыть свой файл.
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen заново открывает файлы наблюдателей, например после ротации внешней утилитой.
+func (s *AuditService) Reopen() error {
+	var errs []error
+	for _, queue := range s.queues {
+		if reopener, ok := queue.target.(Reopener); ok {
+			errs = append(errs, reopener.Reopen())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close доставляет события, оставшиеся в очередях, и останавливает их обработку.
+// Если ctx завершается раньше, недоставленные события сохраняются в spool.
+func (s *AuditService) Close(ctx context.Context) error {
+	errs := make([]error, len(s.queues))
+
+	var wg sync.WaitGroup
+	for i, queue := range s.queues {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = queue.Close(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
 }
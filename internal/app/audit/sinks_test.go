@@ -0,0 +1,250 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHTTPAuditObserver_Signing(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		opts          HTTPOptions
+		wantSignature bool
+	}{
+		{
+			name:          "#1 signed",
+			opts:          HTTPOptions{HMACKey: []byte("secret"), Headers: map[string]string{"X-Tenant": "shortener"}},
+			wantSignature: true,
+		},
+		{
+			name: "#2 unsigned",
+			opts: HTTPOptions{Headers: map[string]string{"Content-Type": "text/plain"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observer := NewHTTPAuditObserver(server.URL, tt.opts, zap.NewNop().Sugar())
+			observer.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+			require.NoError(t, observer.Notify(context.Background(), NewAuditEvent(ActionShorten, "user", "http://example.com")))
+			req := <-received
+
+			// Заголовок Content-Type не переопределяется пользовательскими заголовками
+			assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+			for name, value := range tt.opts.Headers {
+				if name != "Content-Type" {
+					assert.Equal(t, value, req.header.Get(name))
+				}
+			}
+
+			if !tt.wantSignature {
+				assert.Empty(t, req.header.Get(SignatureHeader))
+				return
+			}
+			assert.Equal(t, "1700000000", req.header.Get(TimestampHeader))
+			assert.Equal(t, "sha256="+signPayload([]byte("secret"), "1700000000", req.body), req.header.Get(SignatureHeader))
+			assert.NotEqual(t, req.header.Get(SignatureHeader), "sha256="+signPayload([]byte("other"), "1700000000", req.body))
+		})
+	}
+}
+
+var syslogPattern = regexp.MustCompile(`^<(\d+)>1 (\S+) host shortener \d+ (\S+) - (\{.*\})$`)
+
+func TestSyslogAuditObserver(t *testing.T) {
+	failed := NewAuditEvent(ActionLogin, "user", "").Fail(assert.AnError)
+	events := []*AuditEvent{NewAuditEvent(ActionShorten, "user", "http://example.com"), failed}
+
+	tests := []struct {
+		name     string
+		network  string
+		facility string
+		wantPRI  []string
+	}{
+		{name: "#1 udp", network: "udp", wantPRI: []string{"134", "132"}},
+		{name: "#2 tcp", network: "tcp", facility: "auth", wantPRI: []string{"38", "36"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, messages := listenSyslog(t, tt.network)
+
+			observer, err := NewSyslogAuditObserver(address, SyslogOptions{
+				Network:  tt.network,
+				Facility: tt.facility,
+				Hostname: "host",
+				Timeout:  time.Second,
+			}, zap.NewNop().Sugar())
+			require.NoError(t, err)
+			defer observer.Close()
+
+			require.NoError(t, observer.NotifyBatch(context.Background(), events))
+
+			for i, event := range events {
+				var message string
+				select {
+				case message = <-messages:
+				case <-time.After(time.Second):
+					t.Fatal("message not received")
+				}
+
+				match := syslogPattern.FindStringSubmatch(message)
+				require.NotNil(t, match, message)
+				assert.Equal(t, tt.wantPRI[i], match[1])
+				assert.Equal(t, event.Action, match[3])
+
+				var decoded AuditEvent
+				require.NoError(t, json.Unmarshal([]byte(match[4]), &decoded))
+				assert.Equal(t, event.ID, decoded.ID)
+			}
+		})
+	}
+}
+
+func TestNewSyslogAuditObserver_InvalidOptions(t *testing.T) {
+	_, err := NewSyslogAuditObserver("localhost:514", SyslogOptions{Network: "unix"}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, err, ErrUnsupportedNetwork)
+
+	_, err = NewSyslogAuditObserver("localhost:514", SyslogOptions{Facility: "local9"}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, err, ErrUnknownFacility)
+}
+
+// listenSyslog принимает сообщения syslog и передает их в канал.
+// По TCP сообщения разбираются по подсчету октетов.
+func listenSyslog(t *testing.T, network string) (string, <-chan string) {
+	messages := make(chan string, 10)
+
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		go func() {
+			buf := make([]byte, 64*1024)
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				messages <- string(buf[:n])
+			}
+		}()
+
+		return conn.LocalAddr().String(), messages
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			size, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			message := make([]byte, size)
+			if _, err := io.ReadFull(reader, message); err != nil {
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+
+	return listener.Addr().String(), messages
+}
+
+func TestTCPAuditObserver_Reconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+
+	observer := NewTCPAuditObserver(listener.Addr().String(), time.Second, zap.NewNop().Sugar())
+	defer observer.Close()
+
+	first := NewAuditEvent(ActionShorten, "user", "http://a.example.com")
+	second := NewAuditEvent(ActionFollow, "user", "http://a.example.com")
+	require.NoError(t, observer.NotifyBatch(context.Background(), []*AuditEvent{first, second}))
+
+	// После разрыва соединения следующая отправка подключается заново
+	require.NoError(t, observer.conn.Close())
+	third := NewAuditEvent(ActionDelete, "user", "http://a.example.com")
+	require.NoError(t, observer.Notify(context.Background(), third))
+
+	// Строки из разных соединений читаются параллельно, поэтому порядок не проверяется
+	var ids []string
+	for range 3 {
+		select {
+		case line := <-lines:
+			var event AuditEvent
+			require.NoError(t, json.Unmarshal([]byte(line), &event))
+			ids = append(ids, event.ID)
+		case <-time.After(time.Second):
+			t.Fatal("event not received")
+		}
+	}
+	assert.ElementsMatch(t, []string{first.ID, second.ID, third.ID}, ids)
+}
+
+func TestAuditService_DuplicateObserverName(t *testing.T) {
+	service := NewAuditService(zap.NewNop().Sugar(), testOptions(t))
+	defer service.Close(context.Background())
+
+	require.NoError(t, service.AddTCPObserver("siem", "127.0.0.1:1", time.Second))
+	err := service.AddHTTPObserver("siem", "http://127.0.0.1:1", HTTPOptions{})
+	assert.ErrorIs(t, err, ErrDuplicateObserver)
+	assert.Len(t, service.DeliveryStats(), 1)
+}
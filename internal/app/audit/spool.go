@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Spool хранит на диске события, которые не удалось доставить наблюдателю.
+// Новые события дописываются в основной файл. Для повторной доставки файл
+// переименовывается в файл с суффиксом ".replay", который удаляется только
+// после успешной доставки, поэтому сбой процесса во время повтора не теряет события.
+type Spool struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewSpool создает spool в файле path, создавая каталог при необходимости.
+func NewSpool(path string) (*Spool, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	return &Spool{path: path}, nil
+}
+
+// Append сохраняет события в конец spool и сбрасывает их на диск.
+func (s *Spool) Append(events []*AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	data, err := marshalLines(events)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// Replay передает сохраненные события в deliver.
+// При успешной доставке события удаляются из spool, при ошибке остаются для следующей попытки.
+// Возвращает количество доставленных событий.
+func (s *Spool) Replay(deliver func(events []*AuditEvent) error) (int, error) {
+	replayPath := s.path + ".replay"
+
+	s.mutex.Lock()
+	// Файл повтора остается от предыдущей неудачной попытки; новые события ждут своей очереди
+	if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+		err = os.Rename(s.path, replayPath)
+		if errors.Is(err, os.ErrNotExist) {
+			s.mutex.Unlock()
+			return 0, nil
+		}
+		if err != nil {
+			s.mutex.Unlock()
+			return 0, err
+		}
+	}
+	s.mutex.Unlock()
+
+	events, err := readLines(replayPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(events) > 0 {
+		if err := deliver(events); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), os.Remove(replayPath)
+}
+
+// readLines читает события из файла в формате JSON Lines.
+// Поврежденные строки (например, недописанные при сбое) пропускаются.
+func readLines(path string) ([]*AuditEvent, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []*AuditEvent
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		event := new(AuditEvent)
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
@@ -0,0 +1,30 @@
+package audit
+
+import "context"
+
+// EventStore сохраняет события аудита для последующего поиска.
+// Повторное сохранение события с тем же идентификатором не должно создавать дубликат:
+// при повторах доставки событие может прийти несколько раз.
+type EventStore interface {
+	SaveEvents(ctx context.Context, events []*AuditEvent) error
+}
+
+// StoreObserver передает события в хранилище событий.
+type StoreObserver struct {
+	store EventStore
+}
+
+// NewStoreObserver создает наблюдателя, сохраняющего события в store.
+func NewStoreObserver(store EventStore) *StoreObserver {
+	return &StoreObserver{store: store}
+}
+
+// Notify сохраняет одно событие.
+func (s *StoreObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	return s.store.SaveEvents(ctx, []*AuditEvent{event})
+}
+
+// NotifyBatch сохраняет несколько событий одним обращением к хранилищу.
+func (s *StoreObserver) NotifyBatch(ctx context.Context, events []*AuditEvent) error {
+	return s.store.SaveEvents(ctx, events)
+}
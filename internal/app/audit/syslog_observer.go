@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// syslogTimeFormat - формат TIMESTAMP по RFC 5424 с миллисекундами.
+const syslogTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Уровни важности сообщений syslog.
+const (
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+)
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// SyslogOptions задает параметры отправки событий в syslog.
+type SyslogOptions struct {
+	Network  string        // Протокол: tcp или udp (по умолчанию udp)
+	Facility string        // Facility, например local0 или auth (по умолчанию local0)
+	AppName  string        // APP-NAME сообщения (по умолчанию shortener)
+	Hostname string        // HOSTNAME сообщения (по умолчанию имя хоста)
+	Timeout  time.Duration // Ограничение времени подключения и одной отправки (0 - без ограничения)
+}
+
+// SyslogAuditObserver отправляет события сообщениями RFC 5424.
+// Тело сообщения - событие в JSON, MSGID - действие события.
+// Неудачные действия отправляются с уровнем warning, остальные - info.
+// По TCP сообщения разделяются подсчетом октетов (RFC 6587), по UDP каждое сообщение
+// отправляется отдельной датаграммой.
+type SyslogAuditObserver struct {
+	conn     *netConn
+	stream   bool
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	log      *zap.SugaredLogger
+}
+
+// NewSyslogAuditObserver создает наблюдателя, отправляющего события на address (host:port).
+func NewSyslogAuditObserver(address string, opts SyslogOptions, log *zap.SugaredLogger) (*SyslogAuditObserver, error) {
+	network := strings.ToLower(opts.Network)
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, opts.Network)
+	}
+
+	facilityName := strings.ToLower(opts.Facility)
+	if facilityName == "" {
+		facilityName = "local0"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFacility, opts.Facility)
+	}
+
+	appName := opts.AppName
+	if appName == "" {
+		appName = "shortener"
+	}
+
+	hostname := opts.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &SyslogAuditObserver{
+		conn:     newNetConn(network, address, opts.Timeout),
+		stream:   network == "tcp",
+		facility: facility,
+		appName:  appName,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		log:      log,
+	}, nil
+}
+
+// Notify отправляет одно событие.
+func (s *SyslogAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	return s.NotifyBatch(ctx, []*AuditEvent{event})
+}
+
+// NotifyBatch отправляет события: по TCP одной записью, по UDP датаграммой на событие.
+func (s *SyslogAuditObserver) NotifyBatch(ctx context.Context, events []*AuditEvent) error {
+	packets := make([][]byte, 0, len(events))
+	var stream bytes.Buffer
+
+	for _, event := range events {
+		message, err := s.message(event)
+		if err != nil {
+			return err
+		}
+
+		if s.stream {
+			stream.WriteString(strconv.Itoa(len(message)))
+			stream.WriteByte(' ')
+			stream.Write(message)
+			continue
+		}
+		packets = append(packets, message)
+	}
+
+	if s.stream {
+		return s.conn.write(ctx, stream.Bytes())
+	}
+
+	return s.conn.write(ctx, packets...)
+}
+
+// message формирует сообщение RFC 5424:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG.
+func (s *SyslogAuditObserver) message(event *AuditEvent) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации события аудита: %w", err)
+	}
+
+	severity := syslogSeverityInfo
+	if event.Result == ResultFailure {
+		severity = syslogSeverityWarning
+	}
+
+	msgID := event.Action
+	if msgID == "" {
+		msgID = "-"
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s - ",
+		s.facility*8+severity,
+		time.UnixMilli(event.Timestamp).UTC().Format(syslogTimeFormat),
+		s.hostname,
+		s.appName,
+		s.procID,
+		msgID,
+	)
+
+	return append([]byte(header), data...), nil
+}
+
+// Close закрывает соединение.
+func (s *SyslogAuditObserver) Close() error {
+	return s.conn.Close()
+}
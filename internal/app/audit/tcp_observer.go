@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TCPAuditObserver отправляет события по TCP в формате JSON Lines: одно событие в строке.
+// Такой поток принимают сборщики логов (Vector, Fluent Bit, Logstash) и источники
+// Kafka Connect, которые затем публикуют события в топик.
+type TCPAuditObserver struct {
+	conn *netConn
+	log  *zap.SugaredLogger
+}
+
+// NewTCPAuditObserver создает наблюдателя, отправляющего события на address (host:port).
+// timeout ограничивает время подключения и одной отправки (0 - без ограничения).
+func NewTCPAuditObserver(address string, timeout time.Duration, log *zap.SugaredLogger) *TCPAuditObserver {
+	return &TCPAuditObserver{
+		conn: newNetConn("tcp", address, timeout),
+		log:  log,
+	}
+}
+
+// Notify отправляет одно событие.
+func (t *TCPAuditObserver) Notify(ctx context.Context, event *AuditEvent) error {
+	return t.NotifyBatch(ctx, []*AuditEvent{event})
+}
+
+// NotifyBatch отправляет события одной записью в соединение.
+func (t *TCPAuditObserver) NotifyBatch(ctx context.Context, events []*AuditEvent) error {
+	data, err := marshalLines(events)
+	if err != nil {
+		return err
+	}
+
+	return t.conn.write(ctx, data)
+}
+
+// Close закрывает соединение.
+func (t *TCPAuditObserver) Close() error {
+	return t.conn.Close()
+}
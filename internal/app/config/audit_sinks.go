@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// auditSinkJSON - представление AuditSink в JSON-конфигурации и переменной AUDIT_SINKS.
+type auditSinkJSON struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Path     string            `json:"path"`
+	URL      string            `json:"url"`
+	Address  string            `json:"address"`
+	Network  string            `json:"network"`
+	Facility string            `json:"facility"`
+	AppName  string            `json:"app_name"`
+	Timeout  string            `json:"timeout"`
+	HMACKey  string            `json:"hmac_key"`
+	Headers  map[string]string `json:"headers"`
+
+	Actions     []string `json:"actions"`
+	UserIDs     []string `json:"user_ids"`
+	URLPatterns []string `json:"url_patterns"`
+	SampleRate  *float64 `json:"sample_rate"`
+}
+
+// sink возвращает описание получателя.
+// Возвращает ErrInvalidValue, если timeout или sample_rate заданы некорректно.
+func (s auditSinkJSON) sink() (AuditSink, error) {
+	sink := AuditSink{
+		Name:     strings.TrimSpace(s.Name),
+		Type:     strings.ToLower(strings.TrimSpace(s.Type)),
+		Path:     strings.TrimSpace(s.Path),
+		URL:      strings.TrimSpace(s.URL),
+		Address:  strings.TrimSpace(s.Address),
+		Network:  strings.ToLower(strings.TrimSpace(s.Network)),
+		Facility: strings.ToLower(strings.TrimSpace(s.Facility)),
+		AppName:  strings.TrimSpace(s.AppName),
+		HMACKey:  s.HMACKey,
+		Headers:  s.Headers,
+
+		Actions:     trimValues(s.Actions),
+		UserIDs:     trimValues(s.UserIDs),
+		URLPatterns: s.URLPatterns,
+	}
+
+	if strings.TrimSpace(s.Timeout) != "" {
+		value, err := parsePositiveDuration("timeout", s.Timeout)
+		if err != nil {
+			return AuditSink{}, fmt.Errorf("audit sink %q: %w", s.displayName(), err)
+		}
+		sink.Timeout = value
+	}
+
+	if s.SampleRate != nil {
+		if *s.SampleRate < 0 || *s.SampleRate > 1 {
+			return AuditSink{}, fmt.Errorf("audit sink %q: %w: sample_rate %v, expected a number from 0 to 1", s.displayName(), ErrInvalidValue, *s.SampleRate)
+		}
+		sink.SampleRate = *s.SampleRate
+	}
+
+	return sink, nil
+}
+
+// displayName возвращает имя получателя для сообщений об ошибках (по умолчанию - тип).
+func (s auditSinkJSON) displayName() string {
+	if name := strings.TrimSpace(s.Name); name != "" {
+		return name
+	}
+
+	return strings.ToLower(strings.TrimSpace(s.Type))
+}
+
+// trimValues удаляет пробелы по краям значений и пустые значения.
+func trimValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+func auditSinks(sinks []auditSinkJSON) ([]AuditSink, error) {
+	result := make([]AuditSink, 0, len(sinks))
+	for _, s := range sinks {
+		sink, err := s.sink()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sink)
+	}
+
+	return result, nil
+}
+
+// parseAuditSinks разбирает список получателей аудита из JSON-массива.
+func parseAuditSinks(data string) ([]AuditSink, error) {
+	var sinks []auditSinkJSON
+	if err := json.Unmarshal([]byte(data), &sinks); err != nil {
+		return nil, err
+	}
+
+	return auditSinks(sinks)
+}
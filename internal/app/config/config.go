@@ -1,20 +1,148 @@
 package config
 
+import "time"
+
 // Config представляет конфигурацию приложения.
 // Содержит все необходимые параметры для работы сервиса сокращения URL.
 type Config struct {
-	Host            string // Адрес и порт для запуска HTTP-сервера
-	ShortURLHost    string // Базовый URL для создания коротких ссылок
-	FileStoragePath string // Путь к файлу для хранения данных (если используется файловое хранилище)
-	DatabaseDSN     string // Строка подключения к базе данных
-	AuthSecret      string // Секретный ключ для аутентификации
-	AuditFile       string // Путь к файлу аудита
-	AuditURL        string // URL для отправки аудита
-	EnabledHTTPS    bool   // Сервер будет использовать SSL
+	Host                string // Адрес и порт для запуска HTTP-сервера
+	ShortURLHost        string // Базовый URL для создания коротких ссылок
+	FileStoragePath     string // Путь к файлу для хранения данных (если используется файловое хранилище)
+	DatabaseDSN         string // Строка подключения к базе данных
+	AuthSecret          string // Секретный ключ для аутентификации
+	AuditFile           string // Путь к файлу аудита
+	AuditURL            string // URL для отправки аудита
+	EnabledHTTPS        bool   // Сервер будет использовать SSL
+	RedirectQueryPolicy string // Политика параметров запроса при переходе по ссылке: append, override или drop
+
+	AllowedURLSchemes    string // Разрешенные схемы сокращаемых URL через запятую
+	AllowPrivateNetworks bool   // Разрешить URL, указывающие на localhost и частные сети
+	ResolveURLHosts      bool   // Разрешать имена хостов через DNS при проверке сети назначения
+	BlocklistFile        string // Путь к файлу со списком заблокированных доменов
+
+	NormalizeURLs          bool // Приводить URL к каноническому виду перед сохранением и дедупликацией
+	NormalizeSortQuery     bool // Сортировать параметры запроса при нормализации
+	NormalizeStripTracking bool // Удалять параметры отслеживания (utm_*, fbclid и т.д.) при нормализации
+	PreserveOriginalURL    bool // Перенаправлять на URL в точности как его передал пользователь
+
+	UserStoragePath  string // Путь к файлу учетных записей (если используется файловое хранилище)
+	TokenStoragePath string // Путь к файлу API-токенов (если используется файловое хранилище)
+
+	AuthKeyID            string        // Идентификатор текущего ключа подписи JWT (kid); пусто - вычисляется по ключу
+	AuthPrivateKeyFile   string        // PEM-файл закрытого ключа RSA или Ed25519 для подписи JWT (пусто - HS256 с AuthSecret)
+	AuthPreviousKeyFiles string        // PEM-файлы предыдущих ключей через запятую, принимаемых в течение AuthKeyGracePeriod
+	AuthPreviousSecrets  string        // Предыдущие секреты HS256 через запятую, принимаемые в течение AuthKeyGracePeriod
+	AuthKeyGracePeriod   time.Duration // Сколько после запуска принимаются токены, подписанные предыдущими ключами
+
+	AuthStrict         bool // Строгий режим: недействительный токен отклоняется с кодом 401, а не заменяется новым
+	AuthIssueAnonymous bool // Выдавать анонимный идентификатор на маршрутах, где это разрешено
+
+	AdminUserIDs   string // Идентификаторы пользователей с ролью администратора через запятую
+	BanStoragePath string // Путь к файлу блокировок пользователей (если используется файловое хранилище)
+
+	RateLimitCreate     string // Ограничение создания ссылок на пользователя или IP (пусто - выключено), например RATE_LIMIT_CREATE="120/m"
+	RateLimitRedirect   string // Ограничение переходов по ссылкам на пользователя или IP (пусто - выключено), например RATE_LIMIT_REDIRECT="1200/m"
+	RateLimitStore      string // Хранилище ограничений: memory или postgres
+	RateLimitTrustProxy bool   // Брать IP-адрес клиента из заголовков X-Forwarded-For и X-Real-IP
+
+	LinkQuota              int    // Максимальное количество активных ссылок пользователя (0 - без ограничения)
+	LinkQuotaExemptUserIDs string // Пользователи без квоты ссылок через запятую
+
+	AuditQueueSize     int           // Размер очереди событий аудита для каждого получателя
+	AuditBatchSize     int           // Максимальное количество событий аудита в одной доставке
+	AuditFlushInterval time.Duration // Как часто доставляется неполный пакет событий аудита
+	AuditMaxRetries    int           // Количество повторов доставки событий аудита перед сохранением в spool
+	AuditRetryBackoff  time.Duration // Пауза перед первым повтором доставки; каждая следующая вдвое больше
+	AuditSpoolDir      string        // Каталог для недоставленных событий аудита (пусто - события отбрасываются)
+	AuditHTTPTimeout   time.Duration // Ограничение времени одного запроса к AuditURL и HTTP-получателям без своего Timeout
+
+	AuditDeliveryTimeout time.Duration // Ограничение времени одной попытки доставки событий аудита
+	AuditShutdownTimeout time.Duration // Сколько при остановке ждать доставки событий аудита, оставшихся в очередях
+
+	AuditChain   bool   // Записывать файл аудита в виде цепочки хешей, защищенной от незаметного изменения
+	AuditHMACKey string // Ключ HMAC для записей цепочки аудита (пусто - без HMAC)
+
+	AuditMaxSizeMB      int           // Ротировать файл аудита при превышении размера в мегабайтах (0 - не ротировать по размеру)
+	AuditRotateInterval time.Duration // Ротировать файл аудита с указанным интервалом (0 - не ротировать по времени)
+	AuditCompress       bool          // Сжимать ротированные файлы аудита gzip
+	AuditMaxBackups     int           // Сколько ротированных файлов аудита хранить (0 - без ограничения)
+	AuditMaxAge         time.Duration // Сколько хранить ротированные файлы аудита (0 - без ограничения)
+
+	AuditSinks []AuditSink // Получатели событий аудита в дополнение к AuditFile и AuditURL
+
+	AuditStore          bool          // Сохранять события аудита в хранилище для поиска через административный API
+	AuditStorePath      string        // Путь к файлу хранилища событий аудита (если используется файловое хранилище)
+	AuditStoreActions   string        // Сохранять только события с этими действиями через запятую (пусто - все)
+	AuditStoreMaxEvents int           // Сколько последних событий хранить для поиска (0 - без ограничения)
+	AuditStoreMaxAge    time.Duration // Сколько хранить события для поиска (0 - без ограничения)
+
+	TracingExporter    string  // Экспортер трасс: otlp, stdout или file (пусто - трассировка отключена)
+	TracingEndpoint    string  // Адрес коллектора OTLP/HTTP (для экспортера otlp)
+	TracingFile        string  // Путь к файлу трасс в формате JSON Lines (для экспортера file)
+	TracingSampleRatio float64 // Доля записываемых трасс от 0 до 1
+
+	LogLevel              string        // Минимальный уровень журнала: debug, info, warn или error
+	LogFormat             string        // Формат журнала: json или console
+	LogSamplingInitial    int           // Сколько одинаковых записей журнала в секунду пишется полностью (0 - без ограничения)
+	LogSamplingThereafter int           // Из последующих одинаковых записей журнала в ту же секунду пишется каждая N-я
+	LogFile               string        // Файл журнала (пусто - стандартный поток ошибок)
+	LogMaxSizeMB          int           // Ротировать файл журнала при превышении размера в мегабайтах (0 - не ротировать)
+	LogMaxBackups         int           // Сколько ротированных файлов журнала хранить (0 - без ограничения)
+	LogMaxAge             time.Duration // Сколько хранить ротированные файлы журнала (0 - без ограничения)
+
+	ShutdownDelay time.Duration // Сколько после сигнала остановки отвечать отказом на /readyz, прежде чем остановить сервер
+
+	StorageMode            string        // Хранилище данных: memory, file или postgres (пусто - по DatabaseDSN и FileStoragePath)
+	StorageStrict          bool          // Строгий режим: не запускаться, если хранилище не совпадает с настройками или недоступно
+	StorageFallback        bool          // Использовать файловое хранилище или хранилище в памяти, если база данных недоступна
+	DatabaseConnectRetries int           // Количество повторных попыток подключения к базе данных при запуске
+	DatabaseConnectBackoff time.Duration // Пауза перед первым повтором подключения; каждая следующая вдвое больше
+}
+
+// Хранилища данных.
+const (
+	StorageModeMemory   = "memory"   // В памяти процесса, данные теряются при остановке
+	StorageModeFile     = "file"     // Файлы JSON Lines
+	StorageModePostgres = "postgres" // База данных PostgreSQL
+)
+
+// Экспортеры трасс.
+const (
+	TracingExporterOTLP   = "otlp"   // Коллектор OpenTelemetry по OTLP/HTTP
+	TracingExporterStdout = "stdout" // JSON Lines в стандартный вывод
+	TracingExporterFile   = "file"   // JSON Lines в файл
+)
+
+// Типы получателей событий аудита.
+const (
+	AuditSinkFile   = "file"   // Запись в файл
+	AuditSinkHTTP   = "http"   // POST-запрос с JSON
+	AuditSinkSyslog = "syslog" // Сообщения RFC 5424 по TCP или UDP
+	AuditSinkTCP    = "tcp"    // JSON Lines по TCP
+)
+
+// AuditSink описывает одного получателя событий аудита.
+type AuditSink struct {
+	Name     string            // Имя получателя в статистике и имени файла spool (по умолчанию - тип)
+	Type     string            // Тип получателя: file, http, syslog или tcp
+	Path     string            // Путь к файлу (file)
+	URL      string            // Адрес отправки событий (http)
+	Address  string            // Адрес host:port (syslog, tcp)
+	Network  string            // Протокол syslog: tcp или udp (по умолчанию udp)
+	Facility string            // Facility syslog, например local0 или auth (по умолчанию local0)
+	AppName  string            // APP-NAME в сообщениях syslog (по умолчанию shortener)
+	Timeout  time.Duration     // Ограничение времени одной отправки (0 - AuditHTTPTimeout для http, иначе без ограничения)
+	HMACKey  string            // Ключ подписи HTTP-запросов (пусто - без подписи)
+	Headers  map[string]string // Дополнительные заголовки HTTP-запросов
+
+	Actions     []string // Передавать только события с этими действиями (пусто - все)
+	UserIDs     []string // Передавать только события этих пользователей (пусто - все)
+	URLPatterns []string // Передавать только события с URL, подходящим под одно из регулярных выражений (пусто - все)
+	SampleRate  float64  // Доля передаваемых успешных событий от 0 до 1 (0 - все события)
 }
 
 // NewConfig создает новую конфигурацию, объединяя значения из переданных провайдеров.
-// Провайдеры применяются в порядке их передачи.
+// Провайдеры применяются в порядке их передачи, ошибки провайдеров игнорируются.
 func NewConfig(providers ...Provider) Config {
 	conf := Config{}
 	for _, provider := range providers {
@@ -24,6 +152,19 @@ func NewConfig(providers ...Provider) Config {
 	return conf
 }
 
+// LoadConfig создает конфигурацию так же, как NewConfig, но возвращает первую ошибку провайдера,
+// например ErrInvalidValue для некорректного значения параметра.
+func LoadConfig(providers ...Provider) (Config, error) {
+	conf := Config{}
+	for _, provider := range providers {
+		if err := conf.setValues(provider); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return conf, nil
+}
+
 func (c *Config) setValues(provider Provider) error {
 	return provider.setValues(c)
 }
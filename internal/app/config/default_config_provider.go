@@ -3,6 +3,7 @@ package config
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"time"
 )
 
 type DefaultProvider struct{}
@@ -15,6 +16,72 @@ func (d *DefaultProvider) setValues(c *Config) error {
 	c.AuthSecret = d.getAuthSecret()
 	c.AuditFile = "audit.log"
 	c.AuditURL = ""
+	c.RedirectQueryPolicy = "drop"
+	c.AllowedURLSchemes = "http,https"
+	c.AllowPrivateNetworks = false
+	c.ResolveURLHosts = false
+	c.BlocklistFile = ""
+	c.NormalizeURLs = true
+	c.NormalizeSortQuery = false
+	c.NormalizeStripTracking = false
+	c.PreserveOriginalURL = true
+	c.UserStoragePath = "users"
+	c.TokenStoragePath = "tokens"
+	c.AuthKeyID = ""
+	c.AuthPrivateKeyFile = ""
+	c.AuthPreviousKeyFiles = ""
+	c.AuthPreviousSecrets = ""
+	c.AuthKeyGracePeriod = 3 * time.Hour
+	c.AuthStrict = false
+	c.AuthIssueAnonymous = true
+	c.AdminUserIDs = ""
+	c.BanStoragePath = "bans"
+	c.RateLimitCreate = ""
+	c.RateLimitRedirect = ""
+	c.RateLimitStore = "memory"
+	c.RateLimitTrustProxy = false
+	c.LinkQuota = 0
+	c.LinkQuotaExemptUserIDs = ""
+	c.AuditQueueSize = 1000
+	c.AuditBatchSize = 50
+	c.AuditFlushInterval = time.Second
+	c.AuditMaxRetries = 5
+	c.AuditRetryBackoff = 200 * time.Millisecond
+	c.AuditSpoolDir = "audit_spool"
+	c.AuditHTTPTimeout = 5 * time.Second
+	c.AuditDeliveryTimeout = 10 * time.Second
+	c.AuditShutdownTimeout = 15 * time.Second
+	c.AuditChain = false
+	c.AuditHMACKey = ""
+	c.AuditMaxSizeMB = 0
+	c.AuditRotateInterval = 0
+	c.AuditCompress = true
+	c.AuditMaxBackups = 0
+	c.AuditMaxAge = 0
+	c.AuditStore = true
+	c.AuditStorePath = "audit_events"
+	// Переходы по ссылкам самые частые события, поэтому по умолчанию не сохраняются для поиска
+	c.AuditStoreActions = "shorten,delete,update,login,admin"
+	c.AuditStoreMaxEvents = 100000
+	c.AuditStoreMaxAge = 30 * 24 * time.Hour
+	c.TracingExporter = ""
+	c.TracingEndpoint = "http://localhost:4318"
+	c.TracingFile = "traces.jsonl"
+	c.TracingSampleRatio = 1
+	c.LogLevel = "info"
+	c.LogFormat = "json"
+	c.LogSamplingInitial = 100
+	c.LogSamplingThereafter = 100
+	c.LogFile = ""
+	c.LogMaxSizeMB = 0
+	c.LogMaxBackups = 0
+	c.LogMaxAge = 0
+	c.ShutdownDelay = 0
+	c.StorageMode = ""
+	c.StorageStrict = false
+	c.StorageFallback = false
+	c.DatabaseConnectRetries = 5
+	c.DatabaseConnectBackoff = 500 * time.Millisecond
 	return nil
 }
 
@@ -13,4 +13,6 @@ func TestDefaultProvider(t *testing.T) {
 	assert.Equal(t, "http://localhost:8080", config.ShortURLHost)
 	assert.Equal(t, "", config.DatabaseDSN)
 	assert.Equal(t, "store", config.FileStoragePath)
+	assert.Equal(t, "", config.RateLimitCreate)
+	assert.Equal(t, "", config.RateLimitRedirect)
 }
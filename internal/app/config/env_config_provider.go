@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strings"
 )
@@ -61,5 +62,439 @@ func (env *EnvProvider) setValues(c *Config) error {
 		c.EnabledHTTPS = enabledHTTPS == "true"
 	}
 
+	redirectQueryPolicy, ok := env.getter.LookupEnv("REDIRECT_QUERY_POLICY")
+	if ok && strings.TrimSpace(redirectQueryPolicy) != "" {
+		c.RedirectQueryPolicy = redirectQueryPolicy
+	}
+
+	allowedURLSchemes, ok := env.getter.LookupEnv("ALLOWED_URL_SCHEMES")
+	if ok && strings.TrimSpace(allowedURLSchemes) != "" {
+		c.AllowedURLSchemes = allowedURLSchemes
+	}
+
+	allowPrivateNetworks, ok := env.getter.LookupEnv("ALLOW_PRIVATE_NETWORKS")
+	if ok && strings.TrimSpace(allowPrivateNetworks) != "" {
+		c.AllowPrivateNetworks = allowPrivateNetworks == "true"
+	}
+
+	resolveURLHosts, ok := env.getter.LookupEnv("RESOLVE_URL_HOSTS")
+	if ok && strings.TrimSpace(resolveURLHosts) != "" {
+		c.ResolveURLHosts = resolveURLHosts == "true"
+	}
+
+	blocklistFile, ok := env.getter.LookupEnv("BLOCKLIST_FILE")
+	if ok && strings.TrimSpace(blocklistFile) != "" {
+		c.BlocklistFile = blocklistFile
+	}
+
+	normalizeURLs, ok := env.getter.LookupEnv("NORMALIZE_URLS")
+	if ok && strings.TrimSpace(normalizeURLs) != "" {
+		c.NormalizeURLs = normalizeURLs == "true"
+	}
+
+	normalizeSortQuery, ok := env.getter.LookupEnv("NORMALIZE_SORT_QUERY")
+	if ok && strings.TrimSpace(normalizeSortQuery) != "" {
+		c.NormalizeSortQuery = normalizeSortQuery == "true"
+	}
+
+	normalizeStripTracking, ok := env.getter.LookupEnv("NORMALIZE_STRIP_TRACKING")
+	if ok && strings.TrimSpace(normalizeStripTracking) != "" {
+		c.NormalizeStripTracking = normalizeStripTracking == "true"
+	}
+
+	preserveOriginalURL, ok := env.getter.LookupEnv("PRESERVE_ORIGINAL_URL")
+	if ok && strings.TrimSpace(preserveOriginalURL) != "" {
+		c.PreserveOriginalURL = preserveOriginalURL == "true"
+	}
+
+	userStoragePath, ok := env.getter.LookupEnv("USER_STORAGE_PATH")
+	if ok && strings.TrimSpace(userStoragePath) != "" {
+		c.UserStoragePath = userStoragePath
+	}
+
+	tokenStoragePath, ok := env.getter.LookupEnv("TOKEN_STORAGE_PATH")
+	if ok && strings.TrimSpace(tokenStoragePath) != "" {
+		c.TokenStoragePath = tokenStoragePath
+	}
+
+	authKeyID, ok := env.getter.LookupEnv("AUTH_KEY_ID")
+	if ok && strings.TrimSpace(authKeyID) != "" {
+		c.AuthKeyID = authKeyID
+	}
+
+	authPrivateKeyFile, ok := env.getter.LookupEnv("AUTH_PRIVATE_KEY_FILE")
+	if ok && strings.TrimSpace(authPrivateKeyFile) != "" {
+		c.AuthPrivateKeyFile = authPrivateKeyFile
+	}
+
+	authPreviousKeyFiles, ok := env.getter.LookupEnv("AUTH_PREVIOUS_KEY_FILES")
+	if ok && strings.TrimSpace(authPreviousKeyFiles) != "" {
+		c.AuthPreviousKeyFiles = authPreviousKeyFiles
+	}
+
+	authPreviousSecrets, ok := env.getter.LookupEnv("AUTH_PREVIOUS_SECRETS")
+	if ok && strings.TrimSpace(authPreviousSecrets) != "" {
+		c.AuthPreviousSecrets = authPreviousSecrets
+	}
+
+	authKeyGracePeriod, ok := env.getter.LookupEnv("AUTH_KEY_GRACE_PERIOD")
+	if ok && strings.TrimSpace(authKeyGracePeriod) != "" {
+		value, err := parseDuration("AUTH_KEY_GRACE_PERIOD", authKeyGracePeriod)
+		if err != nil {
+			return err
+		}
+		c.AuthKeyGracePeriod = value
+	}
+
+	authStrict, ok := env.getter.LookupEnv("AUTH_STRICT")
+	if ok && strings.TrimSpace(authStrict) != "" {
+		c.AuthStrict = authStrict == "true"
+	}
+
+	authIssueAnonymous, ok := env.getter.LookupEnv("AUTH_ISSUE_ANONYMOUS")
+	if ok && strings.TrimSpace(authIssueAnonymous) != "" {
+		c.AuthIssueAnonymous = authIssueAnonymous == "true"
+	}
+
+	adminUserIDs, ok := env.getter.LookupEnv("ADMIN_USER_IDS")
+	if ok && strings.TrimSpace(adminUserIDs) != "" {
+		c.AdminUserIDs = adminUserIDs
+	}
+
+	banStoragePath, ok := env.getter.LookupEnv("BAN_STORAGE_PATH")
+	if ok && strings.TrimSpace(banStoragePath) != "" {
+		c.BanStoragePath = banStoragePath
+	}
+
+	rateLimitCreate, ok := env.getter.LookupEnv("RATE_LIMIT_CREATE")
+	if ok && strings.TrimSpace(rateLimitCreate) != "" {
+		c.RateLimitCreate = rateLimitCreate
+	}
+
+	rateLimitRedirect, ok := env.getter.LookupEnv("RATE_LIMIT_REDIRECT")
+	if ok && strings.TrimSpace(rateLimitRedirect) != "" {
+		c.RateLimitRedirect = rateLimitRedirect
+	}
+
+	rateLimitStore, ok := env.getter.LookupEnv("RATE_LIMIT_STORE")
+	if ok && strings.TrimSpace(rateLimitStore) != "" {
+		c.RateLimitStore = rateLimitStore
+	}
+
+	rateLimitTrustProxy, ok := env.getter.LookupEnv("RATE_LIMIT_TRUST_PROXY")
+	if ok && strings.TrimSpace(rateLimitTrustProxy) != "" {
+		c.RateLimitTrustProxy = rateLimitTrustProxy == "true"
+	}
+
+	linkQuota, ok := env.getter.LookupEnv("LINK_QUOTA")
+	if ok && strings.TrimSpace(linkQuota) != "" {
+		value, err := parseCount("LINK_QUOTA", linkQuota)
+		if err != nil {
+			return err
+		}
+		c.LinkQuota = value
+	}
+
+	linkQuotaExemptUserIDs, ok := env.getter.LookupEnv("LINK_QUOTA_EXEMPT_USER_IDS")
+	if ok && strings.TrimSpace(linkQuotaExemptUserIDs) != "" {
+		c.LinkQuotaExemptUserIDs = linkQuotaExemptUserIDs
+	}
+
+	auditQueueSize, ok := env.getter.LookupEnv("AUDIT_QUEUE_SIZE")
+	if ok && strings.TrimSpace(auditQueueSize) != "" {
+		value, err := parsePositiveCount("AUDIT_QUEUE_SIZE", auditQueueSize)
+		if err != nil {
+			return err
+		}
+		c.AuditQueueSize = value
+	}
+
+	auditBatchSize, ok := env.getter.LookupEnv("AUDIT_BATCH_SIZE")
+	if ok && strings.TrimSpace(auditBatchSize) != "" {
+		value, err := parsePositiveCount("AUDIT_BATCH_SIZE", auditBatchSize)
+		if err != nil {
+			return err
+		}
+		c.AuditBatchSize = value
+	}
+
+	auditFlushInterval, ok := env.getter.LookupEnv("AUDIT_FLUSH_INTERVAL")
+	if ok && strings.TrimSpace(auditFlushInterval) != "" {
+		value, err := parsePositiveDuration("AUDIT_FLUSH_INTERVAL", auditFlushInterval)
+		if err != nil {
+			return err
+		}
+		c.AuditFlushInterval = value
+	}
+
+	auditMaxRetries, ok := env.getter.LookupEnv("AUDIT_MAX_RETRIES")
+	if ok && strings.TrimSpace(auditMaxRetries) != "" {
+		value, err := parseCount("AUDIT_MAX_RETRIES", auditMaxRetries)
+		if err != nil {
+			return err
+		}
+		c.AuditMaxRetries = value
+	}
+
+	auditRetryBackoff, ok := env.getter.LookupEnv("AUDIT_RETRY_BACKOFF")
+	if ok && strings.TrimSpace(auditRetryBackoff) != "" {
+		value, err := parseDuration("AUDIT_RETRY_BACKOFF", auditRetryBackoff)
+		if err != nil {
+			return err
+		}
+		c.AuditRetryBackoff = value
+	}
+
+	auditSpoolDir, ok := env.getter.LookupEnv("AUDIT_SPOOL_DIR")
+	if ok && strings.TrimSpace(auditSpoolDir) != "" {
+		c.AuditSpoolDir = auditSpoolDir
+	}
+
+	auditHTTPTimeout, ok := env.getter.LookupEnv("AUDIT_HTTP_TIMEOUT")
+	if ok && strings.TrimSpace(auditHTTPTimeout) != "" {
+		value, err := parsePositiveDuration("AUDIT_HTTP_TIMEOUT", auditHTTPTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditHTTPTimeout = value
+	}
+
+	auditDeliveryTimeout, ok := env.getter.LookupEnv("AUDIT_DELIVERY_TIMEOUT")
+	if ok && strings.TrimSpace(auditDeliveryTimeout) != "" {
+		value, err := parsePositiveDuration("AUDIT_DELIVERY_TIMEOUT", auditDeliveryTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditDeliveryTimeout = value
+	}
+
+	auditShutdownTimeout, ok := env.getter.LookupEnv("AUDIT_SHUTDOWN_TIMEOUT")
+	if ok && strings.TrimSpace(auditShutdownTimeout) != "" {
+		value, err := parsePositiveDuration("AUDIT_SHUTDOWN_TIMEOUT", auditShutdownTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditShutdownTimeout = value
+	}
+
+	auditChain, ok := env.getter.LookupEnv("AUDIT_CHAIN")
+	if ok && strings.TrimSpace(auditChain) != "" {
+		c.AuditChain = auditChain == "true"
+	}
+
+	auditHMACKey, ok := env.getter.LookupEnv("AUDIT_HMAC_KEY")
+	if ok && strings.TrimSpace(auditHMACKey) != "" {
+		c.AuditHMACKey = auditHMACKey
+	}
+
+	auditMaxSizeMB, ok := env.getter.LookupEnv("AUDIT_MAX_SIZE_MB")
+	if ok && strings.TrimSpace(auditMaxSizeMB) != "" {
+		value, err := parseCount("AUDIT_MAX_SIZE_MB", auditMaxSizeMB)
+		if err != nil {
+			return err
+		}
+		c.AuditMaxSizeMB = value
+	}
+
+	auditRotateInterval, ok := env.getter.LookupEnv("AUDIT_ROTATE_INTERVAL")
+	if ok && strings.TrimSpace(auditRotateInterval) != "" {
+		value, err := parseDuration("AUDIT_ROTATE_INTERVAL", auditRotateInterval)
+		if err != nil {
+			return err
+		}
+		c.AuditRotateInterval = value
+	}
+
+	auditCompress, ok := env.getter.LookupEnv("AUDIT_COMPRESS")
+	if ok && strings.TrimSpace(auditCompress) != "" {
+		c.AuditCompress = auditCompress == "true"
+	}
+
+	auditMaxBackups, ok := env.getter.LookupEnv("AUDIT_MAX_BACKUPS")
+	if ok && strings.TrimSpace(auditMaxBackups) != "" {
+		value, err := parseCount("AUDIT_MAX_BACKUPS", auditMaxBackups)
+		if err != nil {
+			return err
+		}
+		c.AuditMaxBackups = value
+	}
+
+	auditMaxAge, ok := env.getter.LookupEnv("AUDIT_MAX_AGE")
+	if ok && strings.TrimSpace(auditMaxAge) != "" {
+		value, err := parseDuration("AUDIT_MAX_AGE", auditMaxAge)
+		if err != nil {
+			return err
+		}
+		c.AuditMaxAge = value
+	}
+
+	auditStore, ok := env.getter.LookupEnv("AUDIT_STORE")
+	if ok && strings.TrimSpace(auditStore) != "" {
+		c.AuditStore = auditStore == "true"
+	}
+
+	auditStorePath, ok := env.getter.LookupEnv("AUDIT_STORE_PATH")
+	if ok && strings.TrimSpace(auditStorePath) != "" {
+		c.AuditStorePath = auditStorePath
+	}
+
+	auditStoreActions, ok := env.getter.LookupEnv("AUDIT_STORE_ACTIONS")
+	if ok && strings.TrimSpace(auditStoreActions) != "" {
+		c.AuditStoreActions = auditStoreActions
+	}
+
+	auditStoreMaxEvents, ok := env.getter.LookupEnv("AUDIT_STORE_MAX_EVENTS")
+	if ok && strings.TrimSpace(auditStoreMaxEvents) != "" {
+		value, err := parseCount("AUDIT_STORE_MAX_EVENTS", auditStoreMaxEvents)
+		if err != nil {
+			return err
+		}
+		c.AuditStoreMaxEvents = value
+	}
+
+	auditStoreMaxAge, ok := env.getter.LookupEnv("AUDIT_STORE_MAX_AGE")
+	if ok && strings.TrimSpace(auditStoreMaxAge) != "" {
+		value, err := parseDuration("AUDIT_STORE_MAX_AGE", auditStoreMaxAge)
+		if err != nil {
+			return err
+		}
+		c.AuditStoreMaxAge = value
+	}
+
+	auditSinks, ok := env.getter.LookupEnv("AUDIT_SINKS")
+	if ok && strings.TrimSpace(auditSinks) != "" {
+		value, err := parseAuditSinks(auditSinks)
+		if err != nil {
+			return fmt.Errorf("AUDIT_SINKS: %w", err)
+		}
+		c.AuditSinks = value
+	}
+
+	tracingExporter, ok := env.getter.LookupEnv("TRACING_EXPORTER")
+	if ok && strings.TrimSpace(tracingExporter) != "" {
+		c.TracingExporter = strings.ToLower(strings.TrimSpace(tracingExporter))
+	}
+
+	tracingEndpoint, ok := env.getter.LookupEnv("TRACING_ENDPOINT")
+	if ok && strings.TrimSpace(tracingEndpoint) != "" {
+		c.TracingEndpoint = strings.TrimSpace(tracingEndpoint)
+	}
+
+	tracingFile, ok := env.getter.LookupEnv("TRACING_FILE")
+	if ok && strings.TrimSpace(tracingFile) != "" {
+		c.TracingFile = tracingFile
+	}
+
+	tracingSampleRatio, ok := env.getter.LookupEnv("TRACING_SAMPLE_RATIO")
+	if ok && strings.TrimSpace(tracingSampleRatio) != "" {
+		value, err := parseRatio("TRACING_SAMPLE_RATIO", tracingSampleRatio)
+		if err != nil {
+			return err
+		}
+		c.TracingSampleRatio = value
+	}
+
+	logLevel, ok := env.getter.LookupEnv("LOG_LEVEL")
+	if ok && strings.TrimSpace(logLevel) != "" {
+		c.LogLevel = strings.ToLower(strings.TrimSpace(logLevel))
+	}
+
+	logFormat, ok := env.getter.LookupEnv("LOG_FORMAT")
+	if ok && strings.TrimSpace(logFormat) != "" {
+		c.LogFormat = strings.ToLower(strings.TrimSpace(logFormat))
+	}
+
+	logSamplingInitial, ok := env.getter.LookupEnv("LOG_SAMPLING_INITIAL")
+	if ok && strings.TrimSpace(logSamplingInitial) != "" {
+		value, err := parseCount("LOG_SAMPLING_INITIAL", logSamplingInitial)
+		if err != nil {
+			return err
+		}
+		c.LogSamplingInitial = value
+	}
+
+	logSamplingThereafter, ok := env.getter.LookupEnv("LOG_SAMPLING_THEREAFTER")
+	if ok && strings.TrimSpace(logSamplingThereafter) != "" {
+		value, err := parseCount("LOG_SAMPLING_THEREAFTER", logSamplingThereafter)
+		if err != nil {
+			return err
+		}
+		c.LogSamplingThereafter = value
+	}
+
+	logFile, ok := env.getter.LookupEnv("LOG_FILE")
+	if ok && strings.TrimSpace(logFile) != "" {
+		c.LogFile = logFile
+	}
+
+	logMaxSizeMB, ok := env.getter.LookupEnv("LOG_MAX_SIZE_MB")
+	if ok && strings.TrimSpace(logMaxSizeMB) != "" {
+		value, err := parseCount("LOG_MAX_SIZE_MB", logMaxSizeMB)
+		if err != nil {
+			return err
+		}
+		c.LogMaxSizeMB = value
+	}
+
+	logMaxBackups, ok := env.getter.LookupEnv("LOG_MAX_BACKUPS")
+	if ok && strings.TrimSpace(logMaxBackups) != "" {
+		value, err := parseCount("LOG_MAX_BACKUPS", logMaxBackups)
+		if err != nil {
+			return err
+		}
+		c.LogMaxBackups = value
+	}
+
+	logMaxAge, ok := env.getter.LookupEnv("LOG_MAX_AGE")
+	if ok && strings.TrimSpace(logMaxAge) != "" {
+		value, err := parseDuration("LOG_MAX_AGE", logMaxAge)
+		if err != nil {
+			return err
+		}
+		c.LogMaxAge = value
+	}
+
+	shutdownDelay, ok := env.getter.LookupEnv("SHUTDOWN_DELAY")
+	if ok && strings.TrimSpace(shutdownDelay) != "" {
+		value, err := parseDuration("SHUTDOWN_DELAY", shutdownDelay)
+		if err != nil {
+			return err
+		}
+		c.ShutdownDelay = value
+	}
+
+	storageMode, ok := env.getter.LookupEnv("STORAGE_MODE")
+	if ok && strings.TrimSpace(storageMode) != "" {
+		c.StorageMode = strings.ToLower(strings.TrimSpace(storageMode))
+	}
+
+	storageStrict, ok := env.getter.LookupEnv("STORAGE_STRICT")
+	if ok && strings.TrimSpace(storageStrict) != "" {
+		c.StorageStrict = storageStrict == "true"
+	}
+
+	storageFallback, ok := env.getter.LookupEnv("STORAGE_FALLBACK")
+	if ok && strings.TrimSpace(storageFallback) != "" {
+		c.StorageFallback = storageFallback == "true"
+	}
+
+	databaseConnectRetries, ok := env.getter.LookupEnv("DATABASE_CONNECT_RETRIES")
+	if ok && strings.TrimSpace(databaseConnectRetries) != "" {
+		value, err := parseCount("DATABASE_CONNECT_RETRIES", databaseConnectRetries)
+		if err != nil {
+			return err
+		}
+		c.DatabaseConnectRetries = value
+	}
+
+	databaseConnectBackoff, ok := env.getter.LookupEnv("DATABASE_CONNECT_BACKOFF")
+	if ok && strings.TrimSpace(databaseConnectBackoff) != "" {
+		value, err := parsePositiveDuration("DATABASE_CONNECT_BACKOFF", databaseConnectBackoff)
+		if err != nil {
+			return err
+		}
+		c.DatabaseConnectBackoff = value
+	}
+
 	return nil
 }
@@ -2,9 +2,11 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sviatilnik/url-shortener/internal/app/config/mock_config"
 )
@@ -22,12 +24,129 @@ func TestEnvProvider(t *testing.T) {
 	m.EXPECT().LookupEnv("AUDIT_FILE").Return("audit-file", true).AnyTimes()
 	m.EXPECT().LookupEnv("AUDIT_URL").Return("audit-url", true).AnyTimes()
 	m.EXPECT().LookupEnv("ENABLE_HTTPS").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("REDIRECT_QUERY_POLICY").Return("append", true).AnyTimes()
+	m.EXPECT().LookupEnv("ALLOW_PRIVATE_NETWORKS").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("BLOCKLIST_FILE").Return("/tmp/blocklist.txt", true).AnyTimes()
+	m.EXPECT().LookupEnv("NORMALIZE_SORT_QUERY").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("PRESERVE_ORIGINAL_URL").Return("false", true).AnyTimes()
+	m.EXPECT().LookupEnv("USER_STORAGE_PATH").Return("/tmp/users", true).AnyTimes()
+	m.EXPECT().LookupEnv("TOKEN_STORAGE_PATH").Return("/tmp/tokens", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUTH_PREVIOUS_SECRETS").Return("old-secret", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUTH_KEY_GRACE_PERIOD").Return("30m", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUTH_STRICT").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("ADMIN_USER_IDS").Return("admin1,admin2", true).AnyTimes()
+	m.EXPECT().LookupEnv("RATE_LIMIT_CREATE").Return("10/s", true).AnyTimes()
+	m.EXPECT().LookupEnv("RATE_LIMIT_TRUST_PROXY").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("LINK_QUOTA").Return("50", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_BATCH_SIZE").Return("10", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_MAX_RETRIES").Return("0", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_FLUSH_INTERVAL").Return("2s", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_SPOOL_DIR").Return("/tmp/spool", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_SHUTDOWN_TIMEOUT").Return("30s", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_CHAIN").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_HMAC_KEY").Return("audit-key", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_MAX_SIZE_MB").Return("100", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_ROTATE_INTERVAL").Return("24h", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_COMPRESS").Return("false", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_SINKS").Return(`[{"type":"syslog","address":"siem:514","network":"TCP","timeout":"3s"}]`, true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_STORE").Return("false", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_STORE_ACTIONS").Return("shorten,delete", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_STORE_MAX_EVENTS").Return("0", true).AnyTimes()
+	m.EXPECT().LookupEnv("AUDIT_STORE_MAX_AGE").Return("168h", true).AnyTimes()
+	m.EXPECT().LookupEnv("TRACING_EXPORTER").Return(" OTLP ", true).AnyTimes()
+	m.EXPECT().LookupEnv("TRACING_SAMPLE_RATIO").Return("0.5", true).AnyTimes()
+	m.EXPECT().LookupEnv("LOG_LEVEL").Return(" DEBUG ", true).AnyTimes()
+	m.EXPECT().LookupEnv("LOG_FORMAT").Return("console", true).AnyTimes()
+	m.EXPECT().LookupEnv("LOG_SAMPLING_INITIAL").Return("0", true).AnyTimes()
+	m.EXPECT().LookupEnv("LOG_MAX_SIZE_MB").Return("20", true).AnyTimes()
+	m.EXPECT().LookupEnv("LOG_MAX_AGE").Return("72h", true).AnyTimes()
+	m.EXPECT().LookupEnv("SHUTDOWN_DELAY").Return("5s", true).AnyTimes()
+	m.EXPECT().LookupEnv("STORAGE_MODE").Return(" Postgres ", true).AnyTimes()
+	m.EXPECT().LookupEnv("STORAGE_STRICT").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("STORAGE_FALLBACK").Return("true", true).AnyTimes()
+	m.EXPECT().LookupEnv("DATABASE_CONNECT_RETRIES").Return("10", true).AnyTimes()
+	m.EXPECT().LookupEnv("DATABASE_CONNECT_BACKOFF").Return("3s", true).AnyTimes()
+	m.EXPECT().LookupEnv(gomock.Any()).Return("", false).AnyTimes()
 
-	config := NewConfig(NewEnvProvider(m))
+	config, err := LoadConfig(NewEnvProvider(m))
+	require.NoError(t, err)
 
 	assert.Equal(t, "https://google.com", config.Host)
 	assert.Equal(t, "https://short.google.com", config.ShortURLHost)
 	assert.Equal(t, "database_dsn", config.DatabaseDSN)
 	assert.Equal(t, "/tmp/file_storage", config.FileStoragePath)
 	assert.Equal(t, true, config.EnabledHTTPS)
+	assert.Equal(t, "append", config.RedirectQueryPolicy)
+	assert.Equal(t, true, config.AllowPrivateNetworks)
+	assert.Equal(t, "/tmp/blocklist.txt", config.BlocklistFile)
+	assert.Equal(t, true, config.NormalizeSortQuery)
+	assert.Equal(t, false, config.PreserveOriginalURL)
+	assert.Equal(t, "/tmp/users", config.UserStoragePath)
+	assert.Equal(t, "/tmp/tokens", config.TokenStoragePath)
+	assert.Equal(t, "old-secret", config.AuthPreviousSecrets)
+	assert.Equal(t, 30*time.Minute, config.AuthKeyGracePeriod)
+	assert.Equal(t, true, config.AuthStrict)
+	assert.Equal(t, "admin1,admin2", config.AdminUserIDs)
+	assert.Equal(t, "10/s", config.RateLimitCreate)
+	assert.Equal(t, true, config.RateLimitTrustProxy)
+	assert.Equal(t, 50, config.LinkQuota)
+	assert.Equal(t, 10, config.AuditBatchSize)
+	assert.Equal(t, 0, config.AuditMaxRetries)
+	assert.Equal(t, 2*time.Second, config.AuditFlushInterval)
+	assert.Equal(t, "/tmp/spool", config.AuditSpoolDir)
+	assert.Equal(t, 30*time.Second, config.AuditShutdownTimeout)
+	assert.Equal(t, true, config.AuditChain)
+	assert.Equal(t, "audit-key", config.AuditHMACKey)
+	assert.Equal(t, 100, config.AuditMaxSizeMB)
+	assert.Equal(t, 24*time.Hour, config.AuditRotateInterval)
+	assert.Equal(t, false, config.AuditCompress)
+	assert.Equal(t, false, config.AuditStore)
+	assert.Equal(t, "shorten,delete", config.AuditStoreActions)
+	assert.Equal(t, 0, config.AuditStoreMaxEvents)
+	assert.Equal(t, 168*time.Hour, config.AuditStoreMaxAge)
+	assert.Equal(t, TracingExporterOTLP, config.TracingExporter)
+	assert.Equal(t, 0.5, config.TracingSampleRatio)
+	assert.Equal(t, "debug", config.LogLevel)
+	assert.Equal(t, "console", config.LogFormat)
+	assert.Equal(t, 0, config.LogSamplingInitial)
+	assert.Equal(t, 20, config.LogMaxSizeMB)
+	assert.Equal(t, 72*time.Hour, config.LogMaxAge)
+	assert.Equal(t, 5*time.Second, config.ShutdownDelay)
+	assert.Equal(t, StorageModePostgres, config.StorageMode)
+	assert.Equal(t, true, config.StorageStrict)
+	assert.Equal(t, true, config.StorageFallback)
+	assert.Equal(t, 10, config.DatabaseConnectRetries)
+	assert.Equal(t, 3*time.Second, config.DatabaseConnectBackoff)
+	assert.Equal(t, []AuditSink{{Type: AuditSinkSyslog, Address: "siem:514", Network: "tcp", Timeout: 3 * time.Second}}, config.AuditSinks)
+}
+
+func TestEnvProvider_InvalidValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{name: "#1 bad duration", key: "AUTH_KEY_GRACE_PERIOD", value: "bad"},
+		{name: "#2 negative duration", key: "LOG_MAX_AGE", value: "-1h"},
+		{name: "#3 zero positive duration", key: "AUDIT_FLUSH_INTERVAL", value: "0s"},
+		{name: "#4 bad integer", key: "LINK_QUOTA", value: "ten"},
+		{name: "#5 negative integer", key: "LOG_MAX_SIZE_MB", value: "-1"},
+		{name: "#6 zero positive integer", key: "AUDIT_QUEUE_SIZE", value: "0"},
+		{name: "#7 ratio above 1", key: "TRACING_SAMPLE_RATIO", value: "1.5"},
+		{name: "#8 audit sink sample rate", key: "AUDIT_SINKS", value: `[{"type":"syslog","address":"siem:514","sample_rate":2}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_config.NewMockEnvGetter(ctrl)
+			m.EXPECT().LookupEnv(tt.key).Return(tt.value, true).AnyTimes()
+			m.EXPECT().LookupEnv(gomock.Any()).Return("", false).AnyTimes()
+
+			_, err := LoadConfig(NewEnvProvider(m))
+			assert.ErrorIs(t, err, ErrInvalidValue)
+			assert.ErrorContains(t, err, tt.key)
+		})
+	}
 }
@@ -0,0 +1,7 @@
+package config
+
+import "errors"
+
+var (
+	ErrInvalidValue = errors.New("invalid config value")
+)
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 )
@@ -36,14 +37,98 @@ func (j *JSONConfigProvider) setValues(c *Config) error {
 	}
 
 	var jsonConfig struct {
-		Host            string `json:"server_address"`
-		ShortURLHost    string `json:"base_url"`
-		FileStoragePath string `json:"file_storage_path"`
-		DatabaseDSN     string `json:"database_dsn"`
-		AuthSecret      string `json:"auth_secret"`
-		AuditFile       string `json:"audit_file"`
-		AuditURL        string `json:"audit_url"`
-		EnabledHTTPS    bool   `json:"enable_https"`
+		Host                string `json:"server_address"`
+		ShortURLHost        string `json:"base_url"`
+		FileStoragePath     string `json:"file_storage_path"`
+		DatabaseDSN         string `json:"database_dsn"`
+		AuthSecret          string `json:"auth_secret"`
+		AuditFile           string `json:"audit_file"`
+		AuditURL            string `json:"audit_url"`
+		EnabledHTTPS        bool   `json:"enable_https"`
+		RedirectQueryPolicy string `json:"redirect_query_policy"`
+
+		AllowedURLSchemes    string `json:"allowed_url_schemes"`
+		AllowPrivateNetworks *bool  `json:"allow_private_networks"`
+		ResolveURLHosts      *bool  `json:"resolve_url_hosts"`
+		BlocklistFile        string `json:"blocklist_file"`
+
+		NormalizeURLs          *bool `json:"normalize_urls"`
+		NormalizeSortQuery     *bool `json:"normalize_sort_query"`
+		NormalizeStripTracking *bool `json:"normalize_strip_tracking"`
+		PreserveOriginalURL    *bool `json:"preserve_original_url"`
+
+		UserStoragePath  string `json:"user_storage_path"`
+		TokenStoragePath string `json:"token_storage_path"`
+
+		AuthKeyID            string `json:"auth_key_id"`
+		AuthPrivateKeyFile   string `json:"auth_private_key_file"`
+		AuthPreviousKeyFiles string `json:"auth_previous_key_files"`
+		AuthPreviousSecrets  string `json:"auth_previous_secrets"`
+		AuthKeyGracePeriod   string `json:"auth_key_grace_period"`
+
+		AuthStrict         *bool `json:"auth_strict"`
+		AuthIssueAnonymous *bool `json:"auth_issue_anonymous"`
+
+		AdminUserIDs   string `json:"admin_user_ids"`
+		BanStoragePath string `json:"ban_storage_path"`
+
+		RateLimitCreate     string `json:"rate_limit_create"`
+		RateLimitRedirect   string `json:"rate_limit_redirect"`
+		RateLimitStore      string `json:"rate_limit_store"`
+		RateLimitTrustProxy *bool  `json:"rate_limit_trust_proxy"`
+
+		LinkQuota              *int   `json:"link_quota"`
+		LinkQuotaExemptUserIDs string `json:"link_quota_exempt_user_ids"`
+
+		AuditQueueSize     int    `json:"audit_queue_size"`
+		AuditBatchSize     int    `json:"audit_batch_size"`
+		AuditFlushInterval string `json:"audit_flush_interval"`
+		AuditMaxRetries    *int   `json:"audit_max_retries"`
+		AuditRetryBackoff  string `json:"audit_retry_backoff"`
+		AuditSpoolDir      string `json:"audit_spool_dir"`
+		AuditHTTPTimeout   string `json:"audit_http_timeout"`
+
+		AuditDeliveryTimeout string `json:"audit_delivery_timeout"`
+		AuditShutdownTimeout string `json:"audit_shutdown_timeout"`
+
+		AuditChain   *bool  `json:"audit_chain"`
+		AuditHMACKey string `json:"audit_hmac_key"`
+
+		AuditMaxSizeMB      *int   `json:"audit_max_size_mb"`
+		AuditRotateInterval string `json:"audit_rotate_interval"`
+		AuditCompress       *bool  `json:"audit_compress"`
+		AuditMaxBackups     *int   `json:"audit_max_backups"`
+		AuditMaxAge         string `json:"audit_max_age"`
+
+		AuditSinks []auditSinkJSON `json:"audit_sinks"`
+
+		AuditStore          *bool  `json:"audit_store"`
+		AuditStorePath      string `json:"audit_store_path"`
+		AuditStoreActions   string `json:"audit_store_actions"`
+		AuditStoreMaxEvents *int   `json:"audit_store_max_events"`
+		AuditStoreMaxAge    string `json:"audit_store_max_age"`
+
+		TracingExporter    string   `json:"tracing_exporter"`
+		TracingEndpoint    string   `json:"tracing_endpoint"`
+		TracingFile        string   `json:"tracing_file"`
+		TracingSampleRatio *float64 `json:"tracing_sample_ratio"`
+
+		LogLevel              string `json:"log_level"`
+		LogFormat             string `json:"log_format"`
+		LogSamplingInitial    *int   `json:"log_sampling_initial"`
+		LogSamplingThereafter *int   `json:"log_sampling_thereafter"`
+		LogFile               string `json:"log_file"`
+		LogMaxSizeMB          *int   `json:"log_max_size_mb"`
+		LogMaxBackups         *int   `json:"log_max_backups"`
+		LogMaxAge             string `json:"log_max_age"`
+
+		ShutdownDelay string `json:"shutdown_delay"`
+
+		StorageMode            string `json:"storage_mode"`
+		StorageStrict          *bool  `json:"storage_strict"`
+		StorageFallback        *bool  `json:"storage_fallback"`
+		DatabaseConnectRetries *int   `json:"database_connect_retries"`
+		DatabaseConnectBackoff string `json:"database_connect_backoff"`
 	}
 
 	if err := json.Unmarshal(data, &jsonConfig); err != nil {
@@ -82,5 +167,324 @@ func (j *JSONConfigProvider) setValues(c *Config) error {
 		c.EnabledHTTPS = jsonConfig.EnabledHTTPS
 	}
 
+	if strings.TrimSpace(jsonConfig.RedirectQueryPolicy) != "" {
+		c.RedirectQueryPolicy = jsonConfig.RedirectQueryPolicy
+	}
+
+	if strings.TrimSpace(jsonConfig.AllowedURLSchemes) != "" {
+		c.AllowedURLSchemes = jsonConfig.AllowedURLSchemes
+	}
+
+	if jsonConfig.AllowPrivateNetworks != nil {
+		c.AllowPrivateNetworks = *jsonConfig.AllowPrivateNetworks
+	}
+
+	if jsonConfig.ResolveURLHosts != nil {
+		c.ResolveURLHosts = *jsonConfig.ResolveURLHosts
+	}
+
+	if strings.TrimSpace(jsonConfig.BlocklistFile) != "" {
+		c.BlocklistFile = jsonConfig.BlocklistFile
+	}
+
+	if jsonConfig.NormalizeURLs != nil {
+		c.NormalizeURLs = *jsonConfig.NormalizeURLs
+	}
+
+	if jsonConfig.NormalizeSortQuery != nil {
+		c.NormalizeSortQuery = *jsonConfig.NormalizeSortQuery
+	}
+
+	if jsonConfig.NormalizeStripTracking != nil {
+		c.NormalizeStripTracking = *jsonConfig.NormalizeStripTracking
+	}
+
+	if jsonConfig.PreserveOriginalURL != nil {
+		c.PreserveOriginalURL = *jsonConfig.PreserveOriginalURL
+	}
+
+	if strings.TrimSpace(jsonConfig.UserStoragePath) != "" {
+		c.UserStoragePath = jsonConfig.UserStoragePath
+	}
+
+	if strings.TrimSpace(jsonConfig.TokenStoragePath) != "" {
+		c.TokenStoragePath = jsonConfig.TokenStoragePath
+	}
+
+	if strings.TrimSpace(jsonConfig.AuthKeyID) != "" {
+		c.AuthKeyID = jsonConfig.AuthKeyID
+	}
+
+	if strings.TrimSpace(jsonConfig.AuthPrivateKeyFile) != "" {
+		c.AuthPrivateKeyFile = jsonConfig.AuthPrivateKeyFile
+	}
+
+	if strings.TrimSpace(jsonConfig.AuthPreviousKeyFiles) != "" {
+		c.AuthPreviousKeyFiles = jsonConfig.AuthPreviousKeyFiles
+	}
+
+	if strings.TrimSpace(jsonConfig.AuthPreviousSecrets) != "" {
+		c.AuthPreviousSecrets = jsonConfig.AuthPreviousSecrets
+	}
+
+	if strings.TrimSpace(jsonConfig.AuthKeyGracePeriod) != "" {
+		value, err := parseDuration("auth_key_grace_period", jsonConfig.AuthKeyGracePeriod)
+		if err != nil {
+			return err
+		}
+		c.AuthKeyGracePeriod = value
+	}
+
+	if jsonConfig.AuthStrict != nil {
+		c.AuthStrict = *jsonConfig.AuthStrict
+	}
+
+	if jsonConfig.AuthIssueAnonymous != nil {
+		c.AuthIssueAnonymous = *jsonConfig.AuthIssueAnonymous
+	}
+
+	if strings.TrimSpace(jsonConfig.AdminUserIDs) != "" {
+		c.AdminUserIDs = jsonConfig.AdminUserIDs
+	}
+
+	if strings.TrimSpace(jsonConfig.BanStoragePath) != "" {
+		c.BanStoragePath = jsonConfig.BanStoragePath
+	}
+
+	if strings.TrimSpace(jsonConfig.RateLimitCreate) != "" {
+		c.RateLimitCreate = jsonConfig.RateLimitCreate
+	}
+
+	if strings.TrimSpace(jsonConfig.RateLimitRedirect) != "" {
+		c.RateLimitRedirect = jsonConfig.RateLimitRedirect
+	}
+
+	if strings.TrimSpace(jsonConfig.RateLimitStore) != "" {
+		c.RateLimitStore = jsonConfig.RateLimitStore
+	}
+
+	if jsonConfig.RateLimitTrustProxy != nil {
+		c.RateLimitTrustProxy = *jsonConfig.RateLimitTrustProxy
+	}
+
+	if jsonConfig.LinkQuota != nil && *jsonConfig.LinkQuota >= 0 {
+		c.LinkQuota = *jsonConfig.LinkQuota
+	}
+
+	if strings.TrimSpace(jsonConfig.LinkQuotaExemptUserIDs) != "" {
+		c.LinkQuotaExemptUserIDs = jsonConfig.LinkQuotaExemptUserIDs
+	}
+
+	if jsonConfig.AuditQueueSize > 0 {
+		c.AuditQueueSize = jsonConfig.AuditQueueSize
+	}
+
+	if jsonConfig.AuditBatchSize > 0 {
+		c.AuditBatchSize = jsonConfig.AuditBatchSize
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditFlushInterval) != "" {
+		value, err := parsePositiveDuration("audit_flush_interval", jsonConfig.AuditFlushInterval)
+		if err != nil {
+			return err
+		}
+		c.AuditFlushInterval = value
+	}
+
+	if jsonConfig.AuditMaxRetries != nil && *jsonConfig.AuditMaxRetries >= 0 {
+		c.AuditMaxRetries = *jsonConfig.AuditMaxRetries
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditRetryBackoff) != "" {
+		value, err := parseDuration("audit_retry_backoff", jsonConfig.AuditRetryBackoff)
+		if err != nil {
+			return err
+		}
+		c.AuditRetryBackoff = value
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditSpoolDir) != "" {
+		c.AuditSpoolDir = jsonConfig.AuditSpoolDir
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditHTTPTimeout) != "" {
+		value, err := parsePositiveDuration("audit_http_timeout", jsonConfig.AuditHTTPTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditHTTPTimeout = value
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditDeliveryTimeout) != "" {
+		value, err := parsePositiveDuration("audit_delivery_timeout", jsonConfig.AuditDeliveryTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditDeliveryTimeout = value
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditShutdownTimeout) != "" {
+		value, err := parsePositiveDuration("audit_shutdown_timeout", jsonConfig.AuditShutdownTimeout)
+		if err != nil {
+			return err
+		}
+		c.AuditShutdownTimeout = value
+	}
+
+	if jsonConfig.AuditChain != nil {
+		c.AuditChain = *jsonConfig.AuditChain
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditHMACKey) != "" {
+		c.AuditHMACKey = jsonConfig.AuditHMACKey
+	}
+
+	if jsonConfig.AuditMaxSizeMB != nil && *jsonConfig.AuditMaxSizeMB >= 0 {
+		c.AuditMaxSizeMB = *jsonConfig.AuditMaxSizeMB
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditRotateInterval) != "" {
+		value, err := parseDuration("audit_rotate_interval", jsonConfig.AuditRotateInterval)
+		if err != nil {
+			return err
+		}
+		c.AuditRotateInterval = value
+	}
+
+	if jsonConfig.AuditCompress != nil {
+		c.AuditCompress = *jsonConfig.AuditCompress
+	}
+
+	if jsonConfig.AuditMaxBackups != nil && *jsonConfig.AuditMaxBackups >= 0 {
+		c.AuditMaxBackups = *jsonConfig.AuditMaxBackups
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditMaxAge) != "" {
+		value, err := parseDuration("audit_max_age", jsonConfig.AuditMaxAge)
+		if err != nil {
+			return err
+		}
+		c.AuditMaxAge = value
+	}
+
+	if jsonConfig.AuditSinks != nil {
+		sinks, err := auditSinks(jsonConfig.AuditSinks)
+		if err != nil {
+			return err
+		}
+		c.AuditSinks = sinks
+	}
+
+	if jsonConfig.AuditStore != nil {
+		c.AuditStore = *jsonConfig.AuditStore
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditStorePath) != "" {
+		c.AuditStorePath = jsonConfig.AuditStorePath
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditStoreActions) != "" {
+		c.AuditStoreActions = jsonConfig.AuditStoreActions
+	}
+
+	if jsonConfig.AuditStoreMaxEvents != nil && *jsonConfig.AuditStoreMaxEvents >= 0 {
+		c.AuditStoreMaxEvents = *jsonConfig.AuditStoreMaxEvents
+	}
+
+	if strings.TrimSpace(jsonConfig.AuditStoreMaxAge) != "" {
+		value, err := parseDuration("audit_store_max_age", jsonConfig.AuditStoreMaxAge)
+		if err != nil {
+			return err
+		}
+		c.AuditStoreMaxAge = value
+	}
+
+	if strings.TrimSpace(jsonConfig.TracingExporter) != "" {
+		c.TracingExporter = strings.ToLower(strings.TrimSpace(jsonConfig.TracingExporter))
+	}
+
+	if strings.TrimSpace(jsonConfig.TracingEndpoint) != "" {
+		c.TracingEndpoint = strings.TrimSpace(jsonConfig.TracingEndpoint)
+	}
+
+	if strings.TrimSpace(jsonConfig.TracingFile) != "" {
+		c.TracingFile = jsonConfig.TracingFile
+	}
+
+	if value := jsonConfig.TracingSampleRatio; value != nil {
+		if *value < 0 || *value > 1 {
+			return fmt.Errorf("%w: tracing_sample_ratio %v, expected a number from 0 to 1", ErrInvalidValue, *value)
+		}
+		c.TracingSampleRatio = *value
+	}
+
+	if strings.TrimSpace(jsonConfig.LogLevel) != "" {
+		c.LogLevel = strings.ToLower(strings.TrimSpace(jsonConfig.LogLevel))
+	}
+
+	if strings.TrimSpace(jsonConfig.LogFormat) != "" {
+		c.LogFormat = strings.ToLower(strings.TrimSpace(jsonConfig.LogFormat))
+	}
+
+	if jsonConfig.LogSamplingInitial != nil && *jsonConfig.LogSamplingInitial >= 0 {
+		c.LogSamplingInitial = *jsonConfig.LogSamplingInitial
+	}
+
+	if jsonConfig.LogSamplingThereafter != nil && *jsonConfig.LogSamplingThereafter >= 0 {
+		c.LogSamplingThereafter = *jsonConfig.LogSamplingThereafter
+	}
+
+	if strings.TrimSpace(jsonConfig.LogFile) != "" {
+		c.LogFile = jsonConfig.LogFile
+	}
+
+	if jsonConfig.LogMaxSizeMB != nil && *jsonConfig.LogMaxSizeMB >= 0 {
+		c.LogMaxSizeMB = *jsonConfig.LogMaxSizeMB
+	}
+
+	if jsonConfig.LogMaxBackups != nil && *jsonConfig.LogMaxBackups >= 0 {
+		c.LogMaxBackups = *jsonConfig.LogMaxBackups
+	}
+
+	if strings.TrimSpace(jsonConfig.LogMaxAge) != "" {
+		value, err := parseDuration("log_max_age", jsonConfig.LogMaxAge)
+		if err != nil {
+			return err
+		}
+		c.LogMaxAge = value
+	}
+
+	if strings.TrimSpace(jsonConfig.ShutdownDelay) != "" {
+		value, err := parseDuration("shutdown_delay", jsonConfig.ShutdownDelay)
+		if err != nil {
+			return err
+		}
+		c.ShutdownDelay = value
+	}
+
+	if strings.TrimSpace(jsonConfig.StorageMode) != "" {
+		c.StorageMode = strings.ToLower(strings.TrimSpace(jsonConfig.StorageMode))
+	}
+
+	if jsonConfig.StorageStrict != nil {
+		c.StorageStrict = *jsonConfig.StorageStrict
+	}
+
+	if jsonConfig.StorageFallback != nil {
+		c.StorageFallback = *jsonConfig.StorageFallback
+	}
+
+	if jsonConfig.DatabaseConnectRetries != nil && *jsonConfig.DatabaseConnectRetries >= 0 {
+		c.DatabaseConnectRetries = *jsonConfig.DatabaseConnectRetries
+	}
+
+	if strings.TrimSpace(jsonConfig.DatabaseConnectBackoff) != "" {
+		value, err := parsePositiveDuration("database_connect_backoff", jsonConfig.DatabaseConnectBackoff)
+		if err != nil {
+			return err
+		}
+		c.DatabaseConnectBackoff = value
+	}
+
 	return nil
 }
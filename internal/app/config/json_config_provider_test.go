@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -24,16 +25,60 @@ func TestJSONConfigProvider(t *testing.T) {
 			"auth_secret": "test-secret",
 			"audit_file": "/tmp/audit.log",
 			"audit_url": "http://audit.example.com",
-			"enable_https": true
+			"enable_https": true,
+			"redirect_query_policy": "override",
+			"allowed_url_schemes": "https",
+			"allow_private_networks": true,
+			"blocklist_file": "/tmp/blocklist.txt",
+			"normalize_urls": false,
+			"normalize_strip_tracking": true,
+			"user_storage_path": "/tmp/users",
+			"token_storage_path": "/tmp/tokens",
+			"auth_private_key_file": "/tmp/jwt.pem",
+			"auth_key_grace_period": "1h",
+			"auth_issue_anonymous": false,
+			"admin_user_ids": "admin1",
+			"ban_storage_path": "/tmp/bans",
+			"rate_limit_create": "120/m",
+			"rate_limit_redirect": "100/s",
+			"rate_limit_store": "postgres",
+			"link_quota": 10,
+			"link_quota_exempt_user_ids": "admin1",
+			"audit_store": false,
+			"audit_store_path": "/tmp/audit_events",
+			"audit_store_max_events": 0,
+			"audit_store_max_age": "168h",
+			"tracing_exporter": "file",
+			"tracing_file": "/tmp/traces.jsonl",
+			"tracing_sample_ratio": 0,
+			"log_level": "warn",
+			"log_format": "Console",
+			"log_sampling_initial": 0,
+			"log_file": "/tmp/shortener.log",
+			"log_max_size_mb": 50,
+			"log_max_backups": 3,
+			"log_max_age": "48h",
+			"shutdown_delay": "15s",
+			"storage_mode": "file",
+			"storage_strict": true,
+			"storage_fallback": true,
+			"database_connect_retries": 0,
+			"database_connect_backoff": "2s",
+			"audit_sinks": [
+				{"name": "siem", "type": "http", "url": "https://siem.example.com", "hmac_key": "key", "headers": {"X-Tenant": "shortener"}, "timeout": "5s"},
+				{"type": "tcp", "address": "collector:5170", "actions": [" shorten", "delete", ""], "url_patterns": ["^https://"], "sample_rate": 0.25},
+				{"type": "syslog", "address": "siem:514", "sample_rate": 1}
+			]
 		}`
 
 		err := os.WriteFile(configFile, []byte(jsonConfig), 0644)
 		require.NoError(t, err)
 
-		config := NewConfig(
+		config, err := LoadConfig(
 			&DefaultProvider{},
 			NewJSONConfigProvider(configFile),
 		)
+		require.NoError(t, err)
 
 		assert.Equal(t, "localhost:9090", config.Host)
 		assert.Equal(t, "http://localhost:9090", config.ShortURLHost)
@@ -43,6 +88,70 @@ func TestJSONConfigProvider(t *testing.T) {
 		assert.Equal(t, "/tmp/audit.log", config.AuditFile)
 		assert.Equal(t, "http://audit.example.com", config.AuditURL)
 		assert.Equal(t, true, config.EnabledHTTPS)
+		assert.Equal(t, "override", config.RedirectQueryPolicy)
+		assert.Equal(t, "https", config.AllowedURLSchemes)
+		assert.Equal(t, true, config.AllowPrivateNetworks)
+		assert.Equal(t, "/tmp/blocklist.txt", config.BlocklistFile)
+		assert.Equal(t, false, config.NormalizeURLs)
+		assert.Equal(t, true, config.NormalizeStripTracking)
+		assert.Equal(t, true, config.PreserveOriginalURL)
+		assert.Equal(t, "/tmp/users", config.UserStoragePath)
+		assert.Equal(t, "/tmp/tokens", config.TokenStoragePath)
+		assert.Equal(t, "/tmp/jwt.pem", config.AuthPrivateKeyFile)
+		assert.Equal(t, time.Hour, config.AuthKeyGracePeriod)
+		assert.Equal(t, false, config.AuthIssueAnonymous)
+		assert.Equal(t, "admin1", config.AdminUserIDs)
+		assert.Equal(t, "/tmp/bans", config.BanStoragePath)
+		assert.Equal(t, "120/m", config.RateLimitCreate)
+		assert.Equal(t, "100/s", config.RateLimitRedirect)
+		assert.Equal(t, "postgres", config.RateLimitStore)
+		assert.Equal(t, 10, config.LinkQuota)
+		assert.Equal(t, "admin1", config.LinkQuotaExemptUserIDs)
+		assert.Equal(t, false, config.AuditStore)
+		assert.Equal(t, "/tmp/audit_events", config.AuditStorePath)
+		assert.Equal(t, 0, config.AuditStoreMaxEvents)
+		assert.Equal(t, 168*time.Hour, config.AuditStoreMaxAge)
+		assert.Equal(t, StorageModeFile, config.StorageMode)
+		assert.Equal(t, true, config.StorageStrict)
+		assert.Equal(t, true, config.StorageFallback)
+		assert.Equal(t, 0, config.DatabaseConnectRetries)
+		assert.Equal(t, 2*time.Second, config.DatabaseConnectBackoff)
+		assert.Equal(t, TracingExporterFile, config.TracingExporter)
+		assert.Equal(t, "/tmp/traces.jsonl", config.TracingFile)
+		assert.Equal(t, float64(0), config.TracingSampleRatio)
+		assert.Equal(t, 48*time.Hour, config.LogMaxAge)
+		assert.Equal(t, []AuditSink{
+			{Name: "siem", Type: AuditSinkHTTP, URL: "https://siem.example.com", HMACKey: "key", Headers: map[string]string{"X-Tenant": "shortener"}, Timeout: 5 * time.Second},
+			{Type: AuditSinkTCP, Address: "collector:5170", Actions: []string{"shorten", "delete"}, URLPatterns: []string{"^https://"}, SampleRate: 0.25},
+			{Type: AuditSinkSyslog, Address: "siem:514", SampleRate: 1},
+		}, config.AuditSinks)
+	})
+
+	// Некорректные значения не игнорируются
+	t.Run("invalid values", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			config string
+			field  string
+		}{
+			{name: "#1 bad sink timeout", config: `{"audit_sinks": [{"type": "http", "url": "https://siem.example.com", "timeout": "bad"}]}`, field: "timeout"},
+			{name: "#2 negative sink timeout", config: `{"audit_sinks": [{"type": "http", "url": "https://siem.example.com", "timeout": "-1s"}]}`, field: "timeout"},
+			{name: "#3 sink sample rate above 1", config: `{"audit_sinks": [{"type": "syslog", "address": "siem:514", "sample_rate": 2}]}`, field: "sample_rate"},
+			{name: "#4 negative sink sample rate", config: `{"audit_sinks": [{"type": "syslog", "address": "siem:514", "sample_rate": -0.5}]}`, field: "sample_rate"},
+			{name: "#5 bad duration", config: `{"log_max_age": "bad"}`, field: "log_max_age"},
+			{name: "#6 zero positive duration", config: `{"audit_flush_interval": "0s"}`, field: "audit_flush_interval"},
+			{name: "#7 tracing ratio above 1", config: `{"tracing_sample_ratio": 1.5}`, field: "tracing_sample_ratio"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				err := os.WriteFile(configFile, []byte(tt.config), 0644)
+				require.NoError(t, err)
+
+				_, err = LoadConfig(&DefaultProvider{}, NewJSONConfigProvider(configFile))
+				assert.ErrorIs(t, err, ErrInvalidValue)
+				assert.ErrorContains(t, err, tt.field)
+			})
+		}
 	})
 
 	// Тест 2: Чтение частичной конфигурации из JSON
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDuration разбирает неотрицательную длительность параметра field.
+// Возвращает ErrInvalidValue с именем параметра, если значение некорректно.
+func parseDuration(field, raw string) (time.Duration, error) {
+	value, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%w: %s %q, expected a non-negative duration", ErrInvalidValue, field, raw)
+	}
+
+	return value, nil
+}
+
+// parsePositiveDuration разбирает длительность параметра field, которая должна быть больше нуля.
+func parsePositiveDuration(field, raw string) (time.Duration, error) {
+	value, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: %s %q, expected a positive duration", ErrInvalidValue, field, raw)
+	}
+
+	return value, nil
+}
+
+// parseCount разбирает неотрицательное целое значение параметра field.
+func parseCount(field, raw string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%w: %s %q, expected a non-negative integer", ErrInvalidValue, field, raw)
+	}
+
+	return value, nil
+}
+
+// parsePositiveCount разбирает целое значение параметра field, которое должно быть больше нуля.
+func parsePositiveCount(field, raw string) (int, error) {
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: %s %q, expected a positive integer", ErrInvalidValue, field, raw)
+	}
+
+	return value, nil
+}
+
+// parseRatio разбирает долю от 0 до 1 параметра field.
+func parseRatio(field, raw string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || value < 0 || value > 1 {
+		return 0, fmt.Errorf("%w: %s %q, expected a number from 0 to 1", ErrInvalidValue, field, raw)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/admin"
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// adminLinkResponseItem представляет ссылку в ответе административного API.
+type adminLinkResponseItem struct {
+	ShortCode   string `json:"short_code"`   // Короткий код
+	OriginalURL string `json:"original_url"` // Оригинальный URL
+	UserID      string `json:"user_id"`      // Идентификатор владельца
+	IsDeleted   bool   `json:"is_deleted"`   // Ссылка удалена
+	IsDisabled  bool   `json:"is_disabled"`  // Ссылка заблокирована администратором
+}
+
+// banUserRequest представляет структуру запроса на блокировку пользователя.
+type banUserRequest struct {
+	Reason       string `json:"reason"`        // Причина блокировки
+	DisableLinks bool   `json:"disable_links"` // Заблокировать также все ссылки пользователя
+}
+
+// logLevelMessage представляет уровень журнала в запросе и ответе административного API.
+type logLevelMessage struct {
+	Level string `json:"level"` // Уровень журнала: debug, info, warn или error
+}
+
+// AdminSearchLinksHandler создает HTTP-обработчик для поиска ссылок всех пользователей.
+// Условия поиска передаются параметрами запроса: "url" (подстрока URL), "domain"
+// (домен вместе с поддоменами), "user_id", а также "limit" и "offset" для постраничного вывода.
+// Возможные коды ответа:
+//   - 200 OK - результаты поиска (возможно, пустой массив)
+//   - 400 Bad Request - неверные параметры постраничного вывода
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminSearchLinksHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := models.LinkFilter{
+			URL:    query.Get("url"),
+			Domain: query.Get("domain"),
+			UserID: query.Get("user_id"),
+		}
+
+		var err error
+		if value := query.Get("limit"); value != "" {
+			if filter.Limit, err = strconv.Atoi(value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("offset"); value != "" {
+			if filter.Offset, err = strconv.Atoi(value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		links, err := service.SearchLinks(r.Context(), adminID(r), filter)
+		if errors.Is(err, admin.ErrInvalidFilter) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp := make([]adminLinkResponseItem, 0, len(links))
+		for _, link := range links {
+			resp = append(resp, adminLinkResponseItem{
+				ShortCode:   link.ShortCode,
+				OriginalURL: link.OriginalURL,
+				UserID:      link.UserID,
+				IsDeleted:   link.IsDeleted,
+				IsDisabled:  link.IsDisabled,
+			})
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// AdminSetLinkDisabledHandler создает HTTP-обработчик для блокировки (disabled = true)
+// или разблокировки ссылки любого пользователя. Короткий код передается в пути запроса ({short_code}).
+// Возможные коды ответа:
+//   - 204 No Content - состояние ссылки изменено
+//   - 404 Not Found - ссылка не найдена
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminSetLinkDisabledHandler(service *admin.Service, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := service.SetLinkDisabled(r.Context(), adminID(r), r.PathValue("short_code"), disabled)
+		writeAdminResult(w, err)
+	}
+}
+
+// AdminDeleteLinkHandler создает HTTP-обработчик для удаления ссылки любого пользователя.
+// Короткий код передается в пути запроса ({short_code}).
+// Возможные коды ответа:
+//   - 204 No Content - ссылка удалена
+//   - 404 Not Found - ссылка не найдена
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminDeleteLinkHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := service.DeleteLink(r.Context(), adminID(r), r.PathValue("short_code"))
+		writeAdminResult(w, err)
+	}
+}
+
+// AdminBanUserHandler создает HTTP-обработчик для блокировки пользователя.
+// Идентификатор пользователя передается в пути запроса ({user_id}). Тело запроса
+// необязательно и может содержать поля "reason" и "disable_links".
+// Возможные коды ответа:
+//   - 204 No Content - пользователь заблокирован
+//   - 400 Bad Request - неверный формат запроса
+//   - 409 Conflict - пользователь является администратором
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminBanUserHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawBody, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req := new(banUserRequest)
+		if len(rawBody) > 0 {
+			if err = json.Unmarshal(rawBody, req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = service.BanUser(r.Context(), adminID(r), r.PathValue("user_id"), req.Reason, req.DisableLinks)
+		switch {
+		case errors.Is(err, admin.ErrInvalidUser):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, admin.ErrCannotBanAdmin):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			writeAdminResult(w, err)
+		}
+	}
+}
+
+// AdminUnbanUserHandler создает HTTP-обработчик для снятия блокировки пользователя.
+// Идентификатор пользователя передается в пути запроса ({user_id}).
+// Возможные коды ответа:
+//   - 204 No Content - блокировка снята
+//   - 404 Not Found - пользователь не заблокирован
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminUnbanUserHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := service.UnbanUser(r.Context(), adminID(r), r.PathValue("user_id"))
+		writeAdminResult(w, err)
+	}
+}
+
+// AdminStatsHandler создает HTTP-обработчик для получения общих показателей сервиса.
+// Возможные коды ответа:
+//   - 200 OK - показатели успешно получены
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminStatsHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := service.Stats(r.Context(), adminID(r))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, stats)
+	}
+}
+
+// AdminSearchAuditHandler создает HTTP-обработчик для поиска сохраненных событий аудита.
+// Условия поиска передаются параметрами запроса: "user_id", "action", "url" (подстрока URL),
+// "short_code", "from" и "to" (период в формате RFC 3339, "to" не включительно),
+// а также "limit" и "offset" для постраничного вывода. События возвращаются от новых к старым.
+// Возможные коды ответа:
+//   - 200 OK - результаты поиска (возможно, пустой массив)
+//   - 400 Bad Request - неверные параметры периода или постраничного вывода
+//   - 501 Not Implemented - хранилище событий аудита не настроено
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminSearchAuditHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := audit.Query{
+			UserID:    query.Get("user_id"),
+			Action:    query.Get("action"),
+			URL:       query.Get("url"),
+			ShortCode: query.Get("short_code"),
+		}
+
+		var err error
+		if value := query.Get("from"); value != "" {
+			if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("to"); value != "" {
+			if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("limit"); value != "" {
+			if filter.Limit, err = strconv.Atoi(value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		if value := query.Get("offset"); value != "" {
+			if filter.Offset, err = strconv.Atoi(value); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		events, err := service.SearchAudit(r.Context(), adminID(r), filter)
+		switch {
+		case errors.Is(err, admin.ErrInvalidFilter):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, admin.ErrAuditDisabled):
+			w.WriteHeader(http.StatusNotImplemented)
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusOK, events)
+		}
+	}
+}
+
+// AdminLogLevelHandler создает HTTP-обработчик для получения текущего уровня журнала.
+// Возможные коды ответа:
+//   - 200 OK - уровень журнала в поле "level"
+//   - 501 Not Implemented - управление уровнем журнала не подключено
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminLogLevelHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		level, err := service.LogLevel(r.Context(), adminID(r))
+		switch {
+		case errors.Is(err, admin.ErrLogLevelUnavailable):
+			w.WriteHeader(http.StatusNotImplemented)
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusOK, logLevelMessage{Level: level})
+		}
+	}
+}
+
+// AdminChangeLogLevelHandler создает HTTP-обработчик для изменения уровня журнала без перезапуска.
+// Новый уровень передается в поле "level" тела запроса.
+// Возможные коды ответа:
+//   - 200 OK - уровень изменен, в ответе новый уровень
+//   - 400 Bad Request - неверный формат запроса или неизвестный уровень
+//   - 501 Not Implemented - управление уровнем журнала не подключено
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func AdminChangeLogLevelHandler(service *admin.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := new(logLevelMessage)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err := service.ChangeLogLevel(r.Context(), adminID(r), req.Level)
+		switch {
+		case errors.Is(err, admin.ErrInvalidLogLevel):
+			w.WriteHeader(http.StatusBadRequest)
+		case errors.Is(err, admin.ErrLogLevelUnavailable):
+			w.WriteHeader(http.StatusNotImplemented)
+		case err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			level, _ := service.LogLevel(r.Context(), adminID(r))
+			writeJSON(w, http.StatusOK, logLevelMessage{Level: level})
+		}
+	}
+}
+
+// adminID возвращает идентификатор администратора, выполняющего запрос.
+func adminID(r *http.Request) string {
+	userID, _ := r.Context().Value(models.ContextUserID).(string)
+
+	return userID
+}
+
+// writeAdminResult отвечает кодом 204 при успехе, 404 для admin.ErrNotFound и 500 для остальных ошибок.
+func writeAdminResult(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, admin.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	encodedResp, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encodedResp)
+}
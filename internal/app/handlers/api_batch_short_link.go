@@ -28,6 +28,7 @@ type batchResponseItem struct {
 // Возможные коды ответа:
 //   - 201 Created - ссылки успешно созданы
 //   - 400 Bad Request - неверный формат запроса или отсутствие валидных ссылок
+//   - 403 Forbidden - пакет превышает квоту ссылок пользователя
 //   - 500 Internal Server Error - внутренняя ошибка сервера
 func BatchShortLinkHandler(shorter *shortener.Shortener) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -62,6 +63,11 @@ func BatchShortLinkHandler(shorter *shortener.Shortener) http.HandlerFunc {
 			return
 		}
 
+		if errors.Is(err, shortener.ErrQuotaExceeded) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -1,19 +1,27 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 
-	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
 	"github.com/sviatilnik/url-shortener/internal/app/shortener"
 )
 
 // request представляет структуру запроса для создания короткой ссылки.
 type request struct {
-	URL string `json:"url"` // Оригинальный URL для сокращения
+	URL          string               `json:"url"`                    // Оригинальный URL для сокращения
+	QueryPolicy  string               `json:"query_policy,omitempty"` // Политика параметров запроса при переходе: append, override или drop
+	UTM          map[string]string    `json:"utm,omitempty"`          // UTM-метки, добавляемые при каждом переходе
+	Destinations []destinationRequest `json:"destinations,omitempty"` // Варианты назначения для A/B-тестирования
+}
+
+// destinationRequest представляет вариант назначения в запросе на создание ссылки.
+type destinationRequest struct {
+	URL    string `json:"url"`    // URL варианта
+	Weight int    `json:"weight"` // Вес варианта
 }
 
 // response представляет структуру ответа с созданной короткой ссылкой.
@@ -23,10 +31,13 @@ type response struct {
 
 // APIShortLinkHandler создает HTTP-обработчик для API создания коротких ссылок.
 // Обработчик принимает JSON-запрос с полем "url" и возвращает JSON-ответ с полем "result".
+// Необязательные поля "query_policy" и "utm" задают политику параметров запроса и шаблон UTM-меток ссылки,
+// а "destinations" - взвешенные варианты назначения для A/B-тестирования.
 // Возможные коды ответа:
 //   - 201 Created - ссылка успешно создана
 //   - 409 Conflict - ссылка уже существует
 //   - 400 Bad Request - неверный формат запроса или URL
+//   - 403 Forbidden - пользователь исчерпал квоту ссылок
 //   - 500 Internal Server Error - внутренняя ошибка сервера
 func APIShortLinkHandler(short *shortener.Shortener) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -42,20 +53,34 @@ func APIShortLinkHandler(short *shortener.Shortener) http.HandlerFunc {
 			return
 		}
 
-		// Устанавливаем URL в контекст для аудита
-		ctx := context.WithValue(r.Context(), middlewares.AuditURLKey, req.URL)
-		*r = *r.WithContext(ctx)
-
 		status := http.StatusCreated
-		shortLink, err := short.GenerateShortLink(r.Context(), req.URL)
+		destinations := make([]models.Destination, 0, len(req.Destinations))
+		for _, item := range req.Destinations {
+			destinations = append(destinations, models.Destination{
+				URL:    item.URL,
+				Weight: item.Weight,
+			})
+		}
+
+		shortLink, err := short.CreateLink(r.Context(), models.Link{
+			OriginalURL:  req.URL,
+			QueryPolicy:  models.QueryPolicy(req.QueryPolicy),
+			UTM:          req.UTM,
+			Destinations: destinations,
+		})
 		if err != nil {
 			if errors.Is(err, shortener.ErrLinkConflict) {
 				status = http.StatusConflict
 			} else {
 				status = http.StatusInternalServerError
-				if errors.Is(err, shortener.ErrInvalidURL) {
+				if errors.Is(err, shortener.ErrInvalidURL) ||
+					errors.Is(err, shortener.ErrInvalidQueryPolicy) ||
+					errors.Is(err, shortener.ErrInvalidDestination) {
 					status = http.StatusBadRequest
 				}
+				if errors.Is(err, shortener.ErrQuotaExceeded) {
+					status = http.StatusForbidden
+				}
 				w.WriteHeader(status)
 				return
 			}
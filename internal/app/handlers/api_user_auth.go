@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/users"
+)
+
+// TokenIssuer выдает и удаляет токен аутентификации пользователя.
+type TokenIssuer interface {
+	// IssueToken выдает JWT для указанного пользователя.
+	IssueToken(w http.ResponseWriter, userID string) error
+	// ClearToken удаляет токен из cookie.
+	ClearToken(w http.ResponseWriter)
+}
+
+// credentialsRequest представляет структуру запроса на регистрацию или вход.
+type credentialsRequest struct {
+	Email    string `json:"email"`    // Адрес электронной почты
+	Password string `json:"password"` // Пароль
+}
+
+// userResponse представляет структуру ответа с данными учетной записи.
+type userResponse struct {
+	ID    string `json:"id"`    // Идентификатор пользователя
+	Email string `json:"email"` // Адрес электронной почты
+}
+
+// RegisterHandler создает HTTP-обработчик для регистрации пользователя.
+// Обработчик принимает JSON-запрос с полями "email" и "password", выдает JWT
+// так же, как для анонимных пользователей, и привязывает к учетной записи ссылки,
+// созданные под текущим анонимным идентификатором.
+// Возможные коды ответа:
+//   - 201 Created - пользователь зарегистрирован
+//   - 400 Bad Request - неверный формат запроса, email или пароля
+//   - 409 Conflict - пользователь с таким email уже существует
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func RegisterHandler(service *users.Service, issuer TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := readCredentials(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user, err := service.Register(r.Context(), req.Email, req.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, users.ErrInvalidEmail), errors.Is(err, users.ErrInvalidPassword):
+				w.WriteHeader(http.StatusBadRequest)
+			case errors.Is(err, users.ErrUserAlreadyExists):
+				w.WriteHeader(http.StatusConflict)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		signIn(w, r, service, issuer, user, http.StatusCreated)
+	}
+}
+
+// LoginHandler создает HTTP-обработчик для входа пользователя.
+// Обработчик принимает JSON-запрос с полями "email" и "password", выдает JWT
+// с идентификатором учетной записи и привязывает к ней ссылки, созданные
+// под текущим анонимным идентификатором.
+// Возможные коды ответа:
+//   - 200 OK - вход выполнен
+//   - 400 Bad Request - неверный формат запроса
+//   - 401 Unauthorized - неверный email или пароль
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func LoginHandler(service *users.Service, issuer TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := readCredentials(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		user, err := service.Login(r.Context(), req.Email, req.Password)
+		if err != nil {
+			if errors.Is(err, users.ErrInvalidCredentials) {
+				w.WriteHeader(http.StatusUnauthorized)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		signIn(w, r, service, issuer, user, http.StatusOK)
+	}
+}
+
+// LogoutHandler создает HTTP-обработчик для выхода пользователя.
+// Обработчик удаляет cookie с токеном; следующий запрос получит новый анонимный идентификатор.
+// Возможные коды ответа:
+//   - 204 No Content - выход выполнен
+func LogoutHandler(issuer TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		issuer.ClearToken(w)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func readCredentials(r *http.Request) (*credentialsRequest, bool) {
+	rawBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, false
+	}
+
+	req := new(credentialsRequest)
+	if err = json.Unmarshal(rawBody, req); err != nil {
+		return nil, false
+	}
+
+	return req, true
+}
+
+func signIn(w http.ResponseWriter, r *http.Request, service *users.Service, issuer TokenIssuer, user *models.User, status int) {
+	anonymousID, _ := r.Context().Value(models.ContextUserID).(string)
+	if err := service.ClaimAnonymousLinks(r.Context(), anonymousID, user); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := issuer.IssueToken(w, user.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	encodedResp, err := json.Marshal(userResponse{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encodedResp)
+}
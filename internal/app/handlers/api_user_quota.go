@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/shortener"
+)
+
+// quotaResponse представляет использование квоты ссылок пользователем.
+type quotaResponse struct {
+	Limit     *int `json:"limit"`     // Максимальное количество активных ссылок (null - без ограничения)
+	Used      int  `json:"used"`      // Количество активных ссылок
+	Remaining *int `json:"remaining"` // Сколько ссылок еще можно создать (null - без ограничения)
+}
+
+// UserQuotaHandler создает HTTP-обработчик для получения использования квоты ссылок.
+// Обработчик возвращает JSON-объект с полями "limit", "used" и "remaining".
+// Возможные коды ответа:
+//   - 200 OK - квота успешно получена
+//   - 401 Unauthorized - пользователь не авторизован
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func UserQuotaHandler(shorter *shortener.Shortener) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(models.ContextUserID).(string)
+		if strings.TrimSpace(userID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		quota, err := shorter.GetQuota(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp := quotaResponse{Used: quota.Used}
+		if !quota.Unlimited() {
+			limit, remaining := quota.Limit, quota.Remaining()
+			resp.Limit = &limit
+			resp.Remaining = &remaining
+		}
+
+		encodedResp, err := json.Marshal(resp)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(encodedResp)
+	}
+}
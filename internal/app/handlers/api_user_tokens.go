@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tokens"
+)
+
+// createTokenRequest представляет структуру запроса на создание API-токена.
+type createTokenRequest struct {
+	Name   string   `json:"name"`   // Название токена
+	Scopes []string `json:"scopes"` // Разрешения токена: links:read, links:write, admin
+}
+
+// tokenResponseItem представляет API-токен в ответе.
+type tokenResponseItem struct {
+	ID         string     `json:"id"`                     // Идентификатор токена
+	Name       string     `json:"name"`                   // Название токена
+	Scopes     []string   `json:"scopes"`                 // Разрешения токена
+	CreatedAt  time.Time  `json:"created_at"`             // Время создания
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // Время последнего использования
+	Token      string     `json:"token,omitempty"`        // Значение токена (только при создании)
+}
+
+func newTokenResponseItem(token *models.APIToken) tokenResponseItem {
+	item := tokenResponseItem{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		CreatedAt: token.CreatedAt,
+	}
+
+	if !token.LastUsedAt.IsZero() {
+		lastUsedAt := token.LastUsedAt
+		item.LastUsedAt = &lastUsedAt
+	}
+
+	return item
+}
+
+// CreateTokenHandler создает HTTP-обработчик для выпуска персонального API-токена.
+// Обработчик принимает JSON-запрос с полями "name" и "scopes" и возвращает описание токена
+// вместе с его значением в поле "token". Значение показывается только один раз.
+// Разрешение "admin" может получить только администратор (см. admins).
+// Возможные коды ответа:
+//   - 201 Created - токен создан
+//   - 400 Bad Request - неверный формат запроса, название или разрешения
+//   - 401 Unauthorized - пользователь не авторизован
+//   - 403 Forbidden - разрешение "admin" запрошено не администратором
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func CreateTokenHandler(service *tokens.Service, admins middlewares.AdminChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(models.ContextUserID).(string)
+		if strings.TrimSpace(userID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		rawBody, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req := new(createTokenRequest)
+		if err = json.Unmarshal(rawBody, req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if slices.Contains(req.Scopes, models.ScopeAdmin) && (admins == nil || !admins.IsAdmin(r.Context())) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		plain, token, err := service.Create(r.Context(), userID, req.Name, req.Scopes)
+		if err != nil {
+			if errors.Is(err, tokens.ErrInvalidName) || errors.Is(err, tokens.ErrInvalidScope) {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		resp := newTokenResponseItem(token)
+		resp.Token = plain
+
+		encodedResp, err := json.Marshal(resp)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(encodedResp)
+	}
+}
+
+// UserTokensHandler создает HTTP-обработчик для получения списка API-токенов пользователя.
+// Значения токенов не возвращаются.
+// Возможные коды ответа:
+//   - 200 OK - список токенов успешно получен
+//   - 204 No Content - у пользователя нет токенов
+//   - 401 Unauthorized - пользователь не авторизован
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func UserTokensHandler(service *tokens.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(models.ContextUserID).(string)
+		if strings.TrimSpace(userID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		userTokens, err := service.List(r.Context(), userID)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if len(userTokens) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		resp := make([]tokenResponseItem, 0, len(userTokens))
+		for _, token := range userTokens {
+			resp = append(resp, newTokenResponseItem(token))
+		}
+
+		encodedResp, err := json.Marshal(resp)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(encodedResp)
+	}
+}
+
+// RevokeTokenHandler создает HTTP-обработчик для отзыва API-токена.
+// Идентификатор токена передается в пути запроса ({token_id}).
+// Возможные коды ответа:
+//   - 204 No Content - токен отозван
+//   - 401 Unauthorized - пользователь не авторизован
+//   - 404 Not Found - у пользователя нет такого токена
+//   - 500 Internal Server Error - внутренняя ошибка сервера
+func RevokeTokenHandler(service *tokens.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(models.ContextUserID).(string)
+		if strings.TrimSpace(userID) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		err := service.Revoke(r.Context(), userID, r.PathValue("token_id"))
+		if errors.Is(err, tokens.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -12,12 +12,21 @@ import (
 
 // userURLsResponseItem представляет элемент ответа со списком URL пользователя.
 type userURLsResponseItem struct {
-	ShortURL    string `json:"short_url"`    // Сокращенная ссылка
-	OriginalURL string `json:"original_url"` // Оригинальный URL
+	ShortURL    string                `json:"short_url"`          // Сокращенная ссылка
+	OriginalURL string                `json:"original_url"`       // Оригинальный URL
+	Variants    []variantResponseItem `json:"variants,omitempty"` // Варианты назначения со статистикой переходов
+}
+
+// variantResponseItem представляет вариант назначения ссылки со счетчиком переходов.
+type variantResponseItem struct {
+	URL    string `json:"url"`    // URL варианта
+	Weight int    `json:"weight"` // Вес варианта
+	Clicks int64  `json:"clicks"` // Количество переходов на вариант
 }
 
 // UserURLsHandler создает HTTP-обработчик для получения списка URL пользователя.
 // Обработчик возвращает массив JSON-объектов с полями "short_url" и "original_url".
+// Для ссылок с вариантами назначения дополнительно возвращается поле "variants" со счетчиками переходов.
 // Возможные коды ответа:
 //   - 200 OK - список URL успешно получен
 //   - 204 No Content - у пользователя нет сохраненных URL
@@ -28,6 +37,7 @@ func UserURLsHandler(shorter *shortener.Shortener) http.HandlerFunc {
 		tmpUserID := r.Context().Value(models.ContextUserID)
 		if tmpUserID == nil {
 			w.WriteHeader(http.StatusUnauthorized)
+			return
 		}
 
 		userID := tmpUserID.(string)
@@ -48,10 +58,20 @@ func UserURLsHandler(shorter *shortener.Shortener) http.HandlerFunc {
 
 		resp := make([]userURLsResponseItem, 0)
 		for _, item := range userLinks {
-			resp = append(resp, userURLsResponseItem{
+			respItem := userURLsResponseItem{
 				ShortURL:    item.ShortURL,
-				OriginalURL: item.OriginalURL,
-			})
+				OriginalURL: item.SubmittedURL(),
+			}
+
+			for _, destination := range item.Destinations {
+				respItem.Variants = append(respItem.Variants, variantResponseItem{
+					URL:    destination.URL,
+					Weight: destination.Weight,
+					Clicks: destination.Clicks,
+				})
+			}
+
+			resp = append(resp, respItem)
 		}
 
 		encodedResp, err := json.Marshal(resp)
@@ -78,6 +98,7 @@ func DeleteUserURLsHandler(shorter *shortener.Shortener) http.HandlerFunc {
 		tmpUserID := r.Context().Value(models.ContextUserID)
 		if tmpUserID == nil {
 			w.WriteHeader(http.StatusUnauthorized)
+			return
 		}
 
 		userID := tmpUserID.(string)
@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"io"
 	"net/http"
 	"strings"
 
-	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
 	"github.com/sviatilnik/url-shortener/internal/app/shortener"
 )
 
@@ -17,6 +15,7 @@ import (
 //   - 201 Created - ссылка успешно создана
 //   - 409 Conflict - ссылка уже существует
 //   - 400 Bad Request - неверный формат URL
+//   - 403 Forbidden - пользователь исчерпал квоту ссылок
 func GetShortLinkHandler(shorter *shortener.Shortener) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		url, err := io.ReadAll(r.Body)
@@ -27,15 +26,14 @@ func GetShortLinkHandler(shorter *shortener.Shortener) http.HandlerFunc {
 
 		urlStr := strings.TrimSuffix(string(url), "\n")
 
-		// Устанавливаем URL в контекст для аудита
-		ctx := context.WithValue(r.Context(), middlewares.AuditURLKey, urlStr)
-		*r = *r.WithContext(ctx)
-
 		status := http.StatusCreated
 		shortLink, err := shorter.GenerateShortLink(r.Context(), urlStr)
 		if err != nil {
 			if errors.Is(err, shortener.ErrLinkConflict) {
 				status = http.StatusConflict
+			} else if errors.Is(err, shortener.ErrQuotaExceeded) {
+				w.WriteHeader(http.StatusForbidden)
+				return
 			} else {
 				w.WriteHeader(http.StatusBadRequest)
 				return
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/sviatilnik/url-shortener/internal/app/health"
+)
+
+// LivenessHandler создает HTTP-обработчик проверки работоспособности процесса (/healthz).
+// Возможные коды ответа:
+//   - 200 OK - процесс работает
+func LivenessHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, checker.Liveness(r.Context()))
+	}
+}
+
+// ReadinessHandler создает HTTP-обработчик проверки готовности сервиса (/readyz).
+// Ответ содержит результат проверки каждого компонента.
+// Возможные коды ответа:
+//   - 200 OK - все обязательные компоненты работоспособны (отказ получателей аудита дает состояние degraded)
+//   - 503 Service Unavailable - хотя бы один обязательный компонент неработоспособен или сервис останавливается
+func ReadinessHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, checker.Readiness(r.Context()))
+	}
+}
+
+func writeHealthReport(w http.ResponseWriter, report *health.Report) {
+	// Результат проверки не должен кешироваться промежуточными прокси
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, report)
+}
@@ -1,19 +1,30 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/sviatilnik/url-shortener/internal/app/middlewares"
 	"github.com/sviatilnik/url-shortener/internal/app/shortener"
 )
 
+// variantCookieTTL определяет, как долго посетитель закреплен за вариантом назначения.
+const variantCookieTTL = 30 * 24 * time.Hour
+
+func variantCookieName(shortCode string) string {
+	return "variant_" + shortCode
+}
+
 // RedirectToFullLinkHandler создает HTTP-обработчик для перенаправления по короткой ссылке.
 // Обработчик извлекает короткий код из URL-пути и выполняет перенаправление на оригинальный URL.
+// Параметры запроса объединяются с параметрами оригинального URL согласно политике ссылки.
+// Для ссылок с несколькими вариантами назначения посетителю назначается вариант,
+// который запоминается в cookie, чтобы повторные переходы вели на тот же адрес.
 // Возможные коды ответа:
 //   - 307 Temporary Redirect - успешное перенаправление
 //   - 400 Bad Request - короткий код не найден
-//   - 410 Gone - ссылка была удалена
+//   - 410 Gone - ссылка была удалена или заблокирована администратором
+//   - 500 Internal Server Error - не удалось сформировать адрес перенаправления
 func RedirectToFullLinkHandler(shortener *shortener.Shortener) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -25,15 +36,37 @@ func RedirectToFullLinkHandler(shortener *shortener.Shortener) http.HandlerFunc
 			return
 		}
 
-		if link.IsDeleted {
+		if link.IsDeleted || link.IsDisabled {
 			w.WriteHeader(http.StatusGone)
 			return
 		}
 
-		// Устанавливаем URL в контекст для аудита
-		ctx := context.WithValue(r.Context(), middlewares.AuditURLKey, link.OriginalURL)
-		*r = *r.WithContext(ctx)
+		sticky := ""
+		if cookie, err := r.Cookie(variantCookieName(link.ShortCode)); err == nil {
+			sticky = cookie.Value
+		}
+
+		variant := shortener.SelectVariant(link, sticky)
+
+		target, err := shortener.BuildRedirectURL(link, variant, r.URL.Query())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if variant >= 0 && strconv.Itoa(variant) != sticky {
+			http.SetCookie(w, &http.Cookie{
+				Name:     variantCookieName(link.ShortCode),
+				Value:    strconv.Itoa(variant),
+				Path:     "/",
+				HttpOnly: true,
+				Expires:  time.Now().Add(variantCookieTTL),
+			})
+		}
+
+		// Ошибка учета перехода не должна мешать перенаправлению
+		_ = shortener.RegisterFollow(r.Context(), link, variant)
 
-		http.Redirect(w, r, link.OriginalURL, http.StatusTemporaryRedirect)
+		http.Redirect(w, r, target, http.StatusTemporaryRedirect)
 	}
 }
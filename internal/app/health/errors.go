@@ -0,0 +1,7 @@
+package health
+
+import "errors"
+
+var (
+	ErrShuttingDown = errors.New("service is shutting down")
+)
@@ -0,0 +1,178 @@
+// Package health проверяет состояние компонентов сервиса для проверок
+// работоспособности (liveness) и готовности (readiness).
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Состояния компонентов и сервиса в целом.
+// StatusDegraded означает, что неработоспособен необязательный компонент:
+// сервис при этом остается готовым принимать запросы.
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// ShutdownComponent - имя компонента, который не готов во время остановки сервиса.
+const ShutdownComponent = "shutdown"
+
+// Check проверяет один компонент и возвращает ошибку, если он неработоспособен.
+type Check func(ctx context.Context) error
+
+// ComponentStatus содержит результат проверки одного компонента.
+type ComponentStatus struct {
+	Status   string  `json:"status"`          // ok, degraded или fail
+	Error    string  `json:"error,omitempty"` // Причина неработоспособности
+	Duration float64 `json:"duration_ms"`     // Длительность проверки в миллисекундах
+}
+
+// Report содержит результат проверки сервиса. Сервис работоспособен (Status ok),
+// только если работоспособны все компоненты, и готов (Status ok или degraded),
+// если работоспособны все обязательные компоненты.
+type Report struct {
+	Status     string                     `json:"status"`               // ok, degraded или fail
+	Components map[string]ComponentStatus `json:"components,omitempty"` // Результаты по компонентам
+}
+
+// OK сообщает, готов ли сервис: неработоспособные необязательные компоненты не учитываются.
+func (r *Report) OK() bool {
+	return r.Status != StatusFail
+}
+
+type namedCheck struct {
+	name     string
+	check    Check
+	optional bool
+}
+
+// Checker выполняет проверки компонентов. Проверки выполняются параллельно,
+// каждая ограничена временем timeout.
+type Checker struct {
+	timeout      time.Duration
+	mutex        sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает проверку состояния; timeout ограничивает время проверки одного компонента
+// (0 - без ограничения).
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку обязательного компонента name: при его отказе сервис не готов.
+// Повторное добавление заменяет проверку.
+func (c *Checker) Add(name string, check Check) {
+	c.add(namedCheck{name: name, check: check})
+}
+
+// AddOptional добавляет проверку необязательного компонента name: его отказ отражается
+// в отчете состоянием degraded, но не делает сервис неготовым.
+// Повторное добавление заменяет проверку.
+func (c *Checker) AddOptional(name string, check Check) {
+	c.add(namedCheck{name: name, check: check, optional: true})
+}
+
+func (c *Checker) add(check namedCheck) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i := range c.checks {
+		if c.checks[i].name == check.name {
+			c.checks[i] = check
+			return
+		}
+	}
+	c.checks = append(c.checks, check)
+}
+
+// SetShuttingDown помечает сервис как останавливающийся: с этого момента Readiness
+// возвращает отказ, чтобы балансировщик перестал направлять новые запросы.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Liveness проверяет, что процесс способен обрабатывать запросы. Зависимости не проверяются:
+// их недоступность не исправить перезапуском, она отражается в Readiness.
+func (c *Checker) Liveness(_ context.Context) *Report {
+	return &Report{Status: StatusOK}
+}
+
+// Readiness проверяет все компоненты и сообщает, готов ли сервис принимать запросы.
+func (c *Checker) Readiness(ctx context.Context) *Report {
+	c.mutex.RLock()
+	checks := make([]namedCheck, len(c.checks))
+	copy(checks, c.checks)
+	c.mutex.RUnlock()
+
+	statuses := make([]ComponentStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = c.run(ctx, check.check)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Components: make(map[string]ComponentStatus, len(checks)+1)}
+	for i, check := range checks {
+		switch {
+		case statuses[i].Status == StatusOK:
+		case check.optional:
+			statuses[i].Status = StatusDegraded
+			if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		default:
+			report.Status = StatusFail
+		}
+		report.Components[check.name] = statuses[i]
+	}
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusFail
+		report.Components[ShutdownComponent] = ComponentStatus{Status: StatusFail, Error: ErrShuttingDown.Error()}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) ComponentStatus {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	// Проверка, не уложившаяся в срок, считается неудачной, даже если не учитывает ctx
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:   StatusOK,
+		Duration: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusFail
+		status.Error = err.Error()
+	}
+
+	return status
+}
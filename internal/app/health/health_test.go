@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("unavailable") }
+	// hang не учитывает ctx и завершается только после окончания теста
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	hang := func(context.Context) error {
+		<-done
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		checks       map[string]Check
+		optional     map[string]Check
+		shuttingDown bool
+		wantStatus   string
+		wantFailed   map[string]string
+	}{
+		{
+			name:       "#1 no checks",
+			wantStatus: StatusOK,
+			wantFailed: map[string]string{},
+		},
+		{
+			name:       "#2 all components ok",
+			checks:     map[string]Check{"storage": ok, "audit.file": ok},
+			wantStatus: StatusOK,
+			wantFailed: map[string]string{},
+		},
+		{
+			name:       "#3 failing component",
+			checks:     map[string]Check{"storage": ok, "audit.file": fail},
+			wantStatus: StatusFail,
+			wantFailed: map[string]string{"audit.file": "unavailable"},
+		},
+		{
+			name:       "#4 failing optional component",
+			checks:     map[string]Check{"storage": ok},
+			optional:   map[string]Check{"audit.file": fail},
+			wantStatus: StatusDegraded,
+			wantFailed: map[string]string{"audit.file": "unavailable"},
+		},
+		{
+			name:       "#5 failing required and optional components",
+			checks:     map[string]Check{"storage": fail},
+			optional:   map[string]Check{"audit.file": fail},
+			wantStatus: StatusFail,
+			wantFailed: map[string]string{"storage": "unavailable", "audit.file": "unavailable"},
+		},
+		{
+			name:       "#6 check ignoring timeout",
+			checks:     map[string]Check{"storage": hang},
+			wantStatus: StatusFail,
+			wantFailed: map[string]string{"storage": context.DeadlineExceeded.Error()},
+		},
+		{
+			name:         "#7 shutting down",
+			checks:       map[string]Check{"storage": ok},
+			shuttingDown: true,
+			wantStatus:   StatusFail,
+			wantFailed:   map[string]string{ShutdownComponent: ErrShuttingDown.Error()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				checker.Add(name, check)
+			}
+			for name, check := range tt.optional {
+				checker.AddOptional(name, check)
+			}
+			if tt.shuttingDown {
+				checker.SetShuttingDown()
+			}
+
+			report := checker.Readiness(context.Background())
+			assert.Equal(t, tt.wantStatus, report.Status)
+			assert.Equal(t, tt.wantStatus != StatusFail, report.OK())
+
+			failed := map[string]string{}
+			for name, status := range report.Components {
+				if status.Status != StatusOK {
+					failed[name] = status.Error
+				}
+			}
+			assert.Equal(t, tt.wantFailed, failed)
+			assert.Equal(t, StatusOK, checker.Liveness(context.Background()).Status)
+		})
+	}
+}
@@ -1,28 +1,197 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-var once sync.Once
-var instance *zap.SugaredLogger
-var loggerErr error
+// Форматы записей журнала.
+const (
+	FormatJSON    = "json"    // Одна запись JSON в строке
+	FormatConsole = "console" // Записи для чтения человеком
+)
+
+// Options задает параметры журнала приложения.
+type Options struct {
+	Level  string // Минимальный уровень записей: debug, info, warn или error (пусто - info)
+	Format string // Формат записей: json или console (пусто - json)
+
+	SamplingInitial    int // Сколько одинаковых записей в секунду пишется полностью (0 - без ограничения)
+	SamplingThereafter int // Из последующих одинаковых записей в ту же секунду пишется каждая N-я
+
+	File       string        // Файл журнала (пусто - стандартный поток ошибок)
+	MaxSizeMB  int           // Ротировать файл журнала при превышении размера в мегабайтах (0 - не ротировать)
+	MaxBackups int           // Сколько ротированных файлов журнала хранить (0 - без ограничения)
+	MaxAge     time.Duration // Сколько хранить ротированные файлы журнала (0 - без ограничения)
+}
+
+// DefaultOptions возвращает параметры журнала по умолчанию, совпадающие с zap.NewProduction.
+func DefaultOptions() Options {
+	return Options{
+		Level:              "info",
+		Format:             FormatJSON,
+		SamplingInitial:    100,
+		SamplingThereafter: 100,
+	}
+}
+
+var (
+	mutex    sync.Mutex
+	instance *zap.SugaredLogger
+	output   *rotatingFile
+	level    = zap.NewAtomicLevel()
+)
+
+// contextKey - тип ключа логгера в контексте.
+type contextKey struct{}
 
 func getInstance() (*zap.SugaredLogger, error) {
-	once.Do(func() {
-		logger, loggerErr := zap.NewProduction()
-		if loggerErr != nil {
-			return
-		}
+	mutex.Lock()
+	defer mutex.Unlock()
 
-		instance = logger.Sugar()
-	})
+	if instance != nil {
+		return instance, nil
+	}
 
-	return instance, loggerErr
+	log, file, err := build(DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+	instance, output = log, file
+
+	return instance, nil
 }
 
+// NewLogger возвращает общий логгер приложения.
+// До вызова Configure используются параметры DefaultOptions.
 func NewLogger() (*zap.SugaredLogger, error) {
 	return getInstance()
 }
+
+// Configure создает общий логгер приложения по параметрам opts и возвращает его.
+// Файл журнала предыдущего логгера закрывается. При ошибке общий логгер не меняется.
+func Configure(opts Options) (*zap.SugaredLogger, error) {
+	log, file, err := build(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	mutex.Lock()
+	previous, previousOutput := instance, output
+	instance, output = log, file
+	mutex.Unlock()
+
+	if previous != nil {
+		_ = previous.Sync()
+	}
+	if previousOutput != nil {
+		_ = previousOutput.Close()
+	}
+
+	return log, nil
+}
+
+// Level возвращает уровень общего логгера. Изменение уровня сразу действует
+// на все логгеры, полученные из общего, в том числе на логгеры запросов.
+func Level() *zap.AtomicLevel {
+	return &level
+}
+
+// Close сбрасывает буферы общего логгера и закрывает файл журнала.
+func Close() error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return nil
+	}
+
+	// Sync стандартного потока ошибок может вернуть ошибку, которая ничего не значит
+	_ = instance.Sync()
+	if output == nil {
+		return nil
+	}
+
+	err := output.Close()
+	output = nil
+
+	return err
+}
+
+// build создает логгер по параметрам opts. Уровень записывается в общий level.
+func build(opts Options) (*zap.SugaredLogger, *rotatingFile, error) {
+	lvl := zapcore.InfoLevel
+	if strings.TrimSpace(opts.Level) != "" {
+		var err error
+		if lvl, err = zapcore.ParseLevel(strings.TrimSpace(opts.Level)); err != nil {
+			return nil, nil, fmt.Errorf("%w: %q", ErrUnknownLevel, opts.Level)
+		}
+	}
+
+	var encoder zapcore.Encoder
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", FormatJSON:
+		encoder = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownFormat, opts.Format)
+	}
+
+	var sink zapcore.WriteSyncer = zapcore.Lock(os.Stderr)
+	var file *rotatingFile
+	if strings.TrimSpace(opts.File) != "" {
+		var err error
+		file, err = openRotatingFile(opts.File, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		sink = file
+	}
+
+	level.SetLevel(lvl)
+	core := zapcore.NewCore(encoder, sink, level)
+	if opts.SamplingInitial > 0 && opts.SamplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, opts.SamplingInitial, opts.SamplingThereafter)
+	}
+
+	log := zap.New(core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+
+	return log.Sugar(), file, nil
+}
+
+// WithContext сохраняет в контексте логгер запроса.
+func WithContext(ctx context.Context, log *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext возвращает логгер запроса из контекста.
+// Если его нет, возвращается общий логгер приложения.
+func FromContext(ctx context.Context) *zap.SugaredLogger {
+	log, err := getInstance()
+	if err != nil || log == nil {
+		log = zap.NewNop().Sugar()
+	}
+
+	return FromContextOr(ctx, log)
+}
+
+// FromContextOr возвращает логгер запроса из контекста или fallback, если его нет.
+func FromContextOr(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	if log, ok := ctx.Value(contextKey{}).(*zap.SugaredLogger); ok && log != nil {
+		return log
+	}
+
+	return fallback
+}
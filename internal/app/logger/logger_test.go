@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/sviatilnik/url-shortener/internal/app/rotation"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() {
+		_, _ = Configure(DefaultOptions())
+	})
+
+	tests := []struct {
+		name      string
+		opts      Options
+		wantErr   error
+		wantLines int
+		wantJSON  bool
+	}{
+		{name: "#1 default level skips debug", opts: Options{Format: FormatJSON}, wantLines: 2, wantJSON: true},
+		{name: "#2 debug level", opts: Options{Level: "debug"}, wantLines: 3, wantJSON: true},
+		{name: "#3 warn level console", opts: Options{Level: "WARN", Format: FormatConsole}, wantLines: 1},
+		{name: "#4 unknown level", opts: Options{Level: "verbose"}, wantErr: ErrUnknownLevel},
+		{name: "#5 unknown format", opts: Options{Format: "xml"}, wantErr: ErrUnknownFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.File = filepath.Join(t.TempDir(), "app.log")
+
+			log, err := Configure(tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			log.Debug("debug")
+			log.Infow("info", "key", "value")
+			log.Warn("warn")
+			require.NoError(t, Close())
+
+			data, err := os.ReadFile(tt.opts.File)
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			assert.Len(t, lines, tt.wantLines)
+			assert.Equal(t, tt.wantJSON, json.Valid([]byte(lines[0])))
+		})
+	}
+}
+
+func TestConfigure_Sampling(t *testing.T) {
+	t.Cleanup(func() {
+		_, _ = Configure(DefaultOptions())
+	})
+
+	tests := []struct {
+		name      string
+		initial   int
+		wantLines int
+	}{
+		{name: "#1 sampled", initial: 2, wantLines: 2 + 8/5},
+		{name: "#2 sampling disabled", initial: 0, wantLines: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "app.log")
+			log, err := Configure(Options{File: file, SamplingInitial: tt.initial, SamplingThereafter: 5})
+			require.NoError(t, err)
+
+			for range 10 {
+				log.Info("repeated")
+			}
+			require.NoError(t, Close())
+
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), tt.wantLines)
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	t.Cleanup(func() {
+		_, _ = Configure(DefaultOptions())
+	})
+
+	file := filepath.Join(t.TempDir(), "app.log")
+	log, err := Configure(Options{Level: "info", File: file})
+	require.NoError(t, err)
+
+	log.Debug("hidden")
+	require.NoError(t, Level().UnmarshalText([]byte("debug")))
+	log.Debug("shown")
+	require.NoError(t, Close())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hidden")
+	assert.Contains(t, string(data), "shown")
+	assert.Equal(t, zapcore.DebugLevel, Level().Level())
+}
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBackups  int
+		maxAge      time.Duration
+		wantBackups int
+	}{
+		{name: "#1 unlimited", wantBackups: 4},
+		{name: "#2 max backups", maxBackups: 2, wantBackups: 2},
+		{name: "#3 max age", maxAge: time.Hour, wantBackups: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			f, err := openRotatingFile(path, Options{MaxBackups: tt.maxBackups, MaxAge: tt.maxAge})
+			require.NoError(t, err)
+			f.maxSize = 10
+
+			// Каждая запись не помещается к предыдущей, поэтому файл ротируется перед ней
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i := range 5 {
+				f.now = func() time.Time { return now.Add(time.Duration(i) * time.Hour) }
+				_, err = f.Write([]byte("0123456789\n"))
+				require.NoError(t, err)
+			}
+
+			// Возраст определяется по времени изменения файла
+			if tt.maxAge > 0 {
+				files, err := rotation.Files(path)
+				require.NoError(t, err)
+				for _, file := range files {
+					require.NoError(t, os.Chtimes(file, now, now))
+				}
+				f.now = func() time.Time { return time.Now().Add(time.Minute) }
+				_, err = f.Write([]byte("0123456789\n"))
+				require.NoError(t, err)
+			}
+			require.NoError(t, f.Close())
+
+			files, err := rotation.Files(path)
+			require.NoError(t, err)
+			assert.Len(t, files, tt.wantBackups)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "0123456789\n", string(data))
+
+			_, err = f.Write([]byte("closed"))
+			assert.ErrorIs(t, err, os.ErrClosed)
+		})
+	}
+}
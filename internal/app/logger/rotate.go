@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/rotation"
+)
+
+// rotatingFile - файл журнала, который переименовывается в "<path>.<время>" при превышении
+// размера; старые файлы удаляются по количеству и возрасту.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+func openRotatingFile(path string, opts Options) (*rotatingFile, error) {
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(opts.MaxSizeMB) * 1024 * 1024,
+		maxBackups: opts.MaxBackups,
+		maxAge:     opts.MaxAge,
+		now:        time.Now,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Write дописывает запись в файл, предварительно ротируя его, если запись не помещается.
+// Ошибка ротации не теряет запись: она дописывается в текущий файл.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Sync сбрасывает файл на диск.
+func (f *rotatingFile) Sync() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Sync()
+}
+
+// Close закрывает файл. Последующие записи возвращают os.ErrClosed.
+func (f *rotatingFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла журнала: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка открытия файла журнала: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+// rotate переименовывает текущий файл, открывает новый и удаляет устаревшие файлы.
+// Если новый файл открыть не удалось, запись продолжается в прежний.
+func (f *rotatingFile) rotate() error {
+	now := f.now()
+	rotated := rotation.Name(f.path, now)
+
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+
+	previous := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	previous.Close()
+
+	return rotation.RemoveExpired(f.path, f.maxBackups, f.maxAge, now)
+}
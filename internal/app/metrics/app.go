@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+// ShortenerMetrics учитывает создание ссылок и переходы по ним.
+type ShortenerMetrics struct {
+	links     *Counter
+	redirects *Counter
+}
+
+// NewShortenerMetrics создает показатели сервиса сокращения ссылок в реестре r.
+func NewShortenerMetrics(r *Registry) *ShortenerMetrics {
+	return &ShortenerMetrics{
+		links:     r.Counter("shortener_links_created_total", "Number of shorten attempts by result.", "result"),
+		redirects: r.Counter("shortener_redirects_total", "Number of redirects to original URLs."),
+	}
+}
+
+// Shortened учитывает попытку создания ссылки с результатом result.
+func (m *ShortenerMetrics) Shortened(result string) {
+	m.links.Inc(result)
+}
+
+// Redirected учитывает переход по ссылке.
+func (m *ShortenerMetrics) Redirected() {
+	m.redirects.Inc()
+}
+
+// StorageMetrics учитывает время и ошибки операций хранилищ.
+type StorageMetrics struct {
+	duration *Histogram
+	errors   *Counter
+}
+
+// NewStorageMetrics создает показатели хранилищ в реестре r.
+func NewStorageMetrics(r *Registry) *StorageMetrics {
+	return &StorageMetrics{
+		duration: r.Histogram("shortener_storage_operation_duration_seconds", "Storage operation latency by backend and operation.", nil, "backend", "operation"),
+		errors:   r.Counter("shortener_storage_operation_errors_total", "Number of failed storage operations by backend and operation.", "backend", "operation"),
+	}
+}
+
+// ObserveOperation учитывает операцию хранилища.
+// Ожидаемые результаты (ссылка не найдена, URL уже сокращен) не считаются ошибками.
+func (m *StorageMetrics) ObserveOperation(backend, operation string, duration time.Duration, err error) {
+	m.duration.Observe(duration.Seconds(), backend, operation)
+
+	if err != nil && !errors.Is(err, storages.ErrKeyNotFound) && !errors.Is(err, storages.ErrOriginalURLAlreadyExists) {
+		m.errors.Inc(backend, operation)
+	}
+}
+
+// DeliveryStatsProvider возвращает показатели доставки событий аудита по получателям.
+type DeliveryStatsProvider interface {
+	DeliveryStats() map[string]audit.DeliveryStats
+}
+
+// RegisterAudit добавляет в реестр показатели очередей доставки аудита.
+func RegisterAudit(r *Registry, provider DeliveryStatsProvider) {
+	r.GaugeFunc("shortener_audit_queue_depth", "Number of audit events waiting for delivery by observer.", []string{"observer"}, func(observe Observe) {
+		stats := provider.DeliveryStats()
+		for _, name := range sortedKeys(stats) {
+			observe(float64(stats[name].Queued), name)
+		}
+	})
+	r.CounterFunc("shortener_audit_events_delivered_total", "Number of audit events delivered by observer.", []string{"observer"}, func(observe Observe) {
+		stats := provider.DeliveryStats()
+		for _, name := range sortedKeys(stats) {
+			observe(float64(stats[name].Delivered), name)
+		}
+	})
+	r.CounterFunc("shortener_audit_events_dropped_total", "Number of audit events dropped by observer.", []string{"observer"}, func(observe Observe) {
+		stats := provider.DeliveryStats()
+		for _, name := range sortedKeys(stats) {
+			observe(float64(stats[name].Dropped), name)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bufio"
+	"sort"
+	"sync"
+)
+
+// Counter - счетчик, разделенный на ряды по значениям меток.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func newCounter(name, help string, labels []string) *Counter {
+	return &Counter{
+		desc:   newDesc(name, help, typeCounter, labels),
+		series: make(map[string]*counterSeries),
+	}
+}
+
+// Inc увеличивает счетчик на единицу.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счетчик на value. Отрицательные значения игнорируются.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+
+	values := c.values(labelValues)
+	key := seriesKey(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	series, ok := c.series[key]
+	if !ok {
+		series = &counterSeries{values: append([]string(nil), values...)}
+		c.series[key] = series
+	}
+	series.value += value
+}
+
+// Value возвращает текущее значение ряда.
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := seriesKey(c.values(labelValues))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if series, ok := c.series[key]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w)
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		c.sample(w, "", series.values, series.value)
+	}
+}
+
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets - границы гистограммы по умолчанию, подходящие для времени ответа в секундах.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram - гистограмма распределения значений, разделенная на ряды по значениям меток.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // Количество значений в каждом интервале (не накопленное)
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{
+		desc:    newDesc(name, help, typeHistogram, labels),
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe учитывает значение value.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	values := h.values(labelValues)
+	key := seriesKey(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{
+			values: append([]string(nil), values...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = series
+	}
+
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		series.counts[i]++
+	}
+	series.sum += value
+	series.count++
+}
+
+// ObserveDuration учитывает время, прошедшее с start, в секундах.
+func (h *Histogram) ObserveDuration(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// Count возвращает количество учтенных значений ряда.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := seriesKey(h.values(labelValues))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if series, ok := h.series[key]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w)
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			h.sample(w, "_bucket", series.values, float64(cumulative), "le", formatValue(bound))
+		}
+		h.sample(w, "_bucket", series.values, float64(series.count), "le", formatValue(math.Inf(1)))
+		h.sample(w, "_sum", series.values, series.sum)
+		h.sample(w, "_count", series.values, float64(series.count))
+	}
+}
@@ -0,0 +1,229 @@
+// Package metrics собирает показатели работы сервиса и отдает их в текстовом
+// формате Prometheus. Пакет не зависит от клиентской библиотеки Prometheus:
+// вывод проверяется в тестах разбором текста.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType - тип содержимого текстового формата Prometheus.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Типы метрик в выводе.
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// metric - семейство метрик, которое умеет записать себя в текстовом формате.
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry хранит метрики сервиса.
+// Метрики создаются методами Counter, Histogram, GaugeFunc и CounterFunc; повторный вызов
+// с тем же именем возвращает ранее созданную метрику.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry создает пустой реестр метрик.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: make(map[string]metric),
+	}
+}
+
+// Counter возвращает счетчик name с метками labels.
+// Если имя уже занято метрикой другого типа, возвращается счетчик, не попадающий в вывод.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		if counter, ok := existing.(*Counter); ok {
+			return counter
+		}
+		return newCounter(name, help, labels)
+	}
+
+	counter := newCounter(name, help, labels)
+	r.metrics[name] = counter
+	return counter
+}
+
+// Histogram возвращает гистограмму name с границами buckets и метками labels.
+// Если buckets пустой, используются DefaultBuckets.
+// Если имя уже занято метрикой другого типа, возвращается гистограмма, не попадающая в вывод.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		if histogram, ok := existing.(*Histogram); ok {
+			return histogram
+		}
+		return newHistogram(name, help, buckets, labels)
+	}
+
+	histogram := newHistogram(name, help, buckets, labels)
+	r.metrics[name] = histogram
+	return histogram
+}
+
+// GaugeFunc регистрирует показатель name, значения которого вычисляются при каждом чтении.
+// collect вызывает observe для каждого набора значений меток.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func(observe Observe)) {
+	r.register(name, &funcMetric{desc: newDesc(name, help, typeGauge, labels), collect: collect})
+}
+
+// CounterFunc регистрирует счетчик name, значения которого вычисляются при каждом чтении.
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func(observe Observe)) {
+	r.register(name, &funcMetric{desc: newDesc(name, help, typeCounter, labels), collect: collect})
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics[name] = m
+}
+
+// Write записывает все метрики в текстовом формате Prometheus, упорядочив их по имени.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	metrics := make([]metric, 0, len(names))
+	for _, name := range names {
+		metrics = append(metrics, r.metrics[name])
+	}
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+
+	return buf.Flush()
+}
+
+// Handler возвращает HTTP-обработчик, отдающий метрики.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.Write(w)
+	})
+}
+
+// Observe передает значение показателя с указанными значениями меток.
+type Observe func(value float64, labelValues ...string)
+
+// desc описывает семейство метрик.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func newDesc(name, help, kind string, labels []string) desc {
+	return desc{name: name, help: help, kind: kind, labels: labels}
+}
+
+func (d desc) header(w *bufio.Writer) {
+	w.WriteString("# HELP " + d.name + " " + escapeHelp(d.help) + "\n")
+	w.WriteString("# TYPE " + d.name + " " + d.kind + "\n")
+}
+
+// values приводит количество значений меток к количеству меток.
+func (d desc) values(labelValues []string) []string {
+	if len(labelValues) == len(d.labels) {
+		return labelValues
+	}
+
+	values := make([]string, len(d.labels))
+	copy(values, labelValues)
+	return values
+}
+
+// sample записывает одно значение: name{labels} value.
+func (d desc) sample(w *bufio.Writer, suffix string, labelValues []string, value float64, extra ...string) {
+	w.WriteString(d.name + suffix)
+	writeLabels(w, d.labels, labelValues, extra...)
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+// seriesKey объединяет значения меток в ключ ряда.
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// writeLabels записывает метки ряда; extra содержит дополнительные пары имя-значение (например, le).
+func writeLabels(w *bufio.Writer, names, values []string, extra ...string) {
+	if len(names) == 0 && len(extra) == 0 {
+		return
+	}
+
+	w.WriteByte('{')
+	first := true
+	pair := func(name, value string) {
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		w.WriteString(name + `="` + escapeLabel(value) + `"`)
+	}
+	for i, name := range names {
+		pair(name, values[i])
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pair(extra[i], extra[i+1])
+	}
+	w.WriteByte('}')
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(value string) string {
+	return helpReplacer.Replace(value)
+}
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
+
+// funcMetric - показатель, значения которого вычисляются при чтении.
+type funcMetric struct {
+	desc
+	collect func(observe Observe)
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.header(w)
+	f.collect(func(value float64, labelValues ...string) {
+		f.sample(w, "", f.values(labelValues), value)
+	})
+}
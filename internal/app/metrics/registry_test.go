@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+// scrape читает метрики через HTTP-обработчик и возвращает значения рядов
+// по строке "имя{метки}", а также типы семейств по имени.
+func scrape(t *testing.T, r *Registry) (map[string]float64, map[string]string) {
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+	samples := make(map[string]float64)
+	types := make(map[string]string)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			require.Len(t, fields, 4, line)
+			types[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.LastIndexByte(line, ' ')
+		require.Positive(t, i, line)
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		require.NoError(t, err, line)
+		samples[line[:i]] = value
+	}
+
+	return samples, types
+}
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+
+	counter := r.Counter("test_requests_total", "Requests.", "route", "code")
+	counter.Inc("/{id}", "200")
+	counter.Add(2, "/{id}", "200")
+	counter.Inc(`/"quoted"`+"\n", "500")
+	counter.Add(-1, "/{id}", "200")
+	assert.Same(t, counter, r.Counter("test_requests_total", "Requests.", "route", "code"))
+
+	histogram := r.Histogram("test_duration_seconds", "Latency.", []float64{0.1, 1}, "route")
+	histogram.Observe(0.05, "/")
+	histogram.Observe(0.5, "/")
+	histogram.Observe(3, "/")
+
+	r.GaugeFunc("test_queue_depth", "Depth.", []string{"observer"}, func(observe Observe) {
+		observe(7, "file")
+	})
+
+	samples, types := scrape(t, r)
+
+	assert.Equal(t, map[string]string{
+		"test_requests_total":   typeCounter,
+		"test_duration_seconds": typeHistogram,
+		"test_queue_depth":      typeGauge,
+	}, types)
+
+	assert.Equal(t, map[string]float64{
+		`test_requests_total{route="/{id}",code="200"}`:         3,
+		`test_requests_total{route="/\"quoted\"\n",code="500"}`: 1,
+		`test_duration_seconds_bucket{route="/",le="0.1"}`:      1,
+		`test_duration_seconds_bucket{route="/",le="1"}`:        2,
+		`test_duration_seconds_bucket{route="/",le="+Inf"}`:     3,
+		`test_duration_seconds_sum{route="/"}`:                  3.55,
+		`test_duration_seconds_count{route="/"}`:                3,
+		`test_queue_depth{observer="file"}`:                     7,
+	}, samples)
+}
+
+func TestRegisterRuntime(t *testing.T) {
+	r := NewRegistry()
+	RegisterRuntime(r)
+
+	samples, types := scrape(t, r)
+
+	assert.Positive(t, samples["go_goroutines"])
+	assert.Positive(t, samples["go_memstats_alloc_bytes"])
+	assert.Equal(t, typeCounter, types["go_gc_cycles_total"])
+	assert.Positive(t, samples["process_start_time_seconds"])
+}
+
+type statsProvider map[string]audit.DeliveryStats
+
+func (p statsProvider) DeliveryStats() map[string]audit.DeliveryStats {
+	return p
+}
+
+func TestAppMetrics(t *testing.T) {
+	r := NewRegistry()
+
+	shortener := NewShortenerMetrics(r)
+	shortener.Shortened("success")
+	shortener.Shortened("conflict")
+	shortener.Redirected()
+
+	storage := NewStorageMetrics(r)
+	storage.ObserveOperation("postgres", "get", 20*time.Millisecond, nil)
+	storage.ObserveOperation("postgres", "get", time.Millisecond, storages.ErrKeyNotFound)
+	storage.ObserveOperation("postgres", "save", time.Millisecond, errors.New("connection refused"))
+
+	RegisterAudit(r, statsProvider{"http": {Queued: 12, Dropped: 3}})
+
+	samples, _ := scrape(t, r)
+
+	assert.Equal(t, float64(1), samples[`shortener_links_created_total{result="success"}`])
+	assert.Equal(t, float64(1), samples[`shortener_links_created_total{result="conflict"}`])
+	assert.Equal(t, float64(1), samples[`shortener_redirects_total`])
+	assert.Equal(t, float64(2), samples[`shortener_storage_operation_duration_seconds_count{backend="postgres",operation="get"}`])
+	assert.Equal(t, float64(1), samples[`shortener_storage_operation_duration_seconds_bucket{backend="postgres",operation="get",le="0.005"}`])
+	assert.NotContains(t, samples, `shortener_storage_operation_errors_total{backend="postgres",operation="get"}`)
+	assert.Equal(t, float64(1), samples[`shortener_storage_operation_errors_total{backend="postgres",operation="save"}`])
+	assert.Equal(t, float64(12), samples[`shortener_audit_queue_depth{observer="http"}`])
+	assert.Equal(t, float64(3), samples[`shortener_audit_events_dropped_total{observer="http"}`])
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bufio"
+	"runtime"
+	"time"
+)
+
+// runtimeMetrics записывает показатели среды выполнения Go.
+// Статистика памяти читается один раз на каждое чтение метрик.
+type runtimeMetrics struct {
+	started time.Time
+}
+
+// RegisterRuntime добавляет в реестр показатели среды выполнения Go:
+// горутины, память, сборку мусора и время запуска процесса.
+func RegisterRuntime(r *Registry) {
+	r.register("go_", &runtimeMetrics{started: time.Now()})
+}
+
+func (m *runtimeMetrics) write(w *bufio.Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	gauge := func(name, help string, value float64) {
+		d := newDesc(name, help, typeGauge, nil)
+		d.header(w)
+		d.sample(w, "", nil, value)
+	}
+	counter := func(name, help string, value float64) {
+		d := newDesc(name, help, typeCounter, nil)
+		d.header(w)
+		d.sample(w, "", nil, value)
+	}
+
+	info := newDesc("go_info", "Information about the Go environment.", typeGauge, []string{"version"})
+	info.header(w)
+	info.sample(w, "", []string{runtime.Version()}, 1)
+
+	gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(stats.Alloc))
+	counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(stats.TotalAlloc))
+	gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(stats.Sys))
+	gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(stats.HeapInuse))
+	gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(stats.HeapObjects))
+	counter("go_memstats_mallocs_total", "Total number of mallocs.", float64(stats.Mallocs))
+	counter("go_memstats_frees_total", "Total number of frees.", float64(stats.Frees))
+	counter("go_gc_cycles_total", "Number of completed GC cycles.", float64(stats.NumGC))
+	counter("go_gc_pause_seconds_total", "Total GC stop-the-world pause time in seconds.", float64(stats.PauseTotalNs)/float64(time.Second))
+	gauge("go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.", float64(stats.LastGC)/float64(time.Second))
+	gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(m.started.Unix()))
+}
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// AuthErrorUserBanned возвращается в поле "error" ответа 403 для заблокированных пользователей.
+const AuthErrorUserBanned = "user_banned"
+
+// AdminChecker определяет, выполняется ли запрос администратором.
+type AdminChecker interface {
+	IsAdmin(ctx context.Context) bool
+}
+
+// BanChecker проверяет, заблокирован ли пользователь.
+type BanChecker interface {
+	IsBanned(ctx context.Context, userID string) (bool, error)
+}
+
+// RequireAdmin отклоняет с кодом 403 запросы пользователей без роли администратора.
+func RequireAdmin(admins AdminChecker) func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !admins.IsAdmin(r.Context()) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			nextHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RejectBanned отклоняет с кодом 403 запросы заблокированных пользователей.
+func RejectBanned(bans BanChecker, logger *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(models.ContextUserID).(string)
+
+			banned, err := bans.IsBanned(r.Context(), userID)
+			if err != nil {
+				requestLogger(r, logger).Errorw("Failed to check user ban", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if banned {
+				encodedResp, _ := json.Marshal(&AuthError{Code: AuthErrorUserBanned, Message: "user is banned"})
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write(encodedResp)
+				return
+			}
+
+			nextHandler.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,27 +2,30 @@ package middlewares
 
 import (
 	"net/http"
-	"strings"
 
 	"github.com/sviatilnik/url-shortener/internal/app/audit"
-	"github.com/sviatilnik/url-shortener/internal/app/models"
 )
 
+// AuditMiddleware связывает события аудита с HTTP-запросом.
+// Сами события создаются сервисами (Shortener, users.Service, admin.Service);
+// middleware дополняет их сведениями о запросе и статусом ответа.
 type AuditMiddleware struct {
 	auditService *audit.AuditService
+	trustProxy   bool
 }
 
-// ключ для хранения URL в контексте
-type AuditContextKey string
-
-const AuditURLKey AuditContextKey = "audit_url"
-
-func NewAuditMiddleware(auditService *audit.AuditService) *AuditMiddleware {
+// NewAuditMiddleware создает middleware аудита.
+// trustProxy разрешает определять IP-адрес клиента по заголовкам обратного прокси.
+func NewAuditMiddleware(auditService *audit.AuditService, trustProxy bool) *AuditMiddleware {
 	return &AuditMiddleware{
 		auditService: auditService,
+		trustProxy:   trustProxy,
 	}
 }
 
+// Audit сохраняет в контексте сведения о запросе и накопитель событий,
+// а после выполнения обработчика проставляет событиям статус ответа и записывает их.
+// Если обработчик вернул ошибку (статус 400 и выше), события помечаются как неудачные.
 func (m *AuditMiddleware) Audit(nextHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Создаем wrapper для ResponseWriter чтобы перехватить статус код
@@ -31,46 +34,25 @@ func (m *AuditMiddleware) Audit(nextHandler http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
-		// Выполняем следующий handler
-		nextHandler.ServeHTTP(wrapper, r)
-
-		// Логируем событие только при успешном выполнении
-		if wrapper.statusCode < 400 {
-			m.logAuditEvent(r)
-		}
-	})
-}
-
-func (m *AuditMiddleware) logAuditEvent(r *http.Request) {
-
-	userID, ok := r.Context().Value(models.ContextUserID).(string)
-	if !ok {
-		userID = ""
-	}
-
-	path := r.URL.Path
-	method := r.Method
-
-	switch {
-	case method == "POST" && (path == "/" || path == "/api/shorten"):
-		// Событие создания короткой ссылки
-		url := m.extractURLFromRequest(r)
-		if url != "" {
-			m.auditService.LogShortenEvent(r.Context(), userID, url)
-		}
-	case method == "GET" && strings.HasPrefix(path, "/") && path != "/ping":
-		// Событие перехода по короткой ссылке
-		if url, ok := r.Context().Value(AuditURLKey).(string); ok && url != "" {
-			m.auditService.LogFollowEvent(r.Context(), userID, url)
+		ctx := audit.WithRequestInfo(r.Context(), audit.RequestInfo{
+			RequestID: requestID(r),
+			ClientIP:  ClientIP(r, m.trustProxy),
+			UserAgent: r.UserAgent(),
+		})
+		ctx, recorder := audit.WithRecorder(ctx)
+
+		nextHandler.ServeHTTP(wrapper, r.WithContext(ctx))
+
+		events := recorder.Events()
+		for _, event := range events {
+			event.Status = wrapper.statusCode
+			if wrapper.statusCode >= http.StatusBadRequest && event.Result == audit.ResultSuccess {
+				event.Result = audit.ResultFailure
+			}
 		}
-	}
-}
 
-func (m *AuditMiddleware) extractURLFromRequest(r *http.Request) string {
-	if url, ok := r.Context().Value(AuditURLKey).(string); ok {
-		return url
-	}
-	return ""
+		m.auditService.Publish(r.Context(), events...)
+	})
 }
 
 // responseWrapper обертка для ResponseWriter для перехвата статус кода
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+type chanObserver chan *audit.AuditEvent
+
+func (c chanObserver) Notify(_ context.Context, event *audit.AuditEvent) error {
+	c <- event
+	return nil
+}
+
+func TestAuditMiddleware_Audit(t *testing.T) {
+	events := make(chanObserver, 10)
+	service := audit.NewAuditService(zap.NewNop().Sugar(), audit.DefaultDeliveryOptions())
+	service.AddObserver(events)
+
+	tests := []struct {
+		name       string
+		status     int
+		emit       bool
+		requestID  string
+		wantResult string
+	}{
+		{name: "#1 success", status: http.StatusCreated, emit: true, requestID: "req-1", wantResult: audit.ResultSuccess},
+		{name: "#2 failure status", status: http.StatusConflict, emit: true, wantResult: audit.ResultFailure},
+		{name: "#3 no events", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuditMiddleware(service, true).Audit(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tt.emit {
+						event := audit.NewAuditEvent(audit.ActionShorten, "", "http://example.com")
+						event.ShortCode = "abc"
+						service.Emit(r.Context(), event)
+
+						// До завершения запроса событие не отправляется
+						assert.Empty(t, events)
+					}
+					w.WriteHeader(tt.status)
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("User-Agent", "test-agent")
+			r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+			if tt.requestID != "" {
+				r.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			r = r.WithContext(context.WithValue(r.Context(), models.ContextUserID, "user"))
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if !tt.emit {
+				select {
+				case event := <-events:
+					t.Fatalf("unexpected event %+v", event)
+				case <-time.After(50 * time.Millisecond):
+				}
+				return
+			}
+
+			var event *audit.AuditEvent
+			select {
+			case event = <-events:
+			case <-time.After(time.Second):
+				t.Fatal("event was not published")
+			}
+
+			require.NotNil(t, event)
+			assert.Equal(t, audit.SchemaVersion, event.SchemaVersion)
+			assert.NotEmpty(t, event.ID)
+			assert.Equal(t, "user", event.UserID)
+			assert.Equal(t, "abc", event.ShortCode)
+			assert.Equal(t, "203.0.113.7", event.ClientIP)
+			assert.Equal(t, "test-agent", event.UserAgent)
+			assert.Equal(t, tt.status, event.Status)
+			assert.Equal(t, tt.wantResult, event.Result)
+			if tt.requestID != "" {
+				assert.Equal(t, tt.requestID, event.RequestID)
+			} else {
+				assert.NotEmpty(t, event.RequestID)
+			}
+		})
+	}
+}
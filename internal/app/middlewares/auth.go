@@ -14,69 +14,176 @@ import (
 
 	"github.com/sviatilnik/url-shortener/internal/app/config"
 	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tokens"
 )
 
 const TokenExp = time.Hour * 3
 
+// TokenRefreshWindow определяет, за сколько до истечения JWT выдается новый токен.
+const TokenRefreshWindow = time.Hour
+
+// TokenAuthenticator проверяет персональные API-токены.
+type TokenAuthenticator interface {
+	// Authenticate возвращает токен по его значению или tokens.ErrInvalidToken.
+	Authenticate(ctx context.Context, token string) (*models.APIToken, error)
+}
+
 type AuthMiddleware struct {
 	config *config.Config
 	logger *zap.SugaredLogger
+	keys   *KeySet
+	tokens TokenAuthenticator
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID string
+	UserID     string
+	Registered bool // Токен выдан зарегистрированному пользователю при регистрации или входе
 }
 
-func NewAuthMiddleware(config *config.Config, logger *zap.SugaredLogger) *AuthMiddleware {
+// NewAuthMiddleware создает middleware аутентификации.
+// keys задает ключи подписи JWT; если nil, используется HS256 с config.AuthSecret.
+// tokens используется для проверки персональных API-токенов и может быть nil.
+func NewAuthMiddleware(config *config.Config, logger *zap.SugaredLogger, keys *KeySet, tokens TokenAuthenticator) *AuthMiddleware {
+	if keys == nil {
+		keys, _ = NewKeySet(NewHMACKey("", []byte(config.AuthSecret)))
+	}
+
 	return &AuthMiddleware{
 		config: config,
 		logger: logger,
+		keys:   keys,
+		tokens: tokens,
 	}
 }
 
+// Auth определяет пользователя по заголовку Authorization (JWT или API-токен) или по cookie.
+// Middleware только аутентифицирует запрос: анонимный идентификатор выдает IssueAnonymous,
+// а отказ в доступе без действительного токена выполняет Require.
+// Причина, по которой предъявленный токен не принят, сохраняется в контексте запроса.
 func (m *AuthMiddleware) Auth(nextHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		key := m.config.AuthSecret
-
-		userID := ""
-		if strings.TrimSpace(r.Header.Get("Authorization")) != "" {
-			userID = getUserID(key, r.Header.Get("Authorization"))
-		} else {
-			authCookie, err := r.Cookie("Authorization")
-			if errors.Is(err, http.ErrNoCookie) || strings.TrimSpace(authCookie.Value) == "" ||
-				!verifySignUserID(key, authCookie.Value) {
-				userID, err = generateUserID()
-				if err != nil {
-					m.logger.Error("Failed to generate user ID", "error", err)
-					nextHandler.ServeHTTP(w, r)
+		state := &authState{}
+		ctx := r.Context()
+
+		if header := strings.TrimSpace(r.Header.Get("Authorization")); header != "" {
+			state.fromHeader = true
+
+			value := bearerToken(header)
+			if m.tokens != nil && tokens.IsAPIToken(value) {
+				token, err := m.tokens.Authenticate(ctx, value)
+				if err != nil && !errors.Is(err, tokens.ErrInvalidToken) {
+					requestLogger(r, m.logger).Errorw("Failed to authenticate API token", "error", err)
+					w.WriteHeader(http.StatusInternalServerError)
 					return
 				}
 
-				userIDSign := signUserID(key, userID)
-				w.Header().Set("Authorization", userIDSign)
-
-				http.SetCookie(w,
-					&http.Cookie{
-						Name:     "Authorization",
-						Value:    userIDSign,
-						HttpOnly: true,
-						Secure:   true,
-						Path:     "/",
-						Expires:  time.Now().Add(TokenExp),
-					},
-				)
+				if err != nil {
+					state.err = &AuthError{Code: AuthErrorRevokedToken, Message: "API token is invalid or revoked", apiToken: true}
+				} else {
+					state.userID = token.UserID
+					state.registered = true
+					ctx = context.WithValue(ctx, models.ContextAPIToken, token)
+				}
 			} else {
-				userID = getUserID(key, authCookie.Value)
+				m.verifyToken(w, value, state)
+			}
+		} else {
+			authCookie, err := r.Cookie("Authorization")
+			if !errors.Is(err, http.ErrNoCookie) && strings.TrimSpace(authCookie.Value) != "" {
+				m.verifyToken(w, authCookie.Value, state)
 			}
 		}
 
-		r = r.WithContext(context.WithValue(r.Context(), models.ContextUserID, userID))
+		ctx = context.WithValue(ctx, models.ContextUserID, state.userID)
+		ctx = context.WithValue(ctx, authStateKey{}, state)
+
+		nextHandler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IssueToken выдает JWT зарегистрированного пользователя в заголовке и cookie Authorization.
+// Используется после регистрации и входа, чтобы заменить анонимный идентификатор.
+func (m *AuthMiddleware) IssueToken(w http.ResponseWriter, userID string) error {
+	token := m.signUserID(userID, true)
+	if token == "" {
+		return ErrSignToken
+	}
+
+	m.setToken(w, token)
+
+	return nil
+}
+
+// ClearToken удаляет cookie Authorization. При следующем запросе будет выдан новый анонимный идентификатор.
+func (m *AuthMiddleware) ClearToken(w http.ResponseWriter) {
+	http.SetCookie(w,
+		&http.Cookie{
+			Name:     "Authorization",
+			Value:    "",
+			HttpOnly: true,
+			Secure:   true,
+			Path:     "/",
+			MaxAge:   -1,
+		},
+	)
+}
+
+func (m *AuthMiddleware) setToken(w http.ResponseWriter, token string) {
+	w.Header().Set("Authorization", token)
+
+	http.SetCookie(w,
+		&http.Cookie{
+			Name:     "Authorization",
+			Value:    token,
+			HttpOnly: true,
+			Secure:   true,
+			Path:     "/",
+			Expires:  time.Now().Add(TokenExp),
+		},
+	)
+}
+
+// RequireScope пропускает запросы, аутентифицированные через JWT, и запросы
+// с API-токеном, которому выдано указанное разрешение. Остальные отклоняются с кодом 403.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := r.Context().Value(models.ContextAPIToken).(*models.APIToken)
+			if ok && !token.HasScope(scope) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			nextHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession отклоняет с кодом 403 запросы, аутентифицированные через API-токен.
+// Используется для управления учетной записью и самими токенами.
+func RequireSession(nextHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(models.ContextAPIToken).(*models.APIToken); ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
 
 		nextHandler.ServeHTTP(w, r)
 	})
 }
 
+// bearerToken извлекает значение из заголовка "Authorization: Bearer <token>".
+// Заголовок без схемы возвращается как есть.
+func bearerToken(header string) string {
+	scheme, value, found := strings.Cut(header, " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(value)
+	}
+
+	return header
+}
+
 func generateUserID() (string, error) {
 	userID := make([]byte, 16)
 	if _, err := rand.Read(userID); err != nil {
@@ -86,39 +193,47 @@ func generateUserID() (string, error) {
 	return hex.EncodeToString(userID), nil
 }
 
-func signUserID(key, userID string) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+// signUserID подписывает JWT с идентификатором пользователя текущим ключом.
+// registered отмечает токен зарегистрированного пользователя.
+// Возвращает пустую строку при ошибке подписи.
+func (m *AuthMiddleware) signUserID(userID string, registered bool) string {
+	tokenString, err := m.keys.Sign(Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExp)),
 		},
-		UserID: userID,
+		UserID:     userID,
+		Registered: registered,
 	})
-
-	tokenString, err := token.SignedString([]byte(key))
 	if err != nil {
+		m.logger.Errorw("Failed to sign token", "error", err)
 		return ""
 	}
 
 	return tokenString
 }
 
-func verifySignUserID(key, token string) bool {
-	return strings.TrimSpace(getUserID(key, token)) != ""
-}
-
-func getUserID(key, tokenString string) string {
+// verifyToken проверяет JWT и записывает в state идентификатор пользователя или причину отказа.
+// Токен, который скоро истечет или подписан не текущим ключом, заменяется новым.
+func (m *AuthMiddleware) verifyToken(w http.ResponseWriter, tokenString string, state *authState) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims,
-		func(t *jwt.Token) (interface{}, error) {
-			return []byte(key), nil
-		})
+	key, err := m.keys.Parse(tokenString, claims)
 	if err != nil {
-		return ""
+		state.err = newAuthError(err)
+		return
 	}
 
-	if !token.Valid {
-		return ""
+	if strings.TrimSpace(claims.UserID) == "" {
+		state.err = &AuthError{Code: AuthErrorInvalidToken, Message: "token has no user ID"}
+		return
+	}
+
+	expiresSoon := claims.ExpiresAt != nil && time.Until(claims.ExpiresAt.Time) < TokenRefreshWindow
+	if expiresSoon || key.ID != m.keys.Current().ID {
+		if refreshed := m.signUserID(claims.UserID, claims.Registered); refreshed != "" {
+			m.setToken(w, refreshed)
+		}
 	}
 
-	return claims.UserID
+	state.userID = claims.UserID
+	state.registered = claims.Registered
 }
@@ -0,0 +1,147 @@
+package middlewares
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// Причины отказа в аутентификации, возвращаемые в поле "error" ответа 401.
+const (
+	AuthErrorMissingToken = "missing_token" // Токен не предъявлен
+	AuthErrorInvalidToken = "invalid_token" // Токен поврежден или подпись неверна
+	AuthErrorTokenExpired = "token_expired" // Срок действия токена истек
+	AuthErrorUnknownKey   = "unknown_key"   // Токен подписан неизвестным или выведенным из оборота ключом
+	AuthErrorRevokedToken = "revoked_token" // API-токен не найден или отозван
+)
+
+// AuthError описывает причину, по которой предъявленный токен не принят.
+type AuthError struct {
+	Code     string `json:"error"`   // Машиночитаемая причина (AuthError*)
+	Message  string `json:"message"` // Описание причины
+	apiToken bool
+}
+
+func (e *AuthError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// authStateKey используется как ключ для хранения результата аутентификации в контексте.
+type authStateKey struct{}
+
+// authState содержит результат аутентификации запроса.
+type authState struct {
+	userID     string
+	registered bool // Пользователь зарегистрирован или запрос аутентифицирован API-токеном
+	err        *AuthError
+	fromHeader bool
+}
+
+func getAuthState(ctx context.Context) *authState {
+	if state, ok := ctx.Value(authStateKey{}).(*authState); ok {
+		return state
+	}
+
+	return &authState{}
+}
+
+func newAuthError(err error) *AuthError {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return &AuthError{Code: AuthErrorTokenExpired, Message: "token has expired"}
+	case errors.Is(err, ErrUnknownKey), errors.Is(err, ErrKeyExpired):
+		return &AuthError{Code: AuthErrorUnknownKey, Message: "token is signed with an unknown or retired key"}
+	default:
+		return &AuthError{Code: AuthErrorInvalidToken, Message: "token is malformed or its signature is invalid"}
+	}
+}
+
+// rejects сообщает, нужно ли отклонить запрос из-за недействительного токена.
+// Недействительный API-токен отклоняется всегда, JWT - только в строгом режиме.
+func (m *AuthMiddleware) rejects(state *authState) bool {
+	return state.err != nil && (m.config.AuthStrict || state.err.apiToken)
+}
+
+// IssueAnonymous выдает анонимный идентификатор запросу без действительного токена,
+// если это разрешено настройкой AuthIssueAnonymous. Применяется к маршрутам, где
+// анонимные пользователи допустимы (например, создание ссылок).
+// В строгом режиме запрос с недействительным токеном отклоняется с кодом 401,
+// а не получает новый идентификатор. В нестрогом режиме, как и раньше,
+// недействительная cookie заменяется новой, а недействительный заголовок дает пустой идентификатор.
+func (m *AuthMiddleware) IssueAnonymous(nextHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := getAuthState(r.Context())
+		if m.rejects(state) {
+			writeAuthError(w, state.err)
+			return
+		}
+
+		if state.userID != "" || !m.config.AuthIssueAnonymous || (state.err != nil && state.fromHeader) {
+			nextHandler.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := generateUserID()
+		if err != nil {
+			requestLogger(r, m.logger).Errorw("Failed to generate user ID", "error", err)
+			nextHandler.ServeHTTP(w, r)
+			return
+		}
+
+		m.setToken(w, m.signUserID(userID, false))
+
+		nextHandler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), models.ContextUserID, userID)))
+	})
+}
+
+// Require требует действительный токен. В строгом режиме запрос без токена
+// или с недействительным токеном отклоняется с кодом 401 и причиной в теле ответа.
+// В нестрогом режиме сохраняется прежнее поведение: при необходимости выдается
+// анонимный идентификатор (см. IssueAnonymous), а проверку выполняет обработчик.
+func (m *AuthMiddleware) Require(nextHandler http.Handler) http.Handler {
+	issueAnonymous := m.IssueAnonymous(nextHandler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.config.AuthStrict {
+			issueAnonymous.ServeHTTP(w, r)
+			return
+		}
+
+		state := getAuthState(r.Context())
+		if state.err != nil {
+			writeAuthError(w, state.err)
+			return
+		}
+
+		if state.userID == "" {
+			writeAuthError(w, &AuthError{Code: AuthErrorMissingToken, Message: "authentication is required"})
+			return
+		}
+
+		nextHandler.ServeHTTP(w, r)
+	})
+}
+
+// writeAuthError отвечает кодом 401 с причиной отказа в заголовке WWW-Authenticate и в теле.
+func writeAuthError(w http.ResponseWriter, authErr *AuthError) {
+	if authErr.Code == AuthErrorMissingToken {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	} else {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+authErr.Message+`"`)
+	}
+
+	encodedResp, err := json.Marshal(authErr)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write(encodedResp)
+}
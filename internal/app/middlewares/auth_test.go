@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/config"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+func TestAuthMiddleware_Refresh(t *testing.T) {
+	m := NewAuthMiddleware(&config.Config{AuthSecret: "secret"}, zap.NewNop().Sugar(), nil, nil)
+
+	var userID string
+	var registered bool
+	handler := m.Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = r.Context().Value(models.ContextUserID).(string)
+		registered = getAuthState(r.Context()).registered
+	}))
+
+	sign := func(ttl time.Duration) string {
+		token, err := m.keys.Sign(Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl))},
+			UserID:           "user",
+			Registered:       true,
+		})
+		require.NoError(t, err)
+
+		return token
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantUserID  string
+		wantRefresh bool
+	}{
+		{
+			name:       "#1 fresh token",
+			token:      sign(TokenExp),
+			wantUserID: "user",
+		},
+		{
+			name:        "#2 token close to expiry",
+			token:       sign(TokenRefreshWindow / 2),
+			wantUserID:  "user",
+			wantRefresh: true,
+		},
+		{
+			name:  "#3 expired token",
+			token: sign(-time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantUserID, userID)
+			assert.Equal(t, tt.wantUserID != "", registered)
+
+			refreshed := w.Header().Get("Authorization")
+			if !tt.wantRefresh {
+				assert.Empty(t, refreshed)
+				return
+			}
+
+			require.NotEmpty(t, refreshed)
+			claims := &Claims{}
+			_, err := m.keys.Parse(refreshed, claims)
+			require.NoError(t, err)
+			assert.Equal(t, "user", claims.UserID)
+			assert.True(t, claims.Registered)
+			assert.Greater(t, time.Until(claims.ExpiresAt.Time), TokenRefreshWindow)
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package middlewares
+
+import "errors"
+
+var (
+	ErrSignToken         = errors.New("failed to sign token")
+	ErrNoSigningKey      = errors.New("current key cannot sign tokens")
+	ErrUnknownKey        = errors.New("unknown signing key")
+	ErrKeyExpired        = errors.New("signing key is no longer accepted")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match key")
+	ErrUnsupportedKey    = errors.New("unsupported key type")
+	ErrInvalidRateLimit  = errors.New("invalid rate limit")
+)
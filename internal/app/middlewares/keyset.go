@@ -0,0 +1,240 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/sviatilnik/url-shortener/internal/app/config"
+)
+
+// SigningKey представляет ключ подписи JWT.
+// Ключ, загруженный только из открытой части, может лишь проверять подписи.
+type SigningKey struct {
+	ID        string            // Идентификатор ключа (заголовок kid)
+	Method    jwt.SigningMethod // Алгоритм подписи
+	ExpiresAt time.Time         // Момент, после которого ключ перестает приниматься (нулевой - без ограничения)
+	signKey   any
+	verifyKey any
+}
+
+// CanSign сообщает, может ли ключ подписывать токены.
+func (k *SigningKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// hmacKeyIDLabel - метка, по которой вычисляется идентификатор HMAC-ключа.
+const hmacKeyIDLabel = "url-shortener jwt key id"
+
+// NewHMACKey создает ключ HS256 из секрета.
+// Если идентификатор не задан, он вычисляется как HMAC секрета от постоянной метки:
+// так он одинаков для одного секрета, но не раскрывает его отпечаток.
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	if id == "" {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(hmacKeyIDLabel))
+		id = hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+
+	return &SigningKey{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// ParseKeyPEM создает ключ из PEM-данных. Поддерживаются закрытые и открытые ключи
+// RSA (RS256) и Ed25519 (EdDSA). Если идентификатор не задан, он вычисляется
+// по открытой части ключа, поэтому у закрытого ключа и его открытой части он совпадает.
+func ParseKeyPEM(id string, data []byte) (*SigningKey, error) {
+	var (
+		key    = &SigningKey{ID: id}
+		public crypto.PublicKey
+	)
+
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		key.Method, key.signKey, public = jwt.SigningMethodRS256, private, &private.PublicKey
+	} else if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		key.Method, key.signKey, public = jwt.SigningMethodEdDSA, private, private.(ed25519.PrivateKey).Public()
+	} else if pub, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		key.Method, public = jwt.SigningMethodRS256, pub
+	} else if pub, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		key.Method, public = jwt.SigningMethodEdDSA, pub
+	} else {
+		return nil, ErrUnsupportedKey
+	}
+
+	switch pub := public.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		key.verifyKey = pub
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if key.ID == "" {
+		der, err := x509.MarshalPKIXPublicKey(public)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(der)
+		key.ID = hex.EncodeToString(sum[:8])
+	}
+
+	return key, nil
+}
+
+// LoadKeyFile загружает ключ из PEM-файла (см. ParseKeyPEM).
+func LoadKeyFile(id, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeyPEM(id, data)
+}
+
+// KeySet содержит текущий ключ подписи JWT и предыдущие ключи,
+// которые принимаются при проверке до истечения их срока.
+type KeySet struct {
+	current *SigningKey
+	keys    map[string]*SigningKey
+	methods []string
+	now     func() time.Time
+}
+
+// NewKeySet создает набор ключей. Токены подписываются ключом current,
+// а проверяются любым ключом набора по заголовку kid.
+// Токены без kid (выпущенные до появления ротации) проверяются текущим ключом.
+func NewKeySet(current *SigningKey, previous ...*SigningKey) (*KeySet, error) {
+	if current == nil || !current.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+
+	set := &KeySet{
+		current: current,
+		keys:    make(map[string]*SigningKey),
+		now:     time.Now,
+	}
+
+	for _, key := range append([]*SigningKey{current}, previous...) {
+		if _, exists := set.keys[key.ID]; exists {
+			continue
+		}
+
+		set.keys[key.ID] = key
+		if !slices.Contains(set.methods, key.Method.Alg()) {
+			set.methods = append(set.methods, key.Method.Alg())
+		}
+	}
+
+	return set, nil
+}
+
+// NewKeySetFromConfig создает набор ключей по конфигурации:
+//   - если задан AuthPrivateKeyFile, токены подписываются этим ключом (RS256 или EdDSA),
+//     иначе - секретом AuthSecret (HS256);
+//   - ключи из AuthPreviousKeyFiles и секреты из AuthPreviousSecrets принимаются
+//     в течение AuthKeyGracePeriod с момента запуска.
+func NewKeySetFromConfig(conf *config.Config) (*KeySet, error) {
+	var (
+		current *SigningKey
+		err     error
+	)
+
+	if strings.TrimSpace(conf.AuthPrivateKeyFile) != "" {
+		current, err = LoadKeyFile(conf.AuthKeyID, conf.AuthPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		current = NewHMACKey(conf.AuthKeyID, []byte(conf.AuthSecret))
+	}
+
+	expiresAt := time.Now().Add(conf.AuthKeyGracePeriod)
+	previous := make([]*SigningKey, 0)
+
+	for _, path := range splitList(conf.AuthPreviousKeyFiles) {
+		key, err := LoadKeyFile("", path)
+		if err != nil {
+			return nil, err
+		}
+		key.ExpiresAt = expiresAt
+		previous = append(previous, key)
+	}
+
+	for _, secret := range splitList(conf.AuthPreviousSecrets) {
+		key := NewHMACKey("", []byte(secret))
+		key.ExpiresAt = expiresAt
+		previous = append(previous, key)
+	}
+
+	return NewKeySet(current, previous...)
+}
+
+// Current возвращает текущий ключ подписи.
+func (k *KeySet) Current() *SigningKey {
+	return k.current
+}
+
+// Sign подписывает claims текущим ключом и добавляет его идентификатор в заголовок kid.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.current.Method, claims)
+	token.Header["kid"] = k.current.ID
+
+	return token.SignedString(k.current.signKey)
+}
+
+// Parse проверяет подпись и срок действия токена и заполняет claims.
+// Алгоритм токена должен совпадать с алгоритмом ключа, указанного в kid.
+// Возвращает ключ, которым был подписан токен.
+func (k *KeySet) Parse(tokenString string, claims jwt.Claims) (*SigningKey, error) {
+	var used *SigningKey
+
+	parser := jwt.NewParser(jwt.WithValidMethods(k.methods))
+	_, err := parser.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		key := k.current
+		if kid, ok := t.Header["kid"].(string); ok {
+			if key, ok = k.keys[kid]; !ok {
+				return nil, ErrUnknownKey
+			}
+		}
+
+		if !key.ExpiresAt.IsZero() && k.now().After(key.ExpiresAt) {
+			return nil, ErrKeyExpired
+		}
+
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, ErrAlgorithmMismatch
+		}
+
+		used = key
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return used, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,184 @@
+package middlewares
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/config"
+)
+
+func testClaims(userID string) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenExp)),
+		},
+		UserID: userID,
+	}
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+
+	return path
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey := NewHMACKey("", []byte("old-secret"))
+	oldSet, err := NewKeySet(oldKey)
+	require.NoError(t, err)
+
+	token, err := oldSet.Sign(testClaims("user"))
+	require.NoError(t, err)
+
+	previous := NewHMACKey("", []byte("old-secret"))
+	previous.ExpiresAt = time.Now().Add(time.Hour)
+	newSet, err := NewKeySet(NewHMACKey("", []byte("new-secret")), previous)
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	key, err := newSet.Parse(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.UserID)
+	assert.Equal(t, oldKey.ID, key.ID)
+
+	// После окончания льготного периода старый ключ не принимается
+	newSet.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = newSet.Parse(token, &Claims{})
+	assert.ErrorIs(t, err, ErrKeyExpired)
+
+	// Без старого ключа токен отклоняется
+	otherSet, err := NewKeySet(NewHMACKey("", []byte("new-secret")))
+	require.NoError(t, err)
+	_, err = otherSet.Parse(token, &Claims{})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestNewHMACKey_ID(t *testing.T) {
+	secret := []byte("secret")
+	fingerprint := sha256.Sum256(append([]byte("kid:"), secret...))
+
+	tests := []struct {
+		name   string
+		id     string
+		secret []byte
+		want   string
+	}{
+		{name: "#1 configured id", id: "main", secret: secret, want: "main"},
+		{name: "#2 derived id is stable", secret: secret, want: NewHMACKey("", []byte("secret")).ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewHMACKey(tt.id, tt.secret).ID)
+		})
+	}
+
+	// Производный идентификатор не совпадает с отпечатком секрета и различается для разных секретов
+	id := NewHMACKey("", secret).ID
+	assert.Len(t, id, 16)
+	assert.NotEqual(t, hex.EncodeToString(fingerprint[:8]), id)
+	assert.NotEqual(t, NewHMACKey("", []byte("other-secret")).ID, id)
+}
+
+func TestKeySet_LegacyTokenWithoutKid(t *testing.T) {
+	set, err := NewKeySet(NewHMACKey("", []byte("secret")))
+	require.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims("user")).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	claims := &Claims{}
+	_, err = set.Parse(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.UserID)
+}
+
+func TestKeySet_Asymmetric(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPrivateDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	require.NoError(t, err)
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPrivate.Public())
+	require.NoError(t, err)
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	require.NoError(t, err)
+
+	edKey, err := LoadKeyFile("", writePEM(t, "PRIVATE KEY", edPrivateDER))
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodEdDSA, edKey.Method)
+
+	edPublicKey, err := LoadKeyFile("", writePEM(t, "PUBLIC KEY", edPublicDER))
+	require.NoError(t, err)
+	assert.Equal(t, edKey.ID, edPublicKey.ID)
+	assert.False(t, edPublicKey.CanSign())
+
+	_, err = NewKeySet(edPublicKey)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	rsaKey, err := ParseKeyPEM("", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivate)}))
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodRS256, rsaKey.Method)
+
+	// Токен, подписанный старым ключом Ed25519, проверяется по его открытой части
+	edSet, err := NewKeySet(edKey)
+	require.NoError(t, err)
+	token, err := edSet.Sign(testClaims("user"))
+	require.NoError(t, err)
+
+	rsaSet, err := NewKeySet(rsaKey, edPublicKey)
+	require.NoError(t, err)
+	claims := &Claims{}
+	_, err = rsaSet.Parse(token, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "user", claims.UserID)
+
+	// Подмена алгоритма: HS256 с открытым ключом RSA в качестве секрета
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims("admin"))
+	forged.Header["kid"] = rsaKey.ID
+	forgedString, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER}))
+	require.NoError(t, err)
+
+	_, err = rsaSet.Parse(forgedString, &Claims{})
+	assert.Error(t, err)
+
+	// Алгоритм none не принимается
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims("admin"))
+	noneString, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = rsaSet.Parse(noneString, &Claims{})
+	assert.Error(t, err)
+}
+
+func TestNewKeySetFromConfig(t *testing.T) {
+	conf := &config.Config{
+		AuthSecret:          "new-secret",
+		AuthPreviousSecrets: "old-secret, older-secret",
+		AuthKeyGracePeriod:  time.Hour,
+	}
+
+	set, err := NewKeySetFromConfig(conf)
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodHS256, set.Current().Method)
+	assert.Len(t, set.keys, 3)
+
+	conf.AuthPrivateKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+	_, err = NewKeySetFromConfig(conf)
+	assert.Error(t, err)
+}
@@ -7,15 +7,9 @@ import (
 	"github.com/sviatilnik/url-shortener/internal/app/logger"
 )
 
+// Log записывает в журнал каждый запрос логгером запроса (см. RequestID).
 func Log(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logg, err := logger.NewLogger()
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
-			return
-		}
-
 		start := time.Now()
 
 		lw := &loggingResponseWriter{
@@ -30,7 +24,7 @@ func Log(next http.Handler) http.Handler {
 
 		duration := time.Since(start)
 
-		logg.Infow(
+		logger.FromContext(r.Context()).Infow(
 			"request",
 			"uri", r.RequestURI,
 			"method", r.Method,
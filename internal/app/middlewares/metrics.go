@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sviatilnik/url-shortener/internal/app/metrics"
+)
+
+// unmatchedRoute - значение метки route для запросов, не подошедших ни к одному маршруту.
+// Путь запроса в метку не попадает, чтобы количество рядов не зависело от запросов клиентов.
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware учитывает количество и время обработки запросов по маршрутам.
+type MetricsMiddleware struct {
+	requests *metrics.Counter
+	duration *metrics.Histogram
+}
+
+// NewMetricsMiddleware создает middleware, записывающий показатели в registry.
+func NewMetricsMiddleware(registry *metrics.Registry) *MetricsMiddleware {
+	return &MetricsMiddleware{
+		requests: registry.Counter("shortener_http_requests_total", "Number of HTTP requests by method, route and status code.", "method", "route", "code"),
+		duration: registry.Histogram("shortener_http_request_duration_seconds", "HTTP request latency by method and route.", nil, "method", "route"),
+	}
+}
+
+// Measure учитывает запрос после его обработки.
+// Маршрут берется из шаблона chi (например, "/{short_code}"), поэтому middleware
+// должен быть подключен к роутеру chi.
+func (m *MetricsMiddleware) Measure(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &loggingResponseWriter{
+			ResponseWriter: w,
+			data:           &responseData{},
+		}
+
+		next.ServeHTTP(lw, r)
+
+		route := unmatchedRoute
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+
+		status := lw.data.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.requests.Inc(r.Method, route, strconv.Itoa(status))
+		m.duration.ObserveDuration(start, r.Method, route)
+	})
+}
@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+// Группы маршрутов с раздельными ограничениями частоты запросов.
+const (
+	RateLimitCreate   = "create"
+	RateLimitRedirect = "redirect"
+)
+
+// RateLimiter ограничивает частоту запросов по алгоритму token bucket.
+// Запросы зарегистрированного пользователя и запросы с API-токеном учитываются
+// по идентификатору из models.ContextUserID, анонимные запросы - по IP-адресу клиента,
+// чтобы новые анонимные идентификаторы не давали новых корзин.
+// Зарегистрированный пользователь определяется по результату AuthMiddleware.Auth без обращения к хранилищу.
+type RateLimiter struct {
+	store      storages.RateLimitStorage
+	logger     *zap.SugaredLogger
+	trustProxy bool
+	now        func() time.Time
+}
+
+// NewRateLimiter создает middleware ограничения частоты запросов.
+// Если trustProxy равен true, IP-адрес клиента берется из заголовков X-Forwarded-For и X-Real-IP.
+func NewRateLimiter(store storages.RateLimitStorage, logger *zap.SugaredLogger, trustProxy bool) *RateLimiter {
+	return &RateLimiter{
+		store:      store,
+		logger:     logger,
+		trustProxy: trustProxy,
+		now:        time.Now,
+	}
+}
+
+// Limit возвращает middleware, применяющий ограничение limit к группе маршрутов name.
+// Каждая группа имеет собственные корзины. Ответы содержат заголовки RateLimit-*,
+// а отклоненные запросы получают код 429 и заголовок Retry-After.
+// При ошибке хранилища запрос пропускается.
+func (l *RateLimiter) Limit(name string, limit models.RateLimit) func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return nextHandler
+		}
+
+		policy := strconv.Itoa(limit.Limit) + ";w=" + strconv.Itoa(ceilSeconds(limit.Period))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := l.store.Take(r.Context(), name+":"+l.key(r), limit, l.now())
+			if err != nil {
+				requestLogger(r, l.logger).Errorw("Failed to check rate limit", "error", err)
+				nextHandler.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", policy)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			nextHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// key возвращает ключ корзины: идентификатор зарегистрированного пользователя или IP-адрес клиента.
+// Анонимный идентификатор может получить любой клиент, поэтому он не дает собственной корзины.
+func (l *RateLimiter) key(r *http.Request) string {
+	if userID, _ := r.Context().Value(models.ContextUserID).(string); userID != "" && getAuthState(r.Context()).registered {
+		return "user:" + userID
+	}
+
+	return "ip:" + ClientIP(r, l.trustProxy)
+}
+
+// ClientIP возвращает IP-адрес клиента.
+// Если trustProxy включен, адрес берется из заголовков X-Forwarded-For (первый адрес)
+// или X-Real-IP, выставляемых обратным прокси.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ParseRateLimit разбирает ограничение в формате "<количество>/<период>", например "60/m",
+// "10/s", "1000/h" или "5/30s". Пустая строка и "0" означают отсутствие ограничения.
+func ParseRateLimit(value string) (models.RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return models.RateLimit{}, nil
+	}
+
+	count, period, found := strings.Cut(value, "/")
+	if !found {
+		return models.RateLimit{}, ErrInvalidRateLimit
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || limit < 0 {
+		return models.RateLimit{}, ErrInvalidRateLimit
+	}
+
+	period = strings.TrimSpace(period)
+	switch period {
+	case "s", "m", "h":
+		period = "1" + period
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return models.RateLimit{}, ErrInvalidRateLimit
+	}
+
+	return models.RateLimit{Limit: limit, Period: duration}, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,124 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    models.RateLimit
+		wantErr bool
+	}{
+		{name: "#1 empty", value: "", want: models.RateLimit{}},
+		{name: "#2 zero", value: "0", want: models.RateLimit{}},
+		{name: "#3 per minute", value: "60/m", want: models.RateLimit{Limit: 60, Period: time.Minute}},
+		{name: "#4 per second", value: " 10 / s ", want: models.RateLimit{Limit: 10, Period: time.Second}},
+		{name: "#5 duration", value: "5/30s", want: models.RateLimit{Limit: 5, Period: 30 * time.Second}},
+		{name: "#6 no period", value: "60", wantErr: true},
+		{name: "#7 bad count", value: "many/m", wantErr: true},
+		{name: "#8 bad period", value: "60/week", wantErr: true},
+		{name: "#9 negative period", value: "60/-1m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRateLimit)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRateLimiter_Limit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(storages.NewInMemoryRateLimitStorage(), zap.NewNop().Sugar(), true)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Limit(RateLimitCreate, models.RateLimit{Limit: 2, Period: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	// Пользователь "user" зарегистрирован: так его отмечает AuthMiddleware.Auth по токену
+	do := func(ip, userID string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if ip != "" {
+			req.Header.Set("X-Forwarded-For", ip+", 10.0.0.1")
+		}
+		ctx := context.WithValue(req.Context(), models.ContextUserID, userID)
+		ctx = context.WithValue(ctx, authStateKey{}, &authState{userID: userID, registered: userID == "user"})
+		req = req.WithContext(ctx)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w.Result()
+	}
+
+	resp := do("203.0.113.1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "2;w=60", resp.Header.Get("RateLimit-Policy"))
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get("RateLimit-Reset"))
+
+	resp = do("203.0.113.1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	resp = do("203.0.113.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+	assert.Equal(t, "60", resp.Header.Get("RateLimit-Reset"))
+
+	// Новый анонимный идентификатор не дает новой корзины
+	assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.1", "anonymous").StatusCode)
+
+	// Другой IP-адрес и зарегистрированный пользователь имеют собственные корзины
+	assert.Equal(t, http.StatusOK, do("203.0.113.2", "").StatusCode)
+	assert.Equal(t, http.StatusOK, do("203.0.113.1", "user").StatusCode)
+	assert.Equal(t, http.StatusOK, do("203.0.113.3", "user").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.4", "user").StatusCode)
+
+	// Без заголовков прокси учитывается адрес соединения
+	assert.Equal(t, http.StatusOK, do("", "").StatusCode)
+
+	now = now.Add(10 * time.Second)
+	resp = do("203.0.113.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "20", resp.Header.Get("Retry-After"))
+
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, http.StatusOK, do("203.0.113.1", "").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, do("203.0.113.1", "").StatusCode)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	limiter := NewRateLimiter(storages.NewInMemoryRateLimitStorage(), zap.NewNop().Sugar(), false)
+
+	handler := limiter.Limit(RateLimitRedirect, models.RateLimit{})(next)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abc", nil))
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+// RequestIDHeader содержит идентификатор запроса, переданный клиентом или прокси.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора запроса, принимаемого от клиента.
+const maxRequestIDLength = 128
+
+// RequestID присваивает запросу идентификатор и сохраняет в контексте логгер запроса.
+// Идентификатор берется из заголовка X-Request-ID или создается заново и возвращается
+// клиенту в том же заголовке. Логгер запроса получается из log и добавляет ко всем
+// записям request_id, а если запрос трассируется - trace_id.
+// Middleware должен быть подключен раньше Log и AuditMiddleware.
+func RequestID(log *zap.SugaredLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := requestID(r)
+			w.Header().Set(RequestIDHeader, id)
+
+			requestLog := log.With("request_id", id)
+			if spanContext := tracing.SpanFromContext(r.Context()).SpanContext(); spanContext.IsValid() {
+				requestLog = requestLog.With("trace_id", spanContext.TraceID.String())
+			}
+
+			ctx := context.WithValue(r.Context(), models.ContextRequestID, id)
+			ctx = logger.WithContext(ctx, requestLog)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestID возвращает идентификатор запроса из контекста, заголовка X-Request-ID
+// или создает новый, если заголовок не передан или слишком длинный.
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(models.ContextRequestID).(string); ok && id != "" {
+		return id
+	}
+
+	if id := strings.TrimSpace(r.Header.Get(RequestIDHeader)); id != "" && len(id) <= maxRequestIDLength && isPrintable(id) {
+		return id
+	}
+
+	return audit.NewEventID()
+}
+
+// isPrintable проверяет, что идентификатор состоит из видимых ASCII-символов
+// и может без искажений попасть в журнал и заголовок ответа.
+func isPrintable(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// requestLogger возвращает логгер запроса или fallback, если RequestID не подключен.
+func requestLogger(r *http.Request, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	return logger.FromContextOr(r.Context(), fallback)
+}
@@ -0,0 +1,108 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		wantSame  bool
+	}{
+		{name: "#1", requestID: "req-1", wantSame: true},
+		{name: "#2", requestID: ""},
+		{name: "#3", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "#4", requestID: "req 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+
+			var ctxRequestID string
+			handler := RequestID(zap.New(core).Sugar())(
+				Log(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					ctxRequestID, _ = r.Context().Value(models.ContextRequestID).(string)
+					logger.FromContext(r.Context()).Info("handler")
+					w.WriteHeader(http.StatusNoContent)
+				})),
+			)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				r.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			responseID := w.Header().Get(RequestIDHeader)
+			require.NotEmpty(t, responseID)
+			if tt.wantSame {
+				assert.Equal(t, tt.requestID, responseID)
+			} else {
+				assert.NotEqual(t, tt.requestID, responseID)
+			}
+			assert.Equal(t, responseID, ctxRequestID)
+
+			// Запись обработчика и запись Log о запросе содержат один идентификатор
+			entries := logs.AllUntimed()
+			require.Len(t, entries, 2)
+			for _, entry := range entries {
+				assert.Equal(t, responseID, entry.ContextMap()["request_id"])
+			}
+			assert.Equal(t, "request", entries[1].Message)
+		})
+	}
+}
+
+func TestRequestID_Correlation(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	events := make(chanObserver, 1)
+	service := audit.NewAuditService(zap.NewNop().Sugar(), audit.DefaultDeliveryOptions())
+	service.AddObserver(events)
+
+	exporter := tracing.NewWriterExporter(&strings.Builder{})
+	tracer, err := tracing.NewTracer("test", exporter, tracing.DefaultOptions(), zap.NewNop().Sugar())
+	require.NoError(t, err)
+	defer tracer.Shutdown(context.Background())
+
+	var traceID string
+	handler := NewTracingMiddleware(tracer).Trace(
+		RequestID(zap.New(core).Sugar())(
+			NewAuditMiddleware(service, false).Audit(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					traceID = tracing.SpanFromContext(r.Context()).SpanContext().TraceID.String()
+					service.Emit(r.Context(), audit.NewAuditEvent(audit.ActionFollow, "", "http://example.com"))
+					logger.FromContext(r.Context()).Info("handler")
+				}),
+			),
+		),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	r.Header.Set(RequestIDHeader, "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	event := <-events
+	assert.Equal(t, "req-42", event.RequestID)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]any{"request_id": "req-42", "trace_id": traceID}, entries[0].ContextMap())
+}
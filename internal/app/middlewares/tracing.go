@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+// TracingMiddleware создает span для каждого HTTP-запроса.
+type TracingMiddleware struct {
+	tracer *tracing.Tracer
+}
+
+// NewTracingMiddleware создает middleware, записывающий span запросов через tracer.
+func NewTracingMiddleware(tracer *tracing.Tracer) *TracingMiddleware {
+	return &TracingMiddleware{tracer: tracer}
+}
+
+// Trace начинает span запроса и сохраняет его в контексте запроса, чтобы операции
+// сервисов и хранилищ стали его дочерними span. Если запрос содержит заголовок
+// traceparent, span продолжает трассу вызывающей стороны.
+// Название span включает шаблон маршрута chi, поэтому middleware должен быть
+// подключен к роутеру chi.
+func (t *TracingMiddleware) Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if parent, err := tracing.Extract(r.Header); err == nil {
+			ctx = tracing.ContextWithRemote(ctx, parent)
+		}
+
+		ctx, span := t.tracer.Start(ctx, r.Method, tracing.SpanKindServer,
+			tracing.String("http.request.method", r.Method),
+			tracing.String("url.path", r.URL.Path),
+			tracing.String("user_agent.original", r.UserAgent()),
+		)
+		defer span.End()
+
+		lw := &loggingResponseWriter{
+			ResponseWriter: w,
+			data:           &responseData{},
+		}
+
+		next.ServeHTTP(lw, r.WithContext(ctx))
+
+		route := unmatchedRoute
+		if routeCtx := chi.RouteContext(ctx); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+
+		status := lw.data.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			tracing.String("http.route", route),
+			tracing.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(tracing.StatusError, http.StatusText(status))
+		}
+	})
+}
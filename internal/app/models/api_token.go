@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	// ScopeLinksRead разрешает просмотр ссылок пользователя.
+	ScopeLinksRead = "links:read"
+	// ScopeLinksWrite разрешает создание и удаление ссылок пользователя.
+	ScopeLinksWrite = "links:write"
+	// ScopeAdmin разрешает доступ к административному API. Выдается только администраторам.
+	ScopeAdmin = "admin"
+)
+
+// APIToken представляет персональный API-токен пользователя.
+// Сам токен не хранится - только его хэш.
+type APIToken struct {
+	ID         string    // Уникальный идентификатор токена
+	UserID     string    // Идентификатор пользователя-владельца токена
+	Name       string    // Название токена, заданное пользователем
+	Hash       string    // SHA-256 хэш токена в шестнадцатеричном виде
+	Scopes     []string  // Разрешения токена (ScopeLinksRead, ScopeLinksWrite, ScopeAdmin)
+	CreatedAt  time.Time // Время создания
+	LastUsedAt time.Time // Время последнего использования (нулевое, если токен не использовался)
+}
+
+// HasScope проверяет, выдано ли токену указанное разрешение.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, item := range t.Scopes {
+		if item == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Clone возвращает глубокую копию токена.
+func (t *APIToken) Clone() *APIToken {
+	tokenCopy := *t
+	tokenCopy.Scopes = append([]string(nil), t.Scopes...)
+
+	return &tokenCopy
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Ban представляет блокировку пользователя администратором.
+type Ban struct {
+	UserID    string    // Идентификатор заблокированного пользователя
+	Reason    string    // Причина блокировки
+	BannedBy  string    // Идентификатор администратора
+	CreatedAt time.Time // Время блокировки
+}
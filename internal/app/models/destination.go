@@ -0,0 +1,9 @@
+package models
+
+// Destination представляет один из вариантов назначения ссылки при A/B-тестировании.
+// Трафик распределяется между вариантами пропорционально их весам.
+type Destination struct {
+	URL    string `json:"url"`    // URL варианта
+	Weight int    `json:"weight"` // Вес варианта при распределении трафика
+	Clicks int64  `json:"clicks"` // Количество переходов на вариант
+}
@@ -2,10 +2,43 @@ package models
 
 // Link представляет структуру ссылки в системе сокращения URL.
 type Link struct {
-	ID          string // Уникальный идентификатор ссылки
-	ShortCode   string // Короткий код для доступа к ссылке
-	ShortURL    string // Полная сокращенная ссылка
-	OriginalURL string // Оригинальный URL
-	UserID      string // Идентификатор пользователя-владельца ссылки
-	IsDeleted   bool   // Флаг удаления ссылки (soft delete)
+	ID           string            // Уникальный идентификатор ссылки
+	ShortCode    string            // Короткий код для доступа к ссылке
+	ShortURL     string            // Полная сокращенная ссылка
+	OriginalURL  string            // Оригинальный URL (в каноническом виде, если включена нормализация)
+	RawURL       string            // URL в точности как его передал пользователь (если отличается от OriginalURL)
+	UserID       string            // Идентификатор пользователя-владельца ссылки
+	IsDeleted    bool              // Флаг удаления ссылки (soft delete)
+	IsDisabled   bool              // Флаг блокировки ссылки администратором
+	QueryPolicy  QueryPolicy       // Политика объединения параметров запроса при переходе (пусто - глобальная)
+	UTM          map[string]string // Шаблон UTM-параметров, добавляемых при каждом переходе
+	Destinations []Destination     // Варианты назначения для A/B-тестирования (пусто - только OriginalURL)
+}
+
+// SubmittedURL возвращает URL в том виде, в котором его передал пользователь.
+func (l *Link) SubmittedURL() string {
+	if l.RawURL != "" {
+		return l.RawURL
+	}
+
+	return l.OriginalURL
+}
+
+// Clone возвращает глубокую копию ссылки.
+func (l *Link) Clone() *Link {
+	linkCopy := *l
+
+	if l.UTM != nil {
+		linkCopy.UTM = make(map[string]string, len(l.UTM))
+		for k, v := range l.UTM {
+			linkCopy.UTM[k] = v
+		}
+	}
+
+	if l.Destinations != nil {
+		linkCopy.Destinations = make([]Destination, len(l.Destinations))
+		copy(linkCopy.Destinations, l.Destinations)
+	}
+
+	return &linkCopy
 }
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// LinkFilter задает условия поиска ссылок в административном API.
+// Пустые поля не ограничивают выборку.
+type LinkFilter struct {
+	URL    string // Подстрока оригинального URL (без учета регистра)
+	Domain string // Домен оригинального URL; совпадают также его поддомены
+	UserID string // Идентификатор владельца ссылки
+	Limit  int    // Максимальное количество ссылок (0 - без ограничения)
+	Offset int    // Количество пропускаемых ссылок
+}
+
+// Match проверяет, удовлетворяет ли ссылка условиям фильтра. Limit и Offset не учитываются.
+func (f LinkFilter) Match(link *Link) bool {
+	if f.UserID != "" && link.UserID != f.UserID {
+		return false
+	}
+
+	if f.URL != "" && !strings.Contains(strings.ToLower(link.OriginalURL), strings.ToLower(f.URL)) {
+		return false
+	}
+
+	if f.Domain != "" {
+		parsed, err := url.Parse(link.OriginalURL)
+		if err != nil {
+			return false
+		}
+
+		host := strings.ToLower(parsed.Hostname())
+		domain := strings.ToLower(strings.TrimPrefix(f.Domain, "."))
+		if host != domain && !strings.HasSuffix(host, "."+domain) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Page возвращает часть ссылок согласно Limit и Offset фильтра.
+func (f LinkFilter) Page(links []*Link) []*Link {
+	if f.Offset >= len(links) {
+		return links[:0]
+	}
+
+	links = links[max(f.Offset, 0):]
+	if f.Limit > 0 && f.Limit < len(links) {
+		links = links[:f.Limit]
+	}
+
+	return links
+}
+
+// LinkStats содержит общие показатели хранилища ссылок.
+type LinkStats struct {
+	Total    int `json:"total"`    // Всего ссылок
+	Active   int `json:"active"`   // Не удаленные и не заблокированные ссылки
+	Deleted  int `json:"deleted"`  // Удаленные ссылки
+	Disabled int `json:"disabled"` // Заблокированные, но не удаленные ссылки
+	Users    int `json:"users"`    // Количество владельцев ссылок
+}
+
+// Add учитывает ссылку в показателях. Учет владельцев выполняется отдельно.
+func (s *LinkStats) Add(link *Link) {
+	s.Total++
+
+	switch {
+	case link.IsDeleted:
+		s.Deleted++
+	case link.IsDisabled:
+		s.Disabled++
+	default:
+		s.Active++
+	}
+}
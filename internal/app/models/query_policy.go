@@ -0,0 +1,23 @@
+package models
+
+// QueryPolicy определяет, как параметры запроса к короткой ссылке
+// объединяются с параметрами оригинального URL при перенаправлении.
+type QueryPolicy string
+
+const (
+	// QueryPolicyAppend добавляет входящие параметры к параметрам оригинального URL.
+	QueryPolicyAppend QueryPolicy = "append"
+	// QueryPolicyOverride заменяет одноименные параметры оригинального URL входящими.
+	QueryPolicyOverride QueryPolicy = "override"
+	// QueryPolicyDrop отбрасывает входящие параметры.
+	QueryPolicyDrop QueryPolicy = "drop"
+)
+
+// IsValid проверяет, что политика является одной из известных.
+func (p QueryPolicy) IsValid() bool {
+	switch p {
+	case QueryPolicyAppend, QueryPolicyOverride, QueryPolicyDrop:
+		return true
+	}
+	return false
+}
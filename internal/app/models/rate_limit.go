@@ -0,0 +1,46 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit задает ограничение частоты запросов по алгоритму token bucket:
+// корзина вмещает Limit токенов и полностью восполняется за Period.
+type RateLimit struct {
+	Limit  int           // Емкость корзины (допустимый всплеск запросов)
+	Period time.Duration // Время полного восполнения корзины
+}
+
+// Enabled сообщает, задано ли ограничение.
+func (l RateLimit) Enabled() bool {
+	return l.Limit > 0 && l.Period > 0
+}
+
+// Take забирает токен из корзины, в которой было tokens токенов elapsed назад.
+// Возвращает новое количество токенов и результат проверки.
+func (l RateLimit) Take(tokens float64, elapsed time.Duration) (float64, RateLimitResult) {
+	perToken := l.Period / time.Duration(l.Limit)
+
+	tokens = math.Min(float64(l.Limit), tokens+elapsed.Seconds()/perToken.Seconds())
+
+	result := RateLimitResult{Allowed: tokens >= 1}
+	if result.Allowed {
+		tokens--
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration((float64(l.Limit) - tokens) * float64(perToken))
+
+	return tokens, result
+}
+
+// RateLimitResult содержит результат проверки ограничения частоты запросов.
+type RateLimitResult struct {
+	Allowed    bool          // Запрос разрешен
+	Remaining  int           // Сколько запросов еще можно выполнить без ожидания
+	RetryAfter time.Duration // Через сколько появится следующий токен (если запрос отклонен)
+	Reset      time.Duration // Через сколько корзина восполнится полностью
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// User представляет зарегистрированного пользователя сервиса.
+type User struct {
+	ID           string    // Уникальный идентификатор пользователя (используется в JWT вместо анонимного)
+	Email        string    // Адрес электронной почты в нижнем регистре
+	PasswordHash string    // Хэш пароля (bcrypt)
+	CreatedAt    time.Time // Время регистрации
+}
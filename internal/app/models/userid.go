@@ -3,7 +3,21 @@ package models
 // userID представляет тип для идентификатора пользователя в контексте.
 type userID string
 
+// apiToken представляет тип для API-токена в контексте.
+type apiToken string
+
+// requestID представляет тип для идентификатора HTTP-запроса в контексте.
+type requestID string
+
 var (
 	// ContextUserID используется как ключ для хранения идентификатора пользователя в контексте HTTP-запроса.
 	ContextUserID userID
+
+	// ContextAPIToken используется как ключ для хранения API-токена (*APIToken), которым аутентифицирован запрос.
+	// Отсутствует, если запрос аутентифицирован через JWT.
+	ContextAPIToken apiToken
+
+	// ContextRequestID используется как ключ для хранения идентификатора HTTP-запроса.
+	// Идентификатор попадает во все записи журнала и события аудита запроса.
+	ContextRequestID requestID
 )
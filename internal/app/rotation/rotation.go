@@ -0,0 +1,115 @@
+// Package rotation содержит общие для журналов и файлов аудита правила именования,
+// упорядочивания и хранения ротированных файлов.
+package rotation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeFormat задает формат метки времени в имени ротированного файла.
+const timeFormat = "20060102T150405.000"
+
+// compressedSuffix - суффикс сжатого ротированного файла.
+const compressedSuffix = ".gz"
+
+// Name возвращает свободное имя "<path>.<время>" для файла path, ротированного в момент now.
+// Если файл с таким именем (или его сжатая копия) уже есть, к имени добавляется номер "-N".
+func Name(path string, now time.Time) string {
+	base := path + "." + now.UTC().Format(timeFormat)
+
+	name := base
+	for i := 1; exists(name) || exists(name+compressedSuffix); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return name
+}
+
+// Files возвращает ротированные файлы path, в том числе сжатые, от старых к новым.
+func Files(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if _, _, ok := order(path, match); ok {
+			files = append(files, match)
+		}
+	}
+	// Имена нельзя сравнивать как строки: "<время>-1.gz" меньше "<время>.gz", а "-10" меньше "-2"
+	slices.SortFunc(files, func(a, b string) int {
+		stampA, counterA, _ := order(path, a)
+		stampB, counterB, _ := order(path, b)
+		if c := strings.Compare(stampA, stampB); c != 0 {
+			return c
+		}
+
+		return counterA - counterB
+	})
+
+	return files, nil
+}
+
+// RemoveExpired удаляет ротированные файлы path сверх maxBackups и старше maxAge
+// (по времени изменения). Нулевые значения снимают соответствующее ограничение.
+func RemoveExpired(path string, maxBackups int, maxAge time.Duration, now time.Time) error {
+	if maxBackups <= 0 && maxAge <= 0 {
+		return nil
+	}
+
+	files, err := Files(path)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, file := range files {
+		expired := maxBackups > 0 && i < len(files)-maxBackups
+		if !expired && maxAge > 0 {
+			info, err := os.Stat(file)
+			expired = err == nil && now.Sub(info.ModTime()) > maxAge
+		}
+
+		if expired {
+			if err := os.Remove(file); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// order разбирает имя ротированного файла name на метку времени и номер среди файлов,
+// ротированных в ту же миллисекунду (0 - первый). ok равен false, если name не ротированный файл path.
+func order(path, name string) (stamp string, counter int, ok bool) {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(name, path+"."), compressedSuffix)
+	stamp, rest, found := strings.Cut(suffix, "-")
+	if _, err := time.Parse(timeFormat, stamp); err != nil {
+		return "", 0, false
+	}
+
+	if found {
+		n, err := strconv.Atoi(rest)
+		if err != nil || n <= 0 {
+			return "", 0, false
+		}
+		counter = n
+	}
+
+	return stamp, counter, true
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
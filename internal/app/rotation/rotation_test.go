@@ -0,0 +1,87 @@
+package rotation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// Файлы, ротированные в одну миллисекунду, отличаются номером после метки времени
+	want := []string{
+		path + ".20250101T000000.000.gz",
+		path + ".20250101T000000.000-1.gz",
+		path + ".20250101T000000.000-2",
+		path + ".20250101T000000.000-10.gz",
+		path + ".20250101T000001.000",
+	}
+	for _, name := range append([]string{path, path + ".bak", path + ".20250101T000000.000-x"}, want...) {
+		require.NoError(t, os.WriteFile(name, nil, 0644))
+	}
+
+	files, err := Files(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, files)
+}
+
+func TestName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Имя занято как обычным, так и сжатым файлом
+	var names []string
+	for i := range 3 {
+		name := Name(path, now)
+		names = append(names, name)
+		if i == 0 {
+			name += compressedSuffix
+		}
+		require.NoError(t, os.WriteFile(name, nil, 0644))
+	}
+
+	assert.Equal(t, []string{
+		path + ".20250101T000000.000",
+		path + ".20250101T000000.000-1",
+		path + ".20250101T000000.000-2",
+	}, names)
+}
+
+func TestRemoveExpired(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		maxBackups int
+		maxAge     time.Duration
+		want       int
+	}{
+		{name: "#1 unlimited", want: 4},
+		{name: "#2 max backups", maxBackups: 3, want: 3},
+		{name: "#3 max age", maxAge: 90 * time.Minute, want: 2},
+		{name: "#4 both", maxBackups: 1, maxAge: 90 * time.Minute, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			// Файлы ротированы раз в час, последний - в момент now
+			for i := range 4 {
+				rotated := now.Add(time.Duration(i-3) * time.Hour)
+				name := Name(path, rotated)
+				require.NoError(t, os.WriteFile(name, nil, 0644))
+				require.NoError(t, os.Chtimes(name, rotated, rotated))
+			}
+
+			require.NoError(t, RemoveExpired(path, tt.maxBackups, tt.maxAge, now))
+
+			files, err := Files(path)
+			require.NoError(t, err)
+			assert.Len(t, files, tt.want)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package shortener
+
+import (
+	"context"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// Auditor записывает события аудита.
+type Auditor interface {
+	Emit(ctx context.Context, event *audit.AuditEvent)
+}
+
+// emit передает событие в аудит, если он настроен.
+// Если операция завершилась ошибкой, событие помечается как неудачное.
+func (s *Shortener) emit(ctx context.Context, event *audit.AuditEvent, err error) {
+	if s.conf.Auditor == nil {
+		return
+	}
+
+	if err != nil {
+		event.Fail(err)
+	}
+
+	s.conf.Auditor.Emit(ctx, event)
+}
+
+// linkEvent создает событие действия action над ссылкой.
+func linkEvent(action string, link *models.Link) *audit.AuditEvent {
+	event := audit.NewAuditEvent(action, link.UserID, link.OriginalURL)
+	event.ShortCode = link.ShortCode
+
+	return event
+}
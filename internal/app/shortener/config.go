@@ -1,21 +1,34 @@
 package shortener
 
-import "github.com/sviatilnik/url-shortener/internal/app/util"
+import (
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/util"
+	"github.com/sviatilnik/url-shortener/internal/app/validators"
+)
 
 // Config представляет конфигурацию сервиса сокращения URL.
 type Config struct {
-	BaseURL string // Базовый URL для создания коротких ссылок
+	BaseURL     string               // Базовый URL для создания коротких ссылок
+	QueryPolicy models.QueryPolicy   // Глобальная политика параметров запроса при переходе по ссылке
+	Validator   validators.Validator // Проверка безопасности URL назначения (nil - только проверка формата)
+	Normalize   NormalizeOptions     // Параметры нормализации URL перед сохранением
+	Quota       QuotaOptions         // Квота на количество активных ссылок пользователя
+	Auditor     Auditor              // Получатель событий аудита (nil - аудит отключен)
+	Metrics     Metrics              // Показатели работы сервиса (nil - не учитываются)
 }
 
 // NewShortenerConfig создает новую конфигурацию сервиса сокращения URL.
 // Если переданный BaseURL невалиден, используется значение по умолчанию "http://localhost/".
+// Параметры запроса при переходе по умолчанию отбрасываются.
 func NewShortenerConfig(BaseURL string) Config {
 	if !util.IsURL(BaseURL) {
 		return Config{
-			BaseURL: "http://localhost/",
+			BaseURL:     "http://localhost/",
+			QueryPolicy: models.QueryPolicyDrop,
 		}
 	}
 	return Config{
-		BaseURL: BaseURL,
+		BaseURL:     BaseURL,
+		QueryPolicy: models.QueryPolicyDrop,
 	}
 }
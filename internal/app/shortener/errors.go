@@ -9,4 +9,8 @@ var (
 	ErrNoValidLinksInBatch = errors.New("no valid links in batch")
 	ErrNoLinksInBatch      = errors.New("no links in batch")
 	ErrLinkConflict        = errors.New("link conflict")
+	ErrInvalidQueryPolicy  = errors.New("invalid query policy")
+	ErrInvalidDestination  = errors.New("invalid destination")
+	ErrQuotaExceeded       = errors.New("link quota exceeded")
+	ErrLinkNotDeleted      = errors.New("link not found or not owned by user")
 )
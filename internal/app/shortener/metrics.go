@@ -0,0 +1,34 @@
+package shortener
+
+import "errors"
+
+// Результаты создания ссылки для показателей.
+const (
+	ShortenSuccess  = "success"  // Ссылка создана
+	ShortenConflict = "conflict" // URL уже был сокращен ранее
+	ShortenError    = "error"    // Ссылка не создана
+)
+
+// Metrics учитывает показатели работы сервиса.
+type Metrics interface {
+	// Shortened учитывает попытку создания ссылки с результатом ShortenSuccess, ShortenConflict или ShortenError.
+	Shortened(result string)
+	// Redirected учитывает переход по ссылке.
+	Redirected()
+}
+
+// countShortened передает результат создания ссылки в показатели, если они настроены.
+func (s *Shortener) countShortened(err error) {
+	if s.conf.Metrics == nil {
+		return
+	}
+
+	switch {
+	case err == nil:
+		s.conf.Metrics.Shortened(ShortenSuccess)
+	case errors.Is(err, ErrLinkConflict):
+		s.conf.Metrics.Shortened(ShortenConflict)
+	default:
+		s.conf.Metrics.Shortened(ShortenError)
+	}
+}
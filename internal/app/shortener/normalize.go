@@ -0,0 +1,215 @@
+package shortener
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// NormalizeOptions определяет параметры приведения URL к каноническому виду.
+type NormalizeOptions struct {
+	Enabled             bool     // Включить нормализацию URL перед генерацией и дедупликацией
+	SortQuery           bool     // Сортировать параметры запроса по имени
+	StripTrackingParams bool     // Удалять параметры отслеживания (utm_*, fbclid, gclid и т.д.)
+	TrackingParams      []string // Имена параметров отслеживания; "*" в конце задает префикс. Пусто - список по умолчанию
+	PreserveOriginal    bool     // Перенаправлять на URL в точности как его передал пользователь
+}
+
+// DefaultTrackingParams содержит параметры отслеживания, удаляемые по умолчанию.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gbraid", "wbraid", "yclid", "msclkid", "mc_cid", "mc_eid", "igshid", "_ga",
+}
+
+// NormalizeURL приводит URL к каноническому виду:
+//   - схема и имя хоста приводятся к нижнему регистру, завершающая точка хоста удаляется;
+//   - интернационализированные домены преобразуются в punycode;
+//   - порт по умолчанию для схемы удаляется;
+//   - процентное кодирование нормализуется: незарезервированные символы декодируются,
+//     шестнадцатеричные цифры остальных приводятся к верхнему регистру;
+//   - пустой путь заменяется на "/";
+//   - при необходимости удаляются параметры отслеживания и сортируются параметры запроса.
+func NormalizeURL(rawURL string, opts NormalizeOptions) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+
+	port := u.Port()
+	if port == defaultPort(u.Scheme) {
+		port = ""
+	}
+
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	escapedPath := normalizeEscapes(u.EscapedPath())
+	if escapedPath == "" && u.Host != "" {
+		escapedPath = "/"
+	}
+	if u.Path, err = url.PathUnescape(escapedPath); err != nil {
+		return "", err
+	}
+	u.RawPath = escapedPath
+
+	u.RawQuery = normalizeQuery(u.RawQuery, opts)
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+
+	return u.String(), nil
+}
+
+func normalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host, nil
+	}
+
+	return idna.Lookup.ToASCII(host)
+}
+
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	case "ftp":
+		return "21"
+	}
+
+	return ""
+}
+
+func normalizeQuery(rawQuery string, opts NormalizeOptions) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	type param struct {
+		key  string // Декодированное имя для сравнения и сортировки
+		pair string // Нормализованная пара ключ=значение
+	}
+
+	trackingParams := opts.TrackingParams
+	if len(trackingParams) == 0 {
+		trackingParams = DefaultTrackingParams
+	}
+
+	params := make([]param, 0)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+
+		if opts.StripTrackingParams && isTrackingParam(key, trackingParams) {
+			continue
+		}
+
+		params = append(params, param{key: key, pair: normalizeEscapes(pair)})
+	}
+
+	if opts.SortQuery {
+		sort.SliceStable(params, func(i, j int) bool {
+			return params[i].key < params[j].key
+		})
+	}
+
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		pairs = append(pairs, p.pair)
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func isTrackingParam(key string, trackingParams []string) bool {
+	key = strings.ToLower(key)
+	for _, name := range trackingParams {
+		name = strings.ToLower(name)
+		if prefix, ok := strings.CutSuffix(name, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEscapes декодирует процентные последовательности незарезервированных
+// символов (RFC 3986, раздел 2.3) и приводит остальные к верхнему регистру.
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		hi, okHi := unhex(s[i+1])
+		lo, okLo := unhex(s[i+2])
+		if !okHi || !okLo {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := hi<<4 | lo
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String()
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+
+	return 0, false
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
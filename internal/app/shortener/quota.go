@@ -0,0 +1,99 @@
+package shortener
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+// QuotaOptions задает квоту на количество активных (не удаленных) ссылок пользователя.
+type QuotaOptions struct {
+	Limit         int      // Максимальное количество активных ссылок (0 - без ограничения)
+	ExemptUserIDs []string // Пользователи, на которых квота не распространяется
+}
+
+// Quota описывает использование квоты пользователем.
+type Quota struct {
+	Limit int // Максимальное количество активных ссылок (0 - без ограничения)
+	Used  int // Количество активных ссылок
+}
+
+// Unlimited сообщает, что квота не ограничивает пользователя.
+func (q *Quota) Unlimited() bool {
+	return q.Limit <= 0
+}
+
+// Remaining возвращает, сколько ссылок пользователь еще может создать.
+func (q *Quota) Remaining() int {
+	return max(q.Limit-q.Used, 0)
+}
+
+// GetQuota возвращает использование квоты пользователем.
+func (s *Shortener) GetQuota(ctx context.Context, userID string) (_ *Quota, err error) {
+	ctx, end := trace(ctx, "GetQuota")
+	defer end(&err)
+
+	used, err := s.storage.CountUserLinks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Quota{
+		Limit: s.quotaLimit(userID),
+		Used:  used,
+	}, nil
+}
+
+// checkQuota проверяет, может ли пользователь создать ссылки links.
+// Ссылки, которые хранилище не создаст, поскольку их оригинальный URL уже сохранен,
+// квоту не расходуют: на них возвращается конфликт, а не превышение квоты.
+// Ссылки без владельца квотой не ограничиваются.
+// Проверка не атомарна: одновременные запросы могут незначительно превысить квоту.
+func (s *Shortener) checkQuota(ctx context.Context, userID string, links []*models.Link) error {
+	if userID == "" || s.quotaLimit(userID) <= 0 {
+		return nil
+	}
+
+	quota, err := s.GetQuota(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if quota.Used+len(links) <= quota.Limit {
+		return nil
+	}
+
+	count := 0
+	for _, link := range links {
+		if !s.isStored(ctx, link) {
+			count++
+		}
+	}
+
+	if quota.Used+count > quota.Limit {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// isStored сообщает, сохранен ли уже оригинальный URL ссылки, так что хранилище не создаст новую.
+// A/B-ссылки не участвуют в дедупликации.
+func (s *Shortener) isStored(ctx context.Context, link *models.Link) bool {
+	finder, ok := s.storage.(storages.OriginalURLFinder)
+	if !ok || len(link.Destinations) > 0 {
+		return false
+	}
+
+	return finder.GetByOriginalURL(ctx, link.OriginalURL) != nil
+}
+
+func (s *Shortener) quotaLimit(userID string) int {
+	if slices.Contains(s.conf.Quota.ExemptUserIDs, userID) {
+		return 0
+	}
+
+	return s.conf.Quota.Limit
+}
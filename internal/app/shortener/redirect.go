@@ -0,0 +1,104 @@
+package shortener
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// BuildRedirectURL формирует адрес перенаправления для ссылки.
+// Базовым адресом служит выбранный вариант назначения (или оригинальный URL,
+// если variant < 0). К нему добавляются UTM-метки из шаблона ссылки, после чего
+// входящие параметры запроса объединяются согласно политике ссылки
+// (или глобальной политике, если у ссылки она не задана).
+// Параметры базового адреса сохраняются в исходном виде (кроме замененных),
+// добавленные параметры дописываются в конец.
+// Если объединять нечего, базовый адрес возвращается без изменений.
+func (s *Shortener) BuildRedirectURL(link *models.Link, variant int, query url.Values) (string, error) {
+	policy := s.queryPolicy(link)
+	base := s.destinationURL(link, variant)
+
+	if len(link.UTM) == 0 && (policy == models.QueryPolicyDrop || len(query) == 0) {
+		return base, nil
+	}
+
+	target, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	added := url.Values{}
+	for key, value := range link.UTM {
+		added.Set(key, value)
+	}
+
+	switch policy {
+	case models.QueryPolicyAppend:
+		for key, items := range query {
+			for _, item := range items {
+				added.Add(key, item)
+			}
+		}
+	case models.QueryPolicyOverride:
+		for key, items := range query {
+			added[key] = items
+		}
+	}
+
+	// UTM-метки и параметры при политике override заменяют одноименные параметры базового адреса
+	replaced := make(map[string]bool, len(link.UTM)+len(query))
+	for key := range link.UTM {
+		replaced[key] = true
+	}
+	if policy == models.QueryPolicyOverride {
+		for key := range query {
+			replaced[key] = true
+		}
+	}
+
+	rawQuery := keepParams(target.RawQuery, replaced)
+	if encoded := added.Encode(); encoded != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += encoded
+	}
+	target.RawQuery = rawQuery
+
+	return target.String(), nil
+}
+
+// keepParams возвращает исходную строку запроса rawQuery без параметров с именами из replaced.
+// Оставшиеся параметры не перекодируются и не переупорядочиваются.
+func keepParams(rawQuery string, replaced map[string]bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !replaced[key] {
+			kept = append(kept, param)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func (s *Shortener) queryPolicy(link *models.Link) models.QueryPolicy {
+	if link.QueryPolicy.IsValid() {
+		return link.QueryPolicy
+	}
+
+	if s.conf.QueryPolicy.IsValid() {
+		return s.conf.QueryPolicy
+	}
+
+	return models.QueryPolicyDrop
+}
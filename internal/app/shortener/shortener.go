@@ -3,12 +3,17 @@ package shortener
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
 	"github.com/sviatilnik/url-shortener/internal/app/generators"
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
 	"github.com/sviatilnik/url-shortener/internal/app/models"
 	"github.com/sviatilnik/url-shortener/internal/app/storages"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
 	"github.com/sviatilnik/url-shortener/internal/app/util"
+	"github.com/sviatilnik/url-shortener/internal/app/validators"
 )
 
 // Shortener представляет основной сервис для сокращения URL.
@@ -34,7 +39,10 @@ func NewShortener(store storages.URLStorage, generator generators.Generator, con
 // Возможные ошибки:
 //   - ErrIDIsRequired - короткий код не указан
 //   - ErrKeyNotFound - ссылка не найдена
-func (s *Shortener) GetFullLinkByShortCode(ctx context.Context, shortCode string) (*models.Link, error) {
+func (s *Shortener) GetFullLinkByShortCode(ctx context.Context, shortCode string) (_ *models.Link, err error) {
+	ctx, end := trace(ctx, "GetFullLinkByShortCode", tracing.String("link.short_code", shortCode))
+	defer end(&err)
+
 	if strings.TrimSpace(shortCode) == "" {
 		return nil, ErrIDIsRequired
 	}
@@ -52,29 +60,99 @@ func (s *Shortener) GetFullLinkByShortCode(ctx context.Context, shortCode string
 // Возможные ошибки:
 //   - ErrInvalidURL - неверный формат URL
 //   - ErrLinkConflict - ссылка уже существует
+//   - ErrQuotaExceeded - пользователь исчерпал квоту ссылок
 //   - ErrCreateShortLink - ошибка создания ссылки
 func (s *Shortener) GenerateShortLink(ctx context.Context, url string) (string, error) {
-	if !util.IsURL(url) {
-		return "", ErrInvalidURL
+	return s.CreateLink(ctx, models.Link{OriginalURL: url})
+}
+
+// CreateLink создает короткую ссылку с дополнительными параметрами
+// (политика параметров запроса, шаблон UTM-меток, варианты назначения).
+// Если оригинальный URL не указан, им становится первый вариант назначения.
+// При включенной нормализации ссылка сохраняется и дедуплицируется по каноническому URL.
+// Возвращает полную сокращенную ссылку.
+// Возможные ошибки:
+//   - ErrInvalidURL - неверный формат URL или URL не прошел проверку безопасности
+//   - ErrInvalidQueryPolicy - неизвестная политика параметров запроса
+//   - ErrInvalidDestination - неверный URL или вес варианта назначения
+//   - ErrLinkConflict - ссылка уже существует
+//   - ErrQuotaExceeded - пользователь исчерпал квоту ссылок
+//   - ErrCreateShortLink - ошибка создания ссылки
+func (s *Shortener) CreateLink(ctx context.Context, link models.Link) (shortLink string, err error) {
+	ctx, end := trace(ctx, "CreateLink")
+	defer end(&err)
+
+	shortLink, err = s.createLink(ctx, &link)
+	s.emit(ctx, linkEvent(audit.ActionShorten, &link), err)
+	s.countShortened(err)
+
+	if err != nil {
+		logger.FromContext(ctx).Debugw("Link not created", "short_code", link.ShortCode, "error", err)
+	} else {
+		logger.FromContext(ctx).Debugw("Link created", "short_code", link.ShortCode, "user_id", link.UserID)
 	}
+
+	return shortLink, err
+}
+
+func (s *Shortener) createLink(ctx context.Context, link *models.Link) (string, error) {
 	userID := ""
 	tmpUserID := ctx.Value(models.ContextUserID)
 	if tmpUserID != nil {
 		userID = tmpUserID.(string)
 	}
+	link.UserID = userID
+
+	if err := validateDestinations(link.Destinations); err != nil {
+		return "", err
+	}
+
+	// OriginalURL A/B-ссылки не участвует в дедупликации: каждая A/B-ссылка сохраняется отдельно
+	if strings.TrimSpace(link.OriginalURL) == "" && len(link.Destinations) > 0 {
+		link.OriginalURL = link.Destinations[0].URL
+	}
+
+	if !util.IsURL(link.OriginalURL) {
+		return "", ErrInvalidURL
+	}
+
+	if err := s.normalizeLink(link); err != nil {
+		return "", err
+	}
+
+	if err := s.validateURL(ctx, link.OriginalURL); err != nil {
+		return "", err
+	}
+
+	for _, destination := range link.Destinations {
+		if err := s.validateURL(ctx, destination.URL); err != nil {
+			return "", err
+		}
+	}
+
+	if link.QueryPolicy != "" && !link.QueryPolicy.IsValid() {
+		return "", ErrInvalidQueryPolicy
+	}
+
+	// Счетчики переходов новой ссылки всегда начинаются с нуля
+	link.Destinations = append([]models.Destination(nil), link.Destinations...)
+	for i := range link.Destinations {
+		link.Destinations[i].Clicks = 0
+	}
+
+	if err := s.checkQuota(ctx, userID, []*models.Link{link}); err != nil {
+		return "", err
+	}
 
-	link := &models.Link{}
 	var saveErr error
 	var savedLink *models.Link
-	short, err := s.generator.Get(url)
+	short, err := s.generate(ctx, link.OriginalURL)
 	if err != nil {
 		return "", err
 	}
 
 	link.ID = short
 	link.ShortCode = short
-	link.OriginalURL = url
-	link.UserID = userID
 
 	savedLink, err = s.storage.Save(ctx, link)
 
@@ -95,7 +173,11 @@ func (s *Shortener) GenerateShortLink(ctx context.Context, url string) (string,
 // Возможные ошибки:
 //   - ErrNoLinksInBatch - пустой массив ссылок
 //   - ErrNoValidLinksInBatch - нет валидных URL в массиве
-func (s *Shortener) GenerateBatchShortLink(ctx context.Context, links []models.Link) ([]*models.Link, error) {
+//   - ErrQuotaExceeded - пакет превышает квоту ссылок пользователя (не сохраняется ни одна ссылка)
+func (s *Shortener) GenerateBatchShortLink(ctx context.Context, links []models.Link) (_ []*models.Link, err error) {
+	ctx, end := trace(ctx, "GenerateBatchShortLink", tracing.Int("batch.size", len(links)))
+	defer end(&err)
+
 	validLinks := make([]*models.Link, 0)
 
 	if len(links) == 0 {
@@ -104,11 +186,27 @@ func (s *Shortener) GenerateBatchShortLink(ctx context.Context, links []models.L
 
 	for _, link := range links {
 		if !util.IsURL(link.OriginalURL) {
+			s.emit(ctx, linkEvent(audit.ActionShorten, &link), ErrInvalidURL)
+			s.countShortened(ErrInvalidURL)
 			continue
 		}
 
-		short, err := s.generator.Get(link.OriginalURL)
+		if err := s.normalizeLink(&link); err != nil {
+			s.emit(ctx, linkEvent(audit.ActionShorten, &link), err)
+			s.countShortened(err)
+			continue
+		}
+
+		if err := s.validateURL(ctx, link.OriginalURL); err != nil {
+			s.emit(ctx, linkEvent(audit.ActionShorten, &link), err)
+			s.countShortened(err)
+			continue
+		}
+
+		short, err := s.generate(ctx, link.OriginalURL)
 		if err != nil {
+			s.emit(ctx, linkEvent(audit.ActionShorten, &link), err)
+			s.countShortened(err)
 			continue
 		}
 
@@ -125,10 +223,30 @@ func (s *Shortener) GenerateBatchShortLink(ctx context.Context, links []models.L
 		return nil, ErrNoValidLinksInBatch
 	}
 
-	err := s.storage.BatchSave(ctx, validLinks)
+	perUser := make(map[string][]*models.Link)
+	for _, link := range validLinks {
+		perUser[link.UserID] = append(perUser[link.UserID], link)
+	}
+	for userID, userLinks := range perUser {
+		if err = s.checkQuota(ctx, userID, userLinks); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = s.storage.BatchSave(ctx, validLinks)
+	}
+
+	for _, link := range validLinks {
+		s.emit(ctx, linkEvent(audit.ActionShorten, link), err)
+		s.countShortened(err)
+	}
+
 	if err != nil {
+		logger.FromContext(ctx).Debugw("Links batch not created", "links", len(validLinks), "error", err)
 		return nil, err
 	}
+	logger.FromContext(ctx).Debugw("Links batch created", "links", len(validLinks), "rejected", len(links)-len(validLinks))
 
 	shortBase := s.getShortBase()
 	for _, link := range validLinks {
@@ -138,6 +256,43 @@ func (s *Shortener) GenerateBatchShortLink(ctx context.Context, links []models.L
 	return validLinks, nil
 }
 
+// normalizeLink приводит оригинальный URL ссылки к каноническому виду.
+// Если настроено сохранение исходного URL, он сохраняется в RawURL для перенаправлений.
+func (s *Shortener) normalizeLink(link *models.Link) error {
+	if !s.conf.Normalize.Enabled {
+		return nil
+	}
+
+	normalized, err := NormalizeURL(link.OriginalURL, s.conf.Normalize)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if s.conf.Normalize.PreserveOriginal && normalized != link.OriginalURL {
+		link.RawURL = link.OriginalURL
+	}
+	link.OriginalURL = normalized
+
+	return nil
+}
+
+// validateURL проверяет безопасность URL назначения настроенным валидатором.
+// Ошибка валидатора оборачивается в ErrInvalidURL.
+func (s *Shortener) validateURL(ctx context.Context, rawURL string) (err error) {
+	if s.conf.Validator == nil {
+		return nil
+	}
+
+	ctx, end := trace(ctx, "validateURL")
+	defer end(&err)
+
+	if err := validators.ValidateString(ctx, s.conf.Validator, rawURL); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	return nil
+}
+
 func (s *Shortener) getShortBase() string {
 	urlBase := s.conf.BaseURL
 	return strings.TrimRight(urlBase, "/")
@@ -145,7 +300,9 @@ func (s *Shortener) getShortBase() string {
 
 // GetUserLinks получает все ссылки пользователя по его идентификатору.
 // Возвращает массив ссылок с заполненными полями ShortURL.
-func (s *Shortener) GetUserLinks(ctx context.Context, userID string) ([]*models.Link, error) {
+func (s *Shortener) GetUserLinks(ctx context.Context, userID string) (_ []*models.Link, err error) {
+	ctx, end := trace(ctx, "GetUserLinks")
+	defer end(&err)
 
 	links, err := s.storage.GetUserLinks(ctx, userID)
 	if err != nil {
@@ -161,6 +318,52 @@ func (s *Shortener) GetUserLinks(ctx context.Context, userID string) ([]*models.
 
 // DeleteUserLinks помечает указанные ссылки как удаленные (soft delete).
 // Принимает массив идентификаторов ссылок и идентификатор пользователя.
-func (s *Shortener) DeleteUserLinks(ctx context.Context, linksIDs []string, userID string) error {
-	return s.storage.Delete(ctx, linksIDs, userID)
+// Для каждой ссылки записывается событие аудита ActionDelete: успешное только для
+// ссылок, действительно помеченных как удаленные, для остальных — с ErrLinkNotDeleted.
+func (s *Shortener) DeleteUserLinks(ctx context.Context, linksIDs []string, userID string) (err error) {
+	ctx, end := trace(ctx, "DeleteUserLinks", tracing.Int("batch.size", len(linksIDs)))
+	defer end(&err)
+
+	deleted, err := s.storage.Delete(ctx, linksIDs, userID)
+	if err == nil {
+		logger.FromContext(ctx).Debugw("User links deleted", "links", len(deleted), "user_id", userID)
+	}
+
+	deletedIDs := make(map[string]struct{}, len(deleted))
+	for _, id := range deleted {
+		deletedIDs[id] = struct{}{}
+	}
+
+	for _, id := range linksIDs {
+		eventErr := err
+		if _, ok := deletedIDs[id]; !ok && eventErr == nil {
+			eventErr = ErrLinkNotDeleted
+		}
+		s.emit(ctx, linkEvent(audit.ActionDelete, &models.Link{ShortCode: id, UserID: userID}), eventErr)
+	}
+
+	return err
+}
+
+// ClaimUserLinks передает все ссылки пользователя fromUserID пользователю toUserID.
+// Используется для привязки ссылок, созданных под анонимным идентификатором, к учетной записи.
+func (s *Shortener) ClaimUserLinks(ctx context.Context, fromUserID, toUserID string) (err error) {
+	if fromUserID == "" || fromUserID == toUserID {
+		return nil
+	}
+
+	ctx, end := trace(ctx, "ClaimUserLinks")
+	defer end(&err)
+
+	err = s.storage.ReassignUserLinks(ctx, fromUserID, toUserID)
+	if err == nil {
+		logger.FromContext(ctx).Debugw("User links claimed", "from_user_id", fromUserID, "user_id", toUserID)
+	}
+
+	event := audit.NewAuditEvent(audit.ActionUpdate, toUserID, "")
+	event.Operation = audit.UpdateClaimLinks
+	event.Target = fromUserID
+	s.emit(ctx, event, err)
+
+	return err
 }
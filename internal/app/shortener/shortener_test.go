@@ -3,15 +3,19 @@ package shortener
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
 	"github.com/sviatilnik/url-shortener/internal/app/generators"
 	"github.com/sviatilnik/url-shortener/internal/app/models"
 	"github.com/sviatilnik/url-shortener/internal/app/storages"
 	"github.com/sviatilnik/url-shortener/internal/app/storages/mock_storages"
+	"github.com/sviatilnik/url-shortener/internal/app/validators"
 )
 
 func TestShortener_GetFullLinkByID(t *testing.T) {
@@ -136,3 +140,521 @@ func TestShortener_GenerateShortLink(t *testing.T) {
 		})
 	}
 }
+
+func TestShortener_BuildRedirectURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		global models.QueryPolicy
+		link   *models.Link
+		query  url.Values
+		want   string
+	}{
+		{
+			name:   "#1 drop keeps original url",
+			global: models.QueryPolicyDrop,
+			link:   &models.Link{OriginalURL: "https://example.com/a?b=1"},
+			query:  url.Values{"utm_source": {"x"}},
+			want:   "https://example.com/a?b=1",
+		},
+		{
+			name:   "#2 append",
+			global: models.QueryPolicyAppend,
+			link:   &models.Link{OriginalURL: "https://example.com/a?b=1"},
+			query:  url.Values{"b": {"2"}, "utm_source": {"x"}},
+			want:   "https://example.com/a?b=1&b=2&utm_source=x",
+		},
+		{
+			name:   "#3 override",
+			global: models.QueryPolicyAppend,
+			link:   &models.Link{OriginalURL: "https://example.com/a?b=1#top", QueryPolicy: models.QueryPolicyOverride},
+			query:  url.Values{"b": {"2"}},
+			want:   "https://example.com/a?b=2#top",
+		},
+		{
+			name:   "#4 link policy drop with utm template",
+			global: models.QueryPolicyOverride,
+			link: &models.Link{
+				OriginalURL: "https://example.com/a",
+				QueryPolicy: models.QueryPolicyDrop,
+				UTM:         map[string]string{"utm_source": "newsletter"},
+			},
+			query: url.Values{"utm_source": {"x"}},
+			want:  "https://example.com/a?utm_source=newsletter",
+		},
+		{
+			name:   "#5 incoming overrides utm template",
+			global: models.QueryPolicyOverride,
+			link: &models.Link{
+				OriginalURL: "https://example.com/a",
+				UTM:         map[string]string{"utm_source": "newsletter", "utm_medium": "email"},
+			},
+			query: url.Values{"utm_source": {"x"}},
+			want:  "https://example.com/a?utm_medium=email&utm_source=x",
+		},
+		{
+			name:   "#6 original query is kept as submitted",
+			global: models.QueryPolicyAppend,
+			link: &models.Link{
+				OriginalURL: "https://example.com/a?z=1&a=%2f&q=hello+world&flag",
+				UTM:         map[string]string{"utm_source": "newsletter"},
+			},
+			query: url.Values{"ref": {"x y"}},
+			want:  "https://example.com/a?z=1&a=%2f&q=hello+world&flag&ref=x+y&utm_source=newsletter",
+		},
+		{
+			name:   "#7 replaced params are removed from original query",
+			global: models.QueryPolicyOverride,
+			link: &models.Link{
+				OriginalURL: "https://example.com/a?z=1&utm_source=old&b=%2f",
+				UTM:         map[string]string{"utm_source": "newsletter"},
+			},
+			query: url.Values{"b": {"2"}},
+			want:  "https://example.com/a?z=1&b=2&utm_source=newsletter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := NewShortenerConfig("http://localhost:8080")
+			conf.QueryPolicy = tt.global
+			s := NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), conf)
+
+			got, err := s.BuildRedirectURL(tt.link, -1, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShortener_CreateLink(t *testing.T) {
+	storage := storages.NewInMemoryStorage()
+	s := NewShortener(storage, generators.NewRandomGenerator(10), NewShortenerConfig("http://localhost:8080"))
+
+	_, err := s.CreateLink(context.Background(), models.Link{
+		OriginalURL: "https://example.com",
+		QueryPolicy: "merge",
+	})
+	assert.ErrorIs(t, err, ErrInvalidQueryPolicy)
+
+	shortURL, err := s.CreateLink(context.Background(), models.Link{
+		OriginalURL: "https://example.com",
+		QueryPolicy: models.QueryPolicyAppend,
+		UTM:         map[string]string{"utm_source": "test"},
+	})
+	assert.NoError(t, err)
+
+	link, err := storage.Get(context.Background(), shortURL[len("http://localhost:8080/"):])
+	assert.NoError(t, err)
+	assert.Equal(t, models.QueryPolicyAppend, link.QueryPolicy)
+	assert.Equal(t, "test", link.UTM["utm_source"])
+}
+
+func TestShortener_SelectVariant(t *testing.T) {
+	s := NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), NewShortenerConfig(""))
+
+	link := &models.Link{
+		OriginalURL: "https://example.com/a",
+		Destinations: []models.Destination{
+			{URL: "https://example.com/a", Weight: 0},
+			{URL: "https://example.com/b", Weight: 1},
+		},
+	}
+	evenLink := &models.Link{
+		OriginalURL: "https://example.com/a",
+		Destinations: []models.Destination{
+			{URL: "https://example.com/a"},
+			{URL: "https://example.com/b"},
+			{URL: "https://example.com/c"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		link   *models.Link
+		sticky string
+		want   int
+	}{
+		{
+			name: "#1 no destinations",
+			link: &models.Link{OriginalURL: "https://example.com"},
+			want: -1,
+		},
+		{
+			name:   "#2 zero weight variant is never chosen",
+			link:   link,
+			sticky: "",
+			want:   1,
+		},
+		{
+			name:   "#3 sticky variant with zero weight is reassigned",
+			link:   link,
+			sticky: "0",
+			want:   1,
+		},
+		{
+			name:   "#4 invalid sticky value",
+			link:   link,
+			sticky: "7",
+			want:   1,
+		},
+		{
+			name:   "#5 sticky variant of even split is kept",
+			link:   evenLink,
+			sticky: "2",
+			want:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.SelectVariant(tt.link, tt.sticky))
+		})
+	}
+
+	link.Destinations[0].Weight = 1
+	assert.Equal(t, 0, s.SelectVariant(link, "0"))
+}
+
+func TestShortener_RegisterFollow(t *testing.T) {
+	storage := storages.NewInMemoryStorage()
+	s := NewShortener(storage, generators.NewRandomGenerator(10), NewShortenerConfig("http://localhost:8080"))
+
+	shortURL, err := s.CreateLink(context.Background(), models.Link{
+		Destinations: []models.Destination{
+			{URL: "https://example.com/a", Weight: 1, Clicks: 100},
+			{URL: "https://example.com/b", Weight: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	link, err := s.GetFullLinkByShortCode(context.Background(), shortURL[len("http://localhost:8080/"):])
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", link.OriginalURL)
+
+	assert.NoError(t, s.RegisterFollow(context.Background(), link, 1))
+	assert.NoError(t, s.RegisterFollow(context.Background(), link, 1))
+	assert.NoError(t, s.RegisterFollow(context.Background(), link, -1))
+
+	link, err = s.GetFullLinkByShortCode(context.Background(), link.ShortCode)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), link.Destinations[0].Clicks)
+	assert.Equal(t, int64(2), link.Destinations[1].Clicks)
+
+	target, err := s.BuildRedirectURL(link, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/b", target)
+
+	_, err = s.CreateLink(context.Background(), models.Link{
+		Destinations: []models.Destination{{URL: "not a url", Weight: 1}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidDestination)
+}
+
+func TestShortener_Validator(t *testing.T) {
+	conf := NewShortenerConfig("http://short.ly")
+	conf.Validator = validators.NewChain(
+		validators.NewSchemeValidator("http", "https"),
+		validators.NewNetworkValidator(nil),
+		validators.NewLoopValidator(conf.BaseURL),
+	)
+	s := NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), conf)
+
+	_, err := s.GenerateShortLink(context.Background(), "http://127.0.0.1/admin")
+	assert.ErrorIs(t, err, ErrInvalidURL)
+	assert.ErrorIs(t, err, validators.ErrPrivateNetwork)
+
+	_, err = s.GenerateShortLink(context.Background(), "https://short.ly/abc")
+	assert.ErrorIs(t, err, validators.ErrRedirectLoop)
+
+	_, err = s.CreateLink(context.Background(), models.Link{
+		Destinations: []models.Destination{
+			{URL: "https://example.com", Weight: 1},
+			{URL: "http://localhost/", Weight: 1},
+		},
+	})
+	assert.ErrorIs(t, err, validators.ErrPrivateNetwork)
+
+	links, err := s.GenerateBatchShortLink(context.Background(), []models.Link{
+		{ID: "1", OriginalURL: "https://example.com"},
+		{ID: "2", OriginalURL: "ftp://example.com"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "1", links[0].ID)
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		opts NormalizeOptions
+		want string
+	}{
+		{
+			name: "#1 host case and default port",
+			url:  "HTTPS://Example.COM:443/Path",
+			want: "https://example.com/Path",
+		},
+		{
+			name: "#2 empty path",
+			url:  "http://example.com:8080",
+			want: "http://example.com:8080/",
+		},
+		{
+			name: "#3 idn host",
+			url:  "https://пример.рф/",
+			want: "https://xn--e1afmkfd.xn--p1ai/",
+		},
+		{
+			name: "#4 percent-encoding",
+			url:  "https://example.com/%7euser/a%2fb?q=%3d%41",
+			want: "https://example.com/~user/a%2Fb?q=%3DA",
+		},
+		{
+			name: "#5 sorted query",
+			url:  "https://example.com/a?b=1&a=2&a=1",
+			opts: NormalizeOptions{SortQuery: true},
+			want: "https://example.com/a?a=2&a=1&b=1",
+		},
+		{
+			name: "#6 tracking params",
+			url:  "https://example.com/a?utm_source=x&id=1&fbclid=abc",
+			opts: NormalizeOptions{StripTrackingParams: true},
+			want: "https://example.com/a?id=1",
+		},
+		{
+			name: "#7 custom tracking params",
+			url:  "https://example.com/a?ref=x&utm_source=y",
+			opts: NormalizeOptions{StripTrackingParams: true, TrackingParams: []string{"ref"}},
+			want: "https://example.com/a?utm_source=y",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.url, tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShortener_CreateLink_Normalize(t *testing.T) {
+	storage := storages.NewInMemoryStorage()
+	conf := NewShortenerConfig("http://localhost:8080")
+	conf.Normalize = NormalizeOptions{Enabled: true, SortQuery: true, PreserveOriginal: true}
+	s := NewShortener(storage, generators.NewRandomGenerator(10), conf)
+
+	shortURL, err := s.CreateLink(context.Background(), models.Link{OriginalURL: "https://Example.com/a?b=1&a=2"})
+	assert.NoError(t, err)
+
+	link, err := storage.Get(context.Background(), shortURL[len("http://localhost:8080/"):])
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/a?a=2&b=1", link.OriginalURL)
+	assert.Equal(t, "https://Example.com/a?b=1&a=2", link.RawURL)
+
+	redirectURL, err := s.BuildRedirectURL(link, -1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://Example.com/a?b=1&a=2", redirectURL)
+
+	// Дедупликация выполняется хранилищем по каноническому URL
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorage := mock_storages.NewMockURLStorage(ctrl)
+	mockStorage.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, l *models.Link) (*models.Link, error) {
+			assert.Equal(t, "https://example.com/a?a=2&b=1", l.OriginalURL)
+			return link, storages.ErrOriginalURLAlreadyExists
+		})
+
+	s = NewShortener(mockStorage, generators.NewRandomGenerator(10), conf)
+	conflictURL, err := s.CreateLink(context.Background(), models.Link{OriginalURL: "https://example.com:443/a?a=2&b=1"})
+	assert.ErrorIs(t, err, ErrLinkConflict)
+	assert.Equal(t, shortURL, conflictURL)
+}
+
+func TestShortener_Quota(t *testing.T) {
+	conf := NewShortenerConfig("http://localhost/")
+	conf.Quota = QuotaOptions{Limit: 2, ExemptUserIDs: []string{"vip"}}
+	s := NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), conf)
+
+	userCtx := context.WithValue(context.Background(), models.ContextUserID, "user")
+
+	first, err := s.GenerateShortLink(userCtx, "https://example.com/1")
+	assert.NoError(t, err)
+	_, err = s.GenerateShortLink(userCtx, "https://example.com/2")
+	assert.NoError(t, err)
+
+	_, err = s.GenerateShortLink(userCtx, "https://example.com/3")
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	_, err = s.GenerateBatchShortLink(userCtx, []models.Link{{OriginalURL: "https://example.com/4", UserID: "user"}})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	quota, err := s.GetQuota(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, &Quota{Limit: 2, Used: 2}, quota)
+	assert.Equal(t, 0, quota.Remaining())
+
+	// Удаленные ссылки не учитываются в квоте
+	firstURL, err := url.Parse(first)
+	assert.NoError(t, err)
+	assert.NoError(t, s.DeleteUserLinks(context.Background(), []string{firstURL.Path[1:]}, "user"))
+
+	_, err = s.GenerateShortLink(userCtx, "https://example.com/5")
+	assert.NoError(t, err)
+
+	// Пакет, превышающий квоту, не сохраняется целиком
+	otherCtx := context.WithValue(context.Background(), models.ContextUserID, "other")
+	_, err = s.GenerateBatchShortLink(otherCtx, []models.Link{
+		{OriginalURL: "https://example.com/6", UserID: "other"},
+		{OriginalURL: "https://example.com/7", UserID: "other"},
+		{OriginalURL: "https://example.com/8", UserID: "other"},
+	})
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	quota, err = s.GetQuota(context.Background(), "other")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, quota.Used)
+
+	// На пользователей-исключений квота не распространяется
+	vipCtx := context.WithValue(context.Background(), models.ContextUserID, "vip")
+	for i := 0; i < 3; i++ {
+		_, err = s.GenerateShortLink(vipCtx, fmt.Sprintf("https://example.com/vip/%d", i))
+		assert.NoError(t, err)
+	}
+
+	quota, err = s.GetQuota(context.Background(), "vip")
+	assert.NoError(t, err)
+	assert.True(t, quota.Unlimited())
+	assert.Equal(t, 3, quota.Used)
+}
+
+// dedupStorage, как хранилище PostgreSQL, не создает повторную ссылку на сохраненный оригинальный URL.
+type dedupStorage struct {
+	storages.URLStorage
+	byURL map[string]*models.Link
+}
+
+func (d *dedupStorage) Save(ctx context.Context, link *models.Link) (*models.Link, error) {
+	if existing := d.GetByOriginalURL(ctx, link.OriginalURL); existing != nil && len(link.Destinations) == 0 {
+		return existing, storages.ErrOriginalURLAlreadyExists
+	}
+
+	saved, err := d.URLStorage.Save(ctx, link)
+	if err == nil && len(link.Destinations) == 0 {
+		d.byURL[link.OriginalURL] = saved
+	}
+
+	return saved, err
+}
+
+func (d *dedupStorage) GetByOriginalURL(_ context.Context, originalURL string) *models.Link {
+	return d.byURL[originalURL]
+}
+
+func TestShortener_QuotaConflict(t *testing.T) {
+	conf := NewShortenerConfig("http://localhost/")
+	conf.Quota = QuotaOptions{Limit: 1}
+	s := NewShortener(&dedupStorage{URLStorage: storages.NewInMemoryStorage(), byURL: map[string]*models.Link{}}, generators.NewRandomGenerator(10), conf)
+
+	userCtx := context.WithValue(context.Background(), models.ContextUserID, "user")
+	shortURL, err := s.GenerateShortLink(userCtx, "https://example.com/1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		link    models.Link
+		wantErr error
+	}{
+		{name: "#1 existing URL is a conflict", link: models.Link{OriginalURL: "https://example.com/1"}, wantErr: ErrLinkConflict},
+		{name: "#2 new URL exceeds quota", link: models.Link{OriginalURL: "https://example.com/2"}, wantErr: ErrQuotaExceeded},
+		{
+			name:    "#3 A/B link is never deduplicated",
+			link:    models.Link{Destinations: []models.Destination{{URL: "https://example.com/1", Weight: 1}}},
+			wantErr: ErrQuotaExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.CreateLink(userCtx, tt.link)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == ErrLinkConflict {
+				assert.Equal(t, shortURL, got)
+			}
+		})
+	}
+
+	// Пакет из уже сохраненных URL не расходует квоту
+	_, err = s.GenerateBatchShortLink(userCtx, []models.Link{{OriginalURL: "https://example.com/1", UserID: "user"}})
+	assert.NotErrorIs(t, err, ErrQuotaExceeded)
+}
+
+type fakeAuditor struct {
+	events []*audit.AuditEvent
+}
+
+func (f *fakeAuditor) Emit(_ context.Context, event *audit.AuditEvent) {
+	f.events = append(f.events, event)
+}
+
+func TestShortener_Audit(t *testing.T) {
+	auditor := &fakeAuditor{}
+	conf := NewShortenerConfig("http://localhost/")
+	conf.Auditor = auditor
+	s := NewShortener(storages.NewInMemoryStorage(), generators.NewRandomGenerator(10), conf)
+
+	userCtx := context.WithValue(context.Background(), models.ContextUserID, "user")
+
+	short, err := s.GenerateShortLink(userCtx, "https://example.com/1")
+	assert.NoError(t, err)
+	shortURL, err := url.Parse(short)
+	assert.NoError(t, err)
+	shortCode := shortURL.Path[1:]
+
+	_, err = s.GenerateShortLink(userCtx, "not a url")
+	assert.ErrorIs(t, err, ErrInvalidURL)
+
+	link, err := s.GetFullLinkByShortCode(context.Background(), shortCode)
+	assert.NoError(t, err)
+	visitorCtx := context.WithValue(context.Background(), models.ContextUserID, "visitor")
+	assert.NoError(t, s.RegisterFollow(visitorCtx, link, -1))
+
+	assert.NoError(t, s.ClaimUserLinks(userCtx, "user", "account"))
+	// Ссылка уже принадлежит другому пользователю и не удаляется
+	assert.NoError(t, s.DeleteUserLinks(userCtx, []string{shortCode}, "user"))
+	assert.NoError(t, s.DeleteUserLinks(userCtx, []string{shortCode, "missing"}, "account"))
+
+	tests := []struct {
+		action    string
+		userID    string
+		shortCode string
+		result    string
+	}{
+		{action: audit.ActionShorten, userID: "user", shortCode: shortCode, result: audit.ResultSuccess},
+		{action: audit.ActionShorten, userID: "user", result: audit.ResultFailure},
+		{action: audit.ActionFollow, userID: "visitor", shortCode: shortCode, result: audit.ResultSuccess},
+		{action: audit.ActionUpdate, userID: "account", result: audit.ResultSuccess},
+		{action: audit.ActionDelete, userID: "user", shortCode: shortCode, result: audit.ResultFailure},
+		{action: audit.ActionDelete, userID: "account", shortCode: shortCode, result: audit.ResultSuccess},
+		{action: audit.ActionDelete, userID: "account", shortCode: "missing", result: audit.ResultFailure},
+	}
+
+	assert.Len(t, auditor.events, len(tests))
+	for i, tt := range tests {
+		if i >= len(auditor.events) {
+			break
+		}
+
+		event := auditor.events[i]
+		assert.Equal(t, tt.action, event.Action, "#%d", i+1)
+		assert.Equal(t, tt.userID, event.UserID, "#%d", i+1)
+		assert.Equal(t, tt.shortCode, event.ShortCode, "#%d", i+1)
+		assert.Equal(t, tt.result, event.Result, "#%d", i+1)
+	}
+
+	assert.Equal(t, ErrInvalidURL.Error(), auditor.events[1].Error)
+	assert.Equal(t, "https://example.com/1", auditor.events[2].URL)
+	assert.Equal(t, audit.UpdateClaimLinks, auditor.events[3].Operation)
+	assert.Equal(t, "user", auditor.events[3].Target)
+	assert.Equal(t, ErrLinkNotDeleted.Error(), auditor.events[4].Error)
+	assert.Equal(t, ErrLinkNotDeleted.Error(), auditor.events[6].Error)
+}
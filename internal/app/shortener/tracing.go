@@ -0,0 +1,26 @@
+package shortener
+
+import (
+	"context"
+
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+// trace начинает span метода сервиса, если запрос трассируется.
+// Возвращаемая функция завершает span с результатом *err и вызывается через defer.
+func trace(ctx context.Context, method string, attributes ...tracing.Attribute) (context.Context, func(err *error)) {
+	ctx, span := tracing.Start(ctx, "Shortener."+method, attributes...)
+
+	return ctx, func(err *error) {
+		span.RecordError(*err)
+		span.End()
+	}
+}
+
+// generate получает короткий код у генератора в отдельном span.
+func (s *Shortener) generate(ctx context.Context, originalURL string) (short string, err error) {
+	_, end := trace(ctx, "generate")
+	defer end(&err)
+
+	return s.generator.Get(originalURL)
+}
@@ -0,0 +1,88 @@
+package shortener
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+	"github.com/sviatilnik/url-shortener/internal/app/util"
+)
+
+// SelectVariant выбирает вариант назначения ссылки для посетителя.
+// Если sticky содержит номер ранее назначенного варианта и он по-прежнему
+// доступен, возвращается он; иначе вариант выбирается случайно пропорционально весам.
+// Если веса не заданы, доступны все варианты, иначе - только варианты с весом больше нуля.
+// Для ссылок без вариантов назначения возвращает -1.
+func (s *Shortener) SelectVariant(link *models.Link, sticky string) int {
+	if len(link.Destinations) == 0 {
+		return -1
+	}
+
+	total := 0
+	for _, destination := range link.Destinations {
+		total += destination.Weight
+	}
+
+	if variant, err := strconv.Atoi(sticky); err == nil &&
+		variant >= 0 && variant < len(link.Destinations) && (total <= 0 || link.Destinations[variant].Weight > 0) {
+		return variant
+	}
+
+	// Если веса не заданы, распределяем трафик равномерно
+	if total <= 0 {
+		return rand.Intn(len(link.Destinations))
+	}
+
+	point := rand.Intn(total)
+	for i, destination := range link.Destinations {
+		if point < destination.Weight {
+			return i
+		}
+		point -= destination.Weight
+	}
+
+	return len(link.Destinations) - 1
+}
+
+// RegisterFollow учитывает переход по ссылке.
+// Для ссылок с вариантами назначения увеличивает счетчик переходов выбранного варианта.
+// Переход записывается в аудит как событие ActionFollow от имени посетителя.
+func (s *Shortener) RegisterFollow(ctx context.Context, link *models.Link, variant int) (err error) {
+	ctx, end := trace(ctx, "RegisterFollow", tracing.String("link.short_code", link.ShortCode), tracing.Int("link.variant", variant))
+	defer end(&err)
+
+	visitorID, _ := ctx.Value(models.ContextUserID).(string)
+	event := linkEvent(audit.ActionFollow, link)
+	event.UserID = visitorID
+	s.emit(ctx, event, nil)
+	if s.conf.Metrics != nil {
+		s.conf.Metrics.Redirected()
+	}
+
+	if variant < 0 {
+		return nil
+	}
+
+	return s.storage.IncrementVariantClicks(ctx, link.ShortCode, variant)
+}
+
+func validateDestinations(destinations []models.Destination) error {
+	for _, destination := range destinations {
+		if !util.IsURL(destination.URL) || destination.Weight < 0 {
+			return ErrInvalidDestination
+		}
+	}
+
+	return nil
+}
+
+func (s *Shortener) destinationURL(link *models.Link, variant int) string {
+	if variant >= 0 && variant < len(link.Destinations) {
+		return link.Destinations[variant].URL
+	}
+
+	return link.SubmittedURL()
+}
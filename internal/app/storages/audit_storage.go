@@ -0,0 +1,34 @@
+package storages
+
+import (
+	"context"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+)
+
+// AuditStorage определяет интерфейс для хранения событий аудита с возможностью поиска.
+type AuditStorage interface {
+	// SaveEvents сохраняет события. События с уже сохраненным идентификатором пропускаются.
+	SaveEvents(ctx context.Context, events []*audit.AuditEvent) error
+
+	// SearchEvents ищет события по условиям запроса, от новых к старым.
+	SearchEvents(ctx context.Context, query audit.Query) ([]*audit.AuditEvent, error)
+}
+
+// AuditRetention ограничивает объем хранилища событий аудита.
+// При превышении ограничений удаляются самые старые события.
+type AuditRetention struct {
+	MaxEvents int           // Максимальное количество хранимых событий (0 - без ограничения)
+	MaxAge    time.Duration // Максимальный возраст хранимых событий (0 - без ограничения)
+}
+
+// expired сообщает, превысило ли событие с меткой времени timestamp (в миллисекундах) допустимый возраст.
+func (r AuditRetention) expired(timestamp int64, now time.Time) bool {
+	return r.MaxAge > 0 && timestamp < now.Add(-r.MaxAge).UnixMilli()
+}
+
+// full сообщает, достигло ли хранилище с count событиями максимального количества событий.
+func (r AuditRetention) full(count int) bool {
+	return r.MaxEvents > 0 && count >= r.MaxEvents
+}
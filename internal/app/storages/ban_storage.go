@@ -0,0 +1,24 @@
+package storages
+
+import (
+	"context"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// BanStorage определяет интерфейс для хранения блокировок пользователей.
+type BanStorage interface {
+	// BanUser сохраняет блокировку пользователя. Повторная блокировка заменяет предыдущую.
+	BanUser(ctx context.Context, ban *models.Ban) error
+
+	// UnbanUser снимает блокировку пользователя.
+	// Возвращает ErrKeyNotFound, если пользователь не заблокирован.
+	UnbanUser(ctx context.Context, userID string) error
+
+	// GetBan получает блокировку пользователя.
+	// Возвращает ErrKeyNotFound, если пользователь не заблокирован.
+	GetBan(ctx context.Context, userID string) (*models.Ban, error)
+
+	// CountBans возвращает количество заблокированных пользователей.
+	CountBans(ctx context.Context) (int, error)
+}
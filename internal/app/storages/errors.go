@@ -8,4 +8,6 @@ var (
 	ErrOriginalURLAlreadyExists = errors.New("original url already exists")
 	ErrBatchIsEmpty             = errors.New("batch is empty")
 	ErrNotImplemented           = errors.New("not implemented")
+	ErrUserAlreadyExists        = errors.New("user already exists")
+	ErrMigrationsNotApplied     = errors.New("storage migrations are not applied")
 )
@@ -212,13 +212,13 @@ func ExampleInMemoryStorage_Delete() {
 
 	// Удаляем первые две ссылки
 	idsToDelete := []string{"to_delete1", "to_delete2"}
-	err := storage.Delete(ctx, idsToDelete, "user123")
+	deleted, err := storage.Delete(ctx, idsToDelete, "user123")
 	if err != nil {
 		fmt.Printf("Error deleting links: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Successfully deleted %d links\n", len(idsToDelete))
+	fmt.Printf("Successfully deleted %d links\n", len(deleted))
 
 	// Проверяем, что операция удаления завершена
 	fmt.Printf("Delete operation completed\n")
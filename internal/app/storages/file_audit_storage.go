@@ -0,0 +1,499 @@
+package storages
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+)
+
+// maxAuditLineSize ограничивает длину одной записи в файле событий аудита.
+const maxAuditLineSize = 1 << 20
+
+// FileAuditStorage представляет хранилище событий аудита в файле.
+// События дописываются в конец файла JSON-строками. В памяти хранится только индекс:
+// смещение записи в файле и поля, по которым выполняется поиск. Событие целиком
+// читается из файла, только если оно попадает в результат поиска.
+// Удаленные по AuditRetention события исключаются из индекса, а файл периодически
+// перезаписывается без них.
+type FileAuditStorage struct {
+	filePath  string
+	retention AuditRetention
+	now       func() time.Time
+
+	loaded  bool
+	partial bool                     // Последняя строка файла не завершена переводом строки
+	size    int64                    // Размер файла: смещение следующей записи
+	stale   int                      // Количество записей файла, отсутствующих в индексе
+	entries []*auditEntry            // Записи индекса в порядке сохранения
+	ids     map[string]struct{}      // Идентификаторы событий в индексе
+	byKey   map[string][]*auditEntry // Записи по пользователю, действию и короткому коду
+
+	mu sync.Mutex
+}
+
+// auditEntry описывает событие в индексе файлового хранилища.
+type auditEntry struct {
+	offset    int64
+	length    int
+	id        string
+	timestamp int64
+	userID    string
+	action    string
+	shortCode string
+}
+
+// keys возвращает ключи вторичного индекса для записи.
+func (e *auditEntry) keys() []string {
+	keys := []string{"action:" + e.action}
+	if e.userID != "" {
+		keys = append(keys, "user:"+e.userID)
+	}
+	if e.shortCode != "" {
+		keys = append(keys, "short:"+e.shortCode)
+	}
+
+	return keys
+}
+
+// match проверяет поля записи, хранящиеся в индексе. Подстрока URL проверяется по событию.
+func (e *auditEntry) match(query audit.Query) bool {
+	return (query.UserID == "" || e.userID == query.UserID) &&
+		(query.Action == "" || e.action == query.Action) &&
+		(query.ShortCode == "" || e.shortCode == query.ShortCode) &&
+		(query.From.IsZero() || e.timestamp >= query.From.UnixMilli()) &&
+		(query.To.IsZero() || e.timestamp < query.To.UnixMilli())
+}
+
+// NewFileAuditStorage создает хранилище событий аудита в указанном файле.
+func NewFileAuditStorage(filePath string, retention AuditRetention) AuditStorage {
+	return &FileAuditStorage{
+		filePath:  filePath,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (f *FileAuditStorage) SaveEvents(ctx context.Context, events []*audit.AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := f.append(events); err != nil {
+		// Событие, не записанное в файл, не должно считаться сохраненным,
+		// иначе повторная доставка его пропустит
+		f.loaded = false
+		return err
+	}
+
+	f.prune()
+
+	if f.stale > 0 && f.stale >= len(f.entries) {
+		if err := f.compact(); err != nil {
+			f.loaded = false
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *FileAuditStorage) SearchEvents(ctx context.Context, query audit.Query) ([]*audit.AuditEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	// Кандидаты берутся из самого короткого подходящего списка индекса
+	candidates := f.entries
+	for _, key := range []string{"user:" + query.UserID, "action:" + query.Action, "short:" + query.ShortCode} {
+		if key[len(key)-1] == ':' {
+			continue
+		}
+		if list := f.byKey[key]; len(list) < len(candidates) {
+			candidates = list
+		}
+	}
+
+	now := f.now()
+	matched := make([]*auditEntry, 0)
+	for j := len(candidates) - 1; j >= 0; j-- {
+		if entry := candidates[j]; entry.match(query) && !f.retention.expired(entry.timestamp, now) {
+			matched = append(matched, entry)
+		}
+	}
+
+	// События с одинаковым временем остаются в обратном порядке сохранения
+	sort.SliceStable(matched, func(a, b int) bool {
+		return matched[a].timestamp > matched[b].timestamp
+	})
+
+	// Без условия на URL страница определяется по индексу
+	if query.URL == "" {
+		matched = pageEntries(matched, query.Limit, query.Offset)
+	}
+
+	if len(matched) == 0 {
+		return []*audit.AuditEvent{}, nil
+	}
+
+	file, err := os.Open(f.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events := make([]*audit.AuditEvent, 0, len(matched))
+	for _, entry := range matched {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		event, err := readAuditEntry(file, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if query.Match(event) {
+			events = append(events, event)
+		}
+	}
+
+	if query.URL != "" {
+		return query.Page(events), nil
+	}
+
+	return events, nil
+}
+
+// load однократно строит индекс по файлу. Отсутствующий файл означает пустое хранилище.
+func (f *FileAuditStorage) load() error {
+	if f.loaded {
+		return nil
+	}
+
+	f.size = 0
+	f.stale = 0
+	f.partial = false
+	f.entries = nil
+	f.ids = make(map[string]struct{})
+	f.byKey = make(map[string][]*auditEntry)
+
+	file, err := os.Open(f.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		f.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := readAuditLine(reader)
+		if len(line) > 0 {
+			f.indexLine(line, f.size)
+			f.size += int64(len(line))
+			f.partial = line[len(line)-1] != '\n'
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	f.prune()
+	f.loaded = true
+
+	return nil
+}
+
+// indexLine добавляет в индекс событие из строки файла, записанной по смещению offset.
+// Некорректные записи и повторы учитываются как устаревшие.
+func (f *FileAuditStorage) indexLine(line []byte, offset int64) {
+	event := &audit.AuditEvent{}
+	if err := json.Unmarshal(bytes.TrimSpace(line), event); err != nil || event.ID == "" {
+		if len(bytes.TrimSpace(line)) > 0 {
+			f.stale++
+		}
+		return
+	}
+
+	if _, ok := f.ids[event.ID]; ok {
+		f.stale++
+		return
+	}
+
+	f.addEntry(&auditEntry{
+		offset:    offset,
+		length:    len(line),
+		id:        event.ID,
+		timestamp: event.Timestamp,
+		userID:    event.UserID,
+		action:    event.Action,
+		shortCode: event.ShortCode,
+	})
+}
+
+func (f *FileAuditStorage) addEntry(entry *auditEntry) {
+	f.entries = append(f.entries, entry)
+	f.ids[entry.id] = struct{}{}
+	for _, key := range entry.keys() {
+		f.byKey[key] = append(f.byKey[key], entry)
+	}
+}
+
+// append дописывает в конец файла события, которых еще нет в хранилище, и добавляет их в индекс.
+func (f *FileAuditStorage) append(events []*audit.AuditEvent) error {
+	now := f.now()
+
+	var buf bytes.Buffer
+	if f.partial {
+		// Недописанная строка завершается, чтобы новая запись начиналась с начала строки
+		buf.WriteByte('\n')
+	}
+
+	var added []*auditEntry
+	for _, event := range events {
+		if event.ID == "" || f.retention.expired(event.Timestamp, now) {
+			continue
+		}
+		if _, ok := f.ids[event.ID]; ok {
+			continue
+		}
+		if f.retention.full(len(f.entries)+len(added)) && event.Timestamp < f.oldest(added).timestamp {
+			continue // Событие было бы удалено первым
+		}
+
+		marshal, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		entry := &auditEntry{
+			offset:    f.size + int64(buf.Len()),
+			length:    len(marshal) + 1,
+			id:        event.ID,
+			timestamp: event.Timestamp,
+			userID:    event.UserID,
+			action:    event.Action,
+			shortCode: event.ShortCode,
+		}
+		buf.Write(marshal)
+		buf.WriteByte('\n')
+
+		// Повтор внутри одного пакета тоже пропускается
+		f.ids[entry.id] = struct{}{}
+		added = append(added, entry)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	f.size += int64(buf.Len())
+	f.partial = false
+	for _, entry := range added {
+		f.addEntry(entry)
+	}
+
+	return nil
+}
+
+// oldest возвращает первую по порядку сохранения запись индекса с учетом еще не добавленных записей added.
+func (f *FileAuditStorage) oldest(added []*auditEntry) *auditEntry {
+	if len(f.entries) > 0 {
+		return f.entries[0]
+	}
+
+	return added[0]
+}
+
+// prune исключает из индекса самые старые события согласно ограничениям хранилища.
+func (f *FileAuditStorage) prune() {
+	now := f.now()
+
+	drop := 0
+	for drop < len(f.entries) && f.retention.expired(f.entries[drop].timestamp, now) {
+		drop++
+	}
+	if f.retention.MaxEvents > 0 && len(f.entries)-drop > f.retention.MaxEvents {
+		drop = len(f.entries) - f.retention.MaxEvents
+	}
+
+	// Записи вторичного индекса упорядочены так же, поэтому удаляемая запись всегда первая в списке
+	for j := 0; j < drop; j++ {
+		entry := f.entries[j]
+		delete(f.ids, entry.id)
+		for _, key := range entry.keys() {
+			if list := f.byKey[key]; len(list) <= 1 {
+				delete(f.byKey, key)
+			} else {
+				list[0] = nil
+				f.byKey[key] = list[1:]
+			}
+		}
+		f.entries[j] = nil
+	}
+
+	f.entries = f.entries[drop:]
+	f.stale += drop
+}
+
+// compact атомарно перезаписывает файл, оставляя только события из индекса.
+func (f *FileAuditStorage) compact() error {
+	file, err := os.Open(f.filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tempFile, err := os.CreateTemp(filepath.Dir(f.filePath), "audit_events_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+	offsets := make([]int64, len(f.entries))
+	var size int64
+	for j, entry := range f.entries {
+		line, err := readAuditAt(file, entry)
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+		if line[len(line)-1] != '\n' {
+			line = append(line, '\n')
+			entry.length = len(line)
+		}
+
+		if _, err = writer.Write(line); err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		offsets[j] = size
+		size += int64(entry.length)
+	}
+
+	if err = writer.Flush(); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tempFile.Name(), f.filePath); err != nil {
+		return err
+	}
+
+	for j, entry := range f.entries {
+		entry.offset = offsets[j]
+	}
+	f.size = size
+	f.stale = 0
+	f.partial = false
+
+	return nil
+}
+
+// pageEntries возвращает часть записей индекса согласно limit и offset.
+func pageEntries(entries []*auditEntry, limit, offset int) []*auditEntry {
+	if offset >= len(entries) {
+		return entries[:0]
+	}
+
+	entries = entries[max(offset, 0):]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
+
+// readAuditLine читает из reader одну строку вместе с переводом строки.
+// В конце файла возвращает прочитанный остаток и io.EOF.
+func readAuditLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			if len(line) > maxAuditLineSize {
+				return nil, bufio.ErrTooLong
+			}
+			continue
+		}
+
+		return line, err
+	}
+}
+
+// readAuditAt читает строку записи индекса из файла.
+func readAuditAt(file *os.File, entry *auditEntry) ([]byte, error) {
+	line := make([]byte, entry.length)
+	n, err := file.ReadAt(line, entry.offset)
+	if n == len(line) {
+		return line, nil
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return nil, err
+}
+
+// readAuditEntry читает событие записи индекса из файла.
+func readAuditEntry(file *os.File, entry *auditEntry) (*audit.AuditEvent, error) {
+	line, err := readAuditAt(file, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &audit.AuditEvent{}
+	if err = json.Unmarshal(bytes.TrimSpace(line), event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
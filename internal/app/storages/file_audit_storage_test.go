@@ -0,0 +1,141 @@
+package storages
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+)
+
+func TestFileAuditStorage(t *testing.T) {
+	filePath := t.TempDir() + "/audit_events"
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	event := func(id, action, userID, url string, at time.Time) *audit.AuditEvent {
+		e := audit.NewAuditEvent(action, userID, url)
+		e.ID = id
+		e.Timestamp = at.UnixMilli()
+		return e
+	}
+	events := []*audit.AuditEvent{
+		event("1", audit.ActionShorten, "user", "http://example.com/a", start),
+		event("2", audit.ActionFollow, "", "http://example.com/a", start.Add(time.Minute)),
+		event("3", audit.ActionShorten, "other", "http://example.org/b", start.Add(2*time.Minute)),
+	}
+
+	f := NewFileAuditStorage(filePath, AuditRetention{})
+	require.NoError(t, f.SaveEvents(context.Background(), events))
+	// Повторная доставка не создает дубликатов
+	require.NoError(t, f.SaveEvents(context.Background(), events[:1]))
+
+	// Некорректные строки пропускаются при загрузке
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString("{broken\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	// Новый экземпляр читает события из файла
+	f = NewFileAuditStorage(filePath, AuditRetention{})
+
+	tests := []struct {
+		name    string
+		query   audit.Query
+		wantIDs []string
+	}{
+		{name: "#1 all", query: audit.Query{}, wantIDs: []string{"3", "2", "1"}},
+		{name: "#2 user", query: audit.Query{UserID: "user"}, wantIDs: []string{"1"}},
+		{name: "#3 action", query: audit.Query{Action: audit.ActionShorten}, wantIDs: []string{"3", "1"}},
+		{name: "#4 url", query: audit.Query{URL: "EXAMPLE.COM"}, wantIDs: []string{"2", "1"}},
+		{name: "#5 period", query: audit.Query{From: start.Add(time.Minute), To: start.Add(2 * time.Minute)}, wantIDs: []string{"2"}},
+		{name: "#6 page", query: audit.Query{Limit: 1, Offset: 1}, wantIDs: []string{"2"}},
+		{name: "#7 offset beyond end", query: audit.Query{Offset: 10}, wantIDs: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.SearchEvents(context.Background(), tt.query)
+			require.NoError(t, err)
+
+			ids := make([]string, 0, len(got))
+			for _, e := range got {
+				ids = append(ids, e.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestAuditStorage_Retention(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	event := func(id string, age time.Duration) *audit.AuditEvent {
+		e := audit.NewAuditEvent(audit.ActionShorten, "user", "http://example.com/"+id)
+		e.ID = id
+		e.Timestamp = now.Add(-age).UnixMilli()
+		return e
+	}
+
+	filePath := t.TempDir() + "/audit_events"
+	retention := AuditRetention{MaxEvents: 2, MaxAge: time.Hour}
+
+	memory := NewInMemoryAuditStorage(retention).(*InMemoryAuditStorage)
+	memory.now = func() time.Time { return now }
+	file := NewFileAuditStorage(filePath, retention).(*FileAuditStorage)
+	file.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		events  []*audit.AuditEvent
+		wantIDs []string
+	}{
+		{name: "#1 expired event is not stored", events: []*audit.AuditEvent{event("1", 2*time.Hour), event("2", 30*time.Minute)}, wantIDs: []string{"2"}},
+		{name: "#2 oldest events are dropped", events: []*audit.AuditEvent{event("3", 20*time.Minute), event("4", 10*time.Minute)}, wantIDs: []string{"4", "3"}},
+		{name: "#3 redelivered dropped event is not stored again", events: []*audit.AuditEvent{event("2", 30*time.Minute)}, wantIDs: []string{"4", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, storage := range []AuditStorage{memory, file} {
+				require.NoError(t, storage.SaveEvents(context.Background(), tt.events))
+
+				got, err := storage.SearchEvents(context.Background(), audit.Query{})
+				require.NoError(t, err)
+
+				ids := make([]string, 0, len(got))
+				for _, e := range got {
+					ids = append(ids, e.ID)
+				}
+				assert.Equal(t, tt.wantIDs, ids)
+			}
+		})
+	}
+
+	// Событие старше допустимого возраста не возвращается, даже если еще не удалено
+	now = now.Add(45 * time.Minute)
+	for _, storage := range []AuditStorage{memory, file} {
+		got, err := storage.SearchEvents(context.Background(), audit.Query{})
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "4", got[0].ID)
+	}
+
+	// Когда удаленных записей становится больше, чем хранимых, файл перезаписывается без них
+	require.NoError(t, file.SaveEvents(context.Background(), []*audit.AuditEvent{event("5", 0), event("6", 0)}))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	// Новый экземпляр строит индекс по перезаписанному файлу
+	reopened := NewFileAuditStorage(filePath, retention).(*FileAuditStorage)
+	reopened.now = func() time.Time { return now }
+	got, err := reopened.SearchEvents(context.Background(), audit.Query{Action: audit.ActionShorten, UserID: "user", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "http://example.com/6", got[0].URL)
+}
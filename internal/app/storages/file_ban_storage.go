@@ -0,0 +1,175 @@
+package storages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// FileBanStorage представляет хранилище блокировок пользователей в файле.
+// Блокировки хранятся JSON-строками; при первом обращении файл загружается в память,
+// а после каждого изменения атомарно перезаписывается целиком.
+type FileBanStorage struct {
+	filePath string
+	loaded   bool
+	bans     *InMemoryBanStorage
+	mu       sync.Mutex
+}
+
+type banStoreItem struct {
+	UserID    string    `json:"user_id"`
+	Reason    string    `json:"reason,omitempty"`
+	BannedBy  string    `json:"banned_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewFileBanStorage создает хранилище блокировок в указанном файле.
+func NewFileBanStorage(filePath string) BanStorage {
+	return &FileBanStorage{
+		filePath: filePath,
+		bans:     NewInMemoryBanStorage().(*InMemoryBanStorage),
+	}
+}
+
+func (f *FileBanStorage) BanUser(ctx context.Context, ban *models.Ban) error {
+	return f.update(ctx, func() error {
+		return f.bans.BanUser(ctx, ban)
+	})
+}
+
+func (f *FileBanStorage) UnbanUser(ctx context.Context, userID string) error {
+	return f.update(ctx, func() error {
+		return f.bans.UnbanUser(ctx, userID)
+	})
+}
+
+func (f *FileBanStorage) GetBan(ctx context.Context, userID string) (*models.Ban, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return f.bans.GetBan(ctx, userID)
+}
+
+func (f *FileBanStorage) CountBans(ctx context.Context) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return 0, err
+	}
+
+	return f.bans.CountBans(ctx)
+}
+
+// update применяет изменение к блокировкам в памяти и сохраняет их в файл.
+func (f *FileBanStorage) update(ctx context.Context, change func() error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	return f.save()
+}
+
+// load однократно читает блокировки из файла в память.
+// Отсутствующий файл означает пустое хранилище.
+func (f *FileBanStorage) load(ctx context.Context) error {
+	if f.loaded {
+		return nil
+	}
+
+	file, err := os.Open(f.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		f.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		row := strings.TrimSpace(scanner.Text())
+		if row == "" {
+			continue
+		}
+
+		item := &banStoreItem{}
+		if err = json.Unmarshal([]byte(row), item); err != nil {
+			continue // Пропускаем некорректные записи
+		}
+
+		err = f.bans.BanUser(ctx, &models.Ban{
+			UserID:    item.UserID,
+			Reason:    item.Reason,
+			BannedBy:  item.BannedBy,
+			CreatedAt: item.CreatedAt,
+		})
+		if err != nil && !errors.Is(err, ErrEmptyKey) {
+			return err
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	f.loaded = true
+	return nil
+}
+
+// save атомарно перезаписывает файл текущим набором блокировок.
+func (f *FileBanStorage) save() error {
+	tempFile, err := os.CreateTemp(filepath.Dir(f.filePath), "url_shortener_bans_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+	for _, ban := range f.bans.snapshot() {
+		marshal, err := json.Marshal(banStoreItem{
+			UserID:    ban.UserID,
+			Reason:    ban.Reason,
+			BannedBy:  ban.BannedBy,
+			CreatedAt: ban.CreatedAt,
+		})
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		writer.Write(marshal)
+		writer.WriteByte('\n')
+	}
+
+	if err = writer.Flush(); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), f.filePath)
+}
@@ -4,27 +4,70 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/sviatilnik/url-shortener/internal/app/models"
 )
 
+// FileStorage хранит ссылки в файле по одной JSON-записи на строку.
+// Счетчики переходов вариантов назначения накапливаются в памяти и записываются
+// в файл при вызове FlushClicks, чтобы переход по ссылке не перезаписывал файл.
 type FileStorage struct {
-	filePath   string
-	lastUUID   int
-	mut        sync.RWMutex
-	cache      map[string]*models.Link
-	cacheMutex sync.RWMutex
+	filePath    string
+	lastUUID    int
+	mut         sync.RWMutex
+	cache       map[string]*models.Link
+	cacheMutex  sync.RWMutex
+	clicks      map[string]map[int]int64
+	clicksMutex sync.Mutex
 }
 
 type storeItem struct {
-	UUID        string `json:"uuid"`
-	Short       string `json:"short"`
-	OriginalURL string `json:"original_url"`
-	UserID      string `json:"user_id"`
-	IsDeleted   bool   `json:"is_deleted"`
+	UUID         string               `json:"uuid"`
+	Short        string               `json:"short"`
+	OriginalURL  string               `json:"original_url"`
+	RawURL       string               `json:"raw_url,omitempty"`
+	UserID       string               `json:"user_id"`
+	IsDeleted    bool                 `json:"is_deleted"`
+	IsDisabled   bool                 `json:"is_disabled,omitempty"`
+	QueryPolicy  string               `json:"query_policy,omitempty"`
+	UTM          map[string]string    `json:"utm,omitempty"`
+	Destinations []models.Destination `json:"destinations,omitempty"`
+}
+
+func newStoreItem(link *models.Link) *storeItem {
+	return &storeItem{
+		OriginalURL:  link.OriginalURL,
+		RawURL:       link.RawURL,
+		Short:        link.ShortCode,
+		UUID:         link.ID,
+		UserID:       link.UserID,
+		IsDeleted:    link.IsDeleted,
+		IsDisabled:   link.IsDisabled,
+		QueryPolicy:  string(link.QueryPolicy),
+		UTM:          link.UTM,
+		Destinations: link.Destinations,
+	}
+}
+
+func (item *storeItem) toLink() *models.Link {
+	return &models.Link{
+		ID:           item.UUID,
+		ShortCode:    item.Short,
+		OriginalURL:  item.OriginalURL,
+		RawURL:       item.RawURL,
+		UserID:       item.UserID,
+		IsDeleted:    item.IsDeleted,
+		IsDisabled:   item.IsDisabled,
+		QueryPolicy:  models.QueryPolicy(item.QueryPolicy),
+		UTM:          item.UTM,
+		Destinations: item.Destinations,
+	}
 }
 
 func NewFileStorage(filePath string) *FileStorage {
@@ -32,6 +75,7 @@ func NewFileStorage(filePath string) *FileStorage {
 		filePath: filePath,
 		lastUUID: 0,
 		cache:    make(map[string]*models.Link),
+		clicks:   make(map[string]map[int]int64),
 	}
 }
 
@@ -50,13 +94,7 @@ func (f *FileStorage) Save(ctx context.Context, link *models.Link) (*models.Link
 		f.mut.Lock()
 		defer f.mut.Unlock()
 
-		item := &storeItem{
-			OriginalURL: link.OriginalURL,
-			Short:       link.ShortCode,
-			UUID:        link.ID,
-			UserID:      link.UserID,
-			IsDeleted:   link.IsDeleted,
-		}
+		item := newStoreItem(link)
 
 		marshal, err := json.Marshal(item)
 		if err != nil {
@@ -139,13 +177,7 @@ func (f *FileStorage) Get(ctx context.Context, shortCode string) (*models.Link,
 					return nil, ErrKeyNotFound
 				}
 
-				link := &models.Link{
-					ID:          item.UUID,
-					ShortCode:   item.Short,
-					OriginalURL: item.OriginalURL,
-					UserID:      item.UserID,
-					IsDeleted:   item.IsDeleted,
-				}
+				link := item.toLink()
 
 				// Добавляем в кэш
 				f.cacheMutex.Lock()
@@ -177,14 +209,7 @@ func (f *FileStorage) GetUserLinks(ctx context.Context, userID string) ([]*model
 		for _, link := range f.cache {
 			if link.UserID == userID && !link.IsDeleted {
 				// Создаем копию ссылки
-				linkCopy := &models.Link{
-					ID:          link.ID,
-					ShortCode:   link.ShortCode,
-					ShortURL:    link.ShortURL,
-					OriginalURL: link.OriginalURL,
-					UserID:      link.UserID,
-					IsDeleted:   link.IsDeleted,
-				}
+				linkCopy := link.Clone()
 				userLinks = append(userLinks, linkCopy)
 			}
 		}
@@ -217,13 +242,7 @@ func (f *FileStorage) GetUserLinks(ctx context.Context, userID string) ([]*model
 				f.cacheMutex.RUnlock()
 
 				if !exists {
-					link := &models.Link{
-						ID:          item.UUID,
-						ShortCode:   item.Short,
-						OriginalURL: item.OriginalURL,
-						UserID:      item.UserID,
-						IsDeleted:   item.IsDeleted,
-					}
+					link := item.toLink()
 					userLinks = append(userLinks, link)
 				}
 			}
@@ -238,13 +257,14 @@ func (f *FileStorage) GetUserLinks(ctx context.Context, userID string) ([]*model
 	}
 }
 
-func (f *FileStorage) Delete(ctx context.Context, IDs []string, userID string) error {
+func (f *FileStorage) Delete(ctx context.Context, IDs []string, userID string) ([]string, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
+		var deleted []string
 		if len(IDs) == 0 {
-			return nil
+			return deleted, nil
 		}
 
 		// Создаем множество ID для быстрого поиска
@@ -262,67 +282,362 @@ func (f *FileStorage) Delete(ctx context.Context, IDs []string, userID string) e
 		}
 		f.cacheMutex.Unlock()
 
-		// Читаем все записи из файла
-		file, err := os.Open(f.filePath)
+		// Помечаем как удаленные ссылки, принадлежащие пользователю
+		err := f.rewrite(func(item *storeItem) {
+			if idsToDelete[item.UUID] && item.UserID == userID && !item.IsDeleted {
+				item.IsDeleted = true
+				deleted = append(deleted, item.UUID)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return deleted, nil
+	}
+}
+
+// IncrementVariantClicks увеличивает счетчик перехода в кэше и в памяти.
+// В файл счетчик попадает при следующем вызове FlushClicks.
+func (f *FileStorage) IncrementVariantClicks(ctx context.Context, shortCode string, variant int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if variant < 0 {
+			return ErrKeyNotFound
+		}
+
+		// Get добавляет найденную ссылку в кэш
+		link, err := f.Get(ctx, shortCode)
 		if err != nil {
-			// Если файл не существует, возвращаем nil
+			return err
+		}
+		if link == nil {
+			return ErrKeyNotFound
+		}
+
+		f.cacheMutex.Lock()
+		if variant >= len(link.Destinations) {
+			f.cacheMutex.Unlock()
+			return ErrKeyNotFound
+		}
+		link.Destinations[variant].Clicks++
+		f.cacheMutex.Unlock()
+
+		f.clicksMutex.Lock()
+		if f.clicks[shortCode] == nil {
+			f.clicks[shortCode] = make(map[int]int64)
+		}
+		f.clicks[shortCode][variant]++
+		f.clicksMutex.Unlock()
+
+		return nil
+	}
+}
+
+// FlushClicks записывает накопленные счетчики переходов в файл одной перезаписью.
+// При ошибке записи счетчики остаются в памяти до следующего вызова.
+func (f *FileStorage) FlushClicks(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		f.mut.Lock()
+		defer f.mut.Unlock()
+
+		f.clicksMutex.Lock()
+		clicks := f.clicks
+		f.clicks = make(map[string]map[int]int64)
+		f.clicksMutex.Unlock()
+
+		if len(clicks) == 0 {
 			return nil
 		}
-		defer file.Close()
 
-		var items []*storeItem
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			row := scanner.Text()
-			row = strings.Trim(row, "\n")
+		err := f.rewriteLocked(func(item *storeItem) {
+			addClicks(item, clicks[item.Short])
+		})
+		if err != nil {
+			f.clicksMutex.Lock()
+			for shortCode, variants := range clicks {
+				if f.clicks[shortCode] == nil {
+					f.clicks[shortCode] = make(map[int]int64)
+				}
+				for variant, count := range variants {
+					f.clicks[shortCode][variant] += count
+				}
+			}
+			f.clicksMutex.Unlock()
+		}
 
-			item := &storeItem{}
-			err = json.Unmarshal([]byte(row), item)
-			if err != nil {
-				continue // Пропускаем некорректные записи
+		return err
+	}
+}
+
+// addClicks прибавляет накопленные счетчики к вариантам назначения записи.
+func addClicks(item *storeItem, variants map[int]int64) {
+	for variant, count := range variants {
+		if variant < len(item.Destinations) {
+			item.Destinations[variant].Clicks += count
+		}
+	}
+}
+
+func (f *FileStorage) ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if strings.TrimSpace(fromUserID) == "" {
+			return ErrEmptyKey
+		}
+
+		f.cacheMutex.Lock()
+		for _, link := range f.cache {
+			if link.UserID == fromUserID {
+				link.UserID = toUserID
 			}
+		}
+		f.cacheMutex.Unlock()
 
-			// Помечаем как удаленные ссылки, принадлежащие пользователю
-			if idsToDelete[item.UUID] && item.UserID == userID {
-				item.IsDeleted = true
+		return f.rewrite(func(item *storeItem) {
+			if item.UserID == fromUserID {
+				item.UserID = toUserID
 			}
+		})
+	}
+}
 
-			items = append(items, item)
+func (f *FileStorage) SearchLinks(ctx context.Context, filter models.LinkFilter) ([]*models.Link, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		items, err := f.readItems()
+		if err != nil {
+			return nil, err
 		}
 
-		err = scanner.Err()
-		if err != nil {
-			return err
+		links := make([]*models.Link, 0)
+		for _, item := range items {
+			if link := item.toLink(); filter.Match(link) {
+				links = append(links, link)
+			}
 		}
 
-		// Перезаписываем файл с обновленными данными
-		tempFile, err := os.CreateTemp("", "url_shortener_*.tmp")
+		sort.Slice(links, func(a, b int) bool {
+			return links[a].ID < links[b].ID
+		})
+
+		return filter.Page(links), nil
+	}
+}
+
+func (f *FileStorage) SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) error {
+	return f.updateLink(ctx, shortCode, func(link *models.Link) {
+		link.IsDisabled = disabled
+	}, func(item *storeItem) {
+		item.IsDisabled = disabled
+	})
+}
+
+func (f *FileStorage) DeleteLink(ctx context.Context, shortCode string) error {
+	return f.updateLink(ctx, shortCode, func(link *models.Link) {
+		link.IsDeleted = true
+	}, func(item *storeItem) {
+		item.IsDeleted = true
+	})
+}
+
+func (f *FileStorage) CountUserLinks(ctx context.Context, userID string) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		items, err := f.readItems()
 		if err != nil {
-			return err
+			return 0, err
 		}
-		defer os.Remove(tempFile.Name())
 
+		count := 0
 		for _, item := range items {
-			marshal, err := json.Marshal(item)
-			if err != nil {
-				tempFile.Close()
-				return err
+			if item.UserID == userID && !item.IsDeleted {
+				count++
 			}
+		}
 
-			if _, err = tempFile.Write(marshal); err != nil {
-				tempFile.Close()
-				return err
+		return count, nil
+	}
+}
+
+func (f *FileStorage) Stats(ctx context.Context) (*models.LinkStats, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		items, err := f.readItems()
+		if err != nil {
+			return nil, err
+		}
+
+		stats := &models.LinkStats{}
+		users := make(map[string]struct{})
+		for _, item := range items {
+			stats.Add(item.toLink())
+			if item.UserID != "" {
+				users[item.UserID] = struct{}{}
 			}
+		}
+		stats.Users = len(users)
 
-			if _, err = tempFile.WriteString("\n"); err != nil {
-				tempFile.Close()
-				return err
+		return stats, nil
+	}
+}
+
+// updateLink изменяет ссылку с указанным коротким кодом в кэше и в файле.
+func (f *FileStorage) updateLink(ctx context.Context, shortCode string, updateCached func(link *models.Link), updateItem func(item *storeItem)) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		f.cacheMutex.Lock()
+		if link, exists := f.cache[shortCode]; exists {
+			updateCached(link)
+		}
+		f.cacheMutex.Unlock()
+
+		found := false
+		err := f.rewrite(func(item *storeItem) {
+			if item.Short == shortCode {
+				updateItem(item)
+				found = true
 			}
+		})
+		if err != nil {
+			return err
 		}
 
-		tempFile.Close()
+		if !found {
+			return ErrKeyNotFound
+		}
 
-		// Атомарно заменяем оригинальный файл
-		return os.Rename(tempFile.Name(), f.filePath)
+		return nil
 	}
 }
+
+// readItems читает все записи файла. Отсутствующий файл означает пустое хранилище.
+func (f *FileStorage) readItems() ([]*storeItem, error) {
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+
+	file, err := os.Open(f.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var items []*storeItem
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		item := &storeItem{}
+		if err = json.Unmarshal(scanner.Bytes(), item); err != nil {
+			continue // Пропускаем некорректные записи
+		}
+
+		items = append(items, item)
+	}
+
+	// Учитываем счетчики переходов, еще не записанные в файл
+	f.clicksMutex.Lock()
+	for _, item := range items {
+		addClicks(item, f.clicks[item.Short])
+	}
+	f.clicksMutex.Unlock()
+
+	return items, scanner.Err()
+}
+
+// rewrite применяет update ко всем записям файла и атомарно перезаписывает файл.
+func (f *FileStorage) rewrite(update func(item *storeItem)) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	return f.rewriteLocked(update)
+}
+
+// rewriteLocked выполняет rewrite; вызывающий должен удерживать f.mut.
+func (f *FileStorage) rewriteLocked(update func(item *storeItem)) error {
+	// Читаем все записи из файла
+	file, err := os.Open(f.filePath)
+	if err != nil {
+		// Если файл не существует, возвращаем nil
+		return nil
+	}
+	defer file.Close()
+
+	var items []*storeItem
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		row := scanner.Text()
+		row = strings.Trim(row, "\n")
+
+		item := &storeItem{}
+		err = json.Unmarshal([]byte(row), item)
+		if err != nil {
+			continue // Пропускаем некорректные записи
+		}
+
+		update(item)
+
+		items = append(items, item)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+
+	// Перезаписываем файл с обновленными данными
+	tempFile, err := os.CreateTemp(filepath.Dir(f.filePath), "url_shortener_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	for _, item := range items {
+		marshal, err := json.Marshal(item)
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		if _, err = tempFile.Write(marshal); err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		if _, err = tempFile.WriteString("\n"); err != nil {
+			tempFile.Close()
+			return err
+		}
+	}
+
+	tempFile.Close()
+
+	// Атомарно заменяем оригинальный файл
+	return os.Rename(tempFile.Name(), f.filePath)
+}
+
+// CheckHealth проверяет, что файл хранилища можно открыть для записи.
+func (f *FileStorage) CheckHealth(_ context.Context) error {
+	file, err := os.OpenFile(f.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
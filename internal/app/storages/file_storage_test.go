@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sviatilnik/url-shortener/internal/app/models"
 )
@@ -189,3 +190,117 @@ func TestFileStorage_Save(t *testing.T) {
 		os.Remove(file.Name())
 	})
 }
+
+func TestFileStorage_IncrementVariantClicks(t *testing.T) {
+	filePath := t.TempDir() + "/storage"
+
+	f := NewFileStorage(filePath)
+	_, err := f.Save(context.Background(), &models.Link{
+		ID:          "1",
+		ShortCode:   "short_code",
+		OriginalURL: "https://example.com/a",
+		RawURL:      "https://EXAMPLE.com/a",
+		Destinations: []models.Destination{
+			{URL: "https://example.com/a", Weight: 1},
+			{URL: "https://example.com/b", Weight: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	before, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	assert.NoError(t, f.IncrementVariantClicks(context.Background(), "short_code", 1))
+	assert.NoError(t, f.IncrementVariantClicks(context.Background(), "short_code", 1))
+	assert.ErrorIs(t, f.IncrementVariantClicks(context.Background(), "short_code", 2), ErrKeyNotFound)
+	assert.ErrorIs(t, f.IncrementVariantClicks(context.Background(), "unknown", 0), ErrKeyNotFound)
+
+	// Переходы не перезаписывают файл, но сразу видны через этот экземпляр
+	after, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	link, err := f.Get(context.Background(), "short_code")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), link.Destinations[1].Clicks)
+
+	links, err := f.SearchLinks(context.Background(), models.LinkFilter{})
+	assert.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, int64(2), links[0].Destinations[1].Clicks)
+
+	require.NoError(t, f.FlushClicks(context.Background()))
+	require.NoError(t, f.FlushClicks(context.Background()))
+
+	// Новый экземпляр читает данные из файла, минуя кэш
+	link, err = NewFileStorage(filePath).Get(context.Background(), "short_code")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://EXAMPLE.com/a", link.RawURL)
+	assert.Equal(t, int64(0), link.Destinations[0].Clicks)
+	assert.Equal(t, int64(2), link.Destinations[1].Clicks)
+}
+
+func TestFileStorage_AdminOperations(t *testing.T) {
+	ctx := context.Background()
+	filePath := t.TempDir() + "/storage"
+
+	f := NewFileStorage(filePath)
+	assert.NoError(t, f.BatchSave(ctx, []*models.Link{
+		{ID: "1", ShortCode: "one", OriginalURL: "https://spam.example.com/a", UserID: "spammer"},
+		{ID: "2", ShortCode: "two", OriginalURL: "https://example.org/b", UserID: "user"},
+	}))
+
+	assert.NoError(t, f.SetLinkDisabled(ctx, "one", true))
+	assert.NoError(t, f.DeleteLink(ctx, "two"))
+	assert.ErrorIs(t, f.SetLinkDisabled(ctx, "unknown", true), ErrKeyNotFound)
+	assert.ErrorIs(t, f.DeleteLink(ctx, "unknown"), ErrKeyNotFound)
+
+	// Кэш обновляется вместе с файлом
+	link, err := f.Get(ctx, "one")
+	assert.NoError(t, err)
+	assert.True(t, link.IsDisabled)
+
+	// Новый экземпляр читает данные из файла, минуя кэш
+	reopened := NewFileStorage(filePath)
+
+	found, err := reopened.SearchLinks(ctx, models.LinkFilter{Domain: "example.com"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "one", found[0].ShortCode)
+	assert.True(t, found[0].IsDisabled)
+
+	found, err = reopened.SearchLinks(ctx, models.LinkFilter{UserID: "user"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.True(t, found[0].IsDeleted)
+
+	stats, err := reopened.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.LinkStats{Total: 2, Deleted: 1, Disabled: 1, Users: 2}, stats)
+}
+
+func TestFileStorage_CheckHealth(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filePath string
+		wantErr  bool
+	}{
+		{name: "#1 writable file", filePath: dir + "/links.jsonl", wantErr: false},
+		{name: "#2 missing directory", filePath: dir + "/missing/links.jsonl", wantErr: true},
+		{name: "#3 directory instead of file", filePath: dir, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewObservedStorage(NewFileStorage(tt.filePath), "file", nil)
+			err := storage.(HealthChecker).CheckHealth(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, storage.(MigrationChecker).CheckMigrations(context.Background()))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package storages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// FileTokenStorage представляет хранилище API-токенов в файле.
+// Токены хранятся JSON-строками; при первом обращении файл загружается в память,
+// а после каждого изменения атомарно перезаписывается целиком.
+type FileTokenStorage struct {
+	filePath string
+	loaded   bool
+	tokens   *InMemoryTokenStorage
+	mu       sync.Mutex
+}
+
+type tokenStoreItem struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	Hash       string    `json:"hash"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// NewFileTokenStorage создает хранилище API-токенов в указанном файле.
+func NewFileTokenStorage(filePath string) TokenStorage {
+	return &FileTokenStorage{
+		filePath: filePath,
+		tokens:   NewInMemoryTokenStorage().(*InMemoryTokenStorage),
+	}
+}
+
+func (f *FileTokenStorage) CreateToken(ctx context.Context, token *models.APIToken) error {
+	return f.update(ctx, func() error {
+		return f.tokens.CreateToken(ctx, token)
+	})
+}
+
+func (f *FileTokenStorage) GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return f.tokens.GetTokenByHash(ctx, hash)
+}
+
+func (f *FileTokenStorage) GetUserTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return f.tokens.GetUserTokens(ctx, userID)
+}
+
+func (f *FileTokenStorage) RevokeToken(ctx context.Context, id, userID string) error {
+	return f.update(ctx, func() error {
+		return f.tokens.RevokeToken(ctx, id, userID)
+	})
+}
+
+func (f *FileTokenStorage) TouchToken(ctx context.Context, id string, lastUsedAt time.Time) error {
+	return f.update(ctx, func() error {
+		return f.tokens.TouchToken(ctx, id, lastUsedAt)
+	})
+}
+
+// update применяет изменение к токенам в памяти и сохраняет их в файл.
+func (f *FileTokenStorage) update(ctx context.Context, change func() error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	return f.save()
+}
+
+// load однократно читает токены из файла в память.
+// Отсутствующий файл означает пустое хранилище.
+func (f *FileTokenStorage) load(ctx context.Context) error {
+	if f.loaded {
+		return nil
+	}
+
+	file, err := os.Open(f.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		f.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		row := strings.TrimSpace(scanner.Text())
+		if row == "" {
+			continue
+		}
+
+		item := &tokenStoreItem{}
+		if err = json.Unmarshal([]byte(row), item); err != nil {
+			continue // Пропускаем некорректные записи
+		}
+
+		err = f.tokens.CreateToken(ctx, &models.APIToken{
+			ID:         item.ID,
+			UserID:     item.UserID,
+			Name:       item.Name,
+			Hash:       item.Hash,
+			Scopes:     item.Scopes,
+			CreatedAt:  item.CreatedAt,
+			LastUsedAt: item.LastUsedAt,
+		})
+		if err != nil && !errors.Is(err, ErrEmptyKey) {
+			return err
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	f.loaded = true
+	return nil
+}
+
+// save атомарно перезаписывает файл текущим набором токенов.
+func (f *FileTokenStorage) save() error {
+	tempFile, err := os.CreateTemp(filepath.Dir(f.filePath), "url_shortener_tokens_*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+	for _, token := range f.tokens.snapshot() {
+		marshal, err := json.Marshal(tokenStoreItem{
+			ID:         token.ID,
+			UserID:     token.UserID,
+			Name:       token.Name,
+			Hash:       token.Hash,
+			Scopes:     token.Scopes,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+		})
+		if err != nil {
+			tempFile.Close()
+			return err
+		}
+
+		writer.Write(marshal)
+		writer.WriteByte('\n')
+	}
+
+	if err = writer.Flush(); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), f.filePath)
+}
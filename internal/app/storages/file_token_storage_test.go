@@ -0,0 +1,53 @@
+package storages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+func TestFileTokenStorage(t *testing.T) {
+	filePath := t.TempDir() + "/tokens"
+	createdAt := time.Now().UTC().Truncate(time.Second)
+
+	f := NewFileTokenStorage(filePath)
+	require.NoError(t, f.CreateToken(context.Background(), &models.APIToken{
+		ID:        "1",
+		UserID:    "user",
+		Name:      "ci",
+		Hash:      "hash1",
+		Scopes:    []string{models.ScopeLinksRead},
+		CreatedAt: createdAt,
+	}))
+	require.NoError(t, f.CreateToken(context.Background(), &models.APIToken{
+		ID:        "2",
+		UserID:    "user",
+		Name:      "deploy",
+		Hash:      "hash2",
+		Scopes:    []string{models.ScopeLinksWrite},
+		CreatedAt: createdAt.Add(time.Second),
+	}))
+	require.NoError(t, f.TouchToken(context.Background(), "1", createdAt.Add(time.Minute)))
+	require.NoError(t, f.RevokeToken(context.Background(), "2", "user"))
+	assert.ErrorIs(t, f.RevokeToken(context.Background(), "1", "other"), ErrKeyNotFound)
+
+	// Новый экземпляр читает токены из файла
+	f = NewFileTokenStorage(filePath)
+
+	token, err := f.GetTokenByHash(context.Background(), "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", token.Name)
+	assert.Equal(t, createdAt.Add(time.Minute), token.LastUsedAt)
+
+	_, err = f.GetTokenByHash(context.Background(), "hash2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	tokens, err := f.GetUserTokens(context.Background(), "user")
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
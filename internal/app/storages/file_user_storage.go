@@ -0,0 +1,141 @@
+package storages
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// FileUserStorage представляет хранилище пользователей в файле.
+// Каждый пользователь хранится отдельной JSON-строкой; при первом обращении
+// файл целиком загружается в память.
+type FileUserStorage struct {
+	filePath string
+	loaded   bool
+	users    *InMemoryUserStorage
+	mu       sync.Mutex
+}
+
+type userStoreItem struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewFileUserStorage создает хранилище пользователей в указанном файле.
+func NewFileUserStorage(filePath string) UserStorage {
+	return &FileUserStorage{
+		filePath: filePath,
+		users:    NewInMemoryUserStorage().(*InMemoryUserStorage),
+	}
+}
+
+func (f *FileUserStorage) CreateUser(ctx context.Context, user *models.User) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return err
+	}
+
+	if err := f.users.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	marshal, err := json.Marshal(userStoreItem{
+		ID:           user.ID,
+		Email:        user.Email,
+		PasswordHash: user.PasswordHash,
+		CreatedAt:    user.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(marshal, '\n'))
+	return err
+}
+
+func (f *FileUserStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return f.users.GetUserByEmail(ctx, email)
+}
+
+func (f *FileUserStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return f.users.GetUserByID(ctx, id)
+}
+
+// load однократно читает пользователей из файла в память.
+// Отсутствующий файл означает пустое хранилище.
+func (f *FileUserStorage) load(ctx context.Context) error {
+	if f.loaded {
+		return nil
+	}
+
+	file, err := os.Open(f.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		f.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		row := strings.TrimSpace(scanner.Text())
+		if row == "" {
+			continue
+		}
+
+		item := &userStoreItem{}
+		if err = json.Unmarshal([]byte(row), item); err != nil {
+			continue // Пропускаем некорректные записи
+		}
+
+		err = f.users.CreateUser(ctx, &models.User{
+			ID:           item.ID,
+			Email:        item.Email,
+			PasswordHash: item.PasswordHash,
+			CreatedAt:    item.CreatedAt,
+		})
+		if err != nil && !errors.Is(err, ErrUserAlreadyExists) && !errors.Is(err, ErrEmptyKey) {
+			return err
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	f.loaded = true
+	return nil
+}
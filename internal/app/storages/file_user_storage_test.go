@@ -0,0 +1,41 @@
+package storages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+func TestFileUserStorage(t *testing.T) {
+	filePath := t.TempDir() + "/users"
+
+	f := NewFileUserStorage(filePath)
+	user := &models.User{
+		ID:           "1",
+		Email:        "user@example.com",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	require.NoError(t, f.CreateUser(context.Background(), user))
+	assert.ErrorIs(t, f.CreateUser(context.Background(), &models.User{ID: "2", Email: "user@example.com"}), ErrUserAlreadyExists)
+
+	// Новый экземпляр читает пользователей из файла
+	f = NewFileUserStorage(filePath)
+
+	got, err := f.GetUserByEmail(context.Background(), "user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	got, err = f.GetUserByID(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	_, err = f.GetUserByID(context.Background(), "2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
@@ -0,0 +1,15 @@
+package storages
+
+import "context"
+
+// HealthChecker реализуется хранилищами, доступность которых можно проверить.
+type HealthChecker interface {
+	// CheckHealth проверяет, что хранилище доступно для чтения и записи.
+	CheckHealth(ctx context.Context) error
+}
+
+// MigrationChecker реализуется хранилищами, схема которых создается при инициализации.
+type MigrationChecker interface {
+	// CheckMigrations проверяет, что схема хранилища соответствует текущей версии.
+	CheckMigrations(ctx context.Context) error
+}
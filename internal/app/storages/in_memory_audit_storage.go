@@ -0,0 +1,111 @@
+package storages
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+)
+
+// InMemoryAuditStorage представляет хранилище событий аудита в памяти.
+// Объем хранилища ограничивается согласно AuditRetention.
+type InMemoryAuditStorage struct {
+	events    []*audit.AuditEvent // События в порядке сохранения
+	ids       map[string]struct{} // Идентификаторы сохраненных событий
+	retention AuditRetention      // Ограничения объема хранилища
+	now       func() time.Time    // Текущее время (подменяется в тестах)
+	mu        sync.RWMutex        // Мьютекс для обеспечения потокобезопасности
+}
+
+// NewInMemoryAuditStorage создает новый экземпляр хранилища событий аудита в памяти.
+func NewInMemoryAuditStorage(retention AuditRetention) AuditStorage {
+	return &InMemoryAuditStorage{
+		ids:       make(map[string]struct{}),
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (i *InMemoryAuditStorage) SaveEvents(ctx context.Context, events []*audit.AuditEvent) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		i.add(events)
+
+		return nil
+	}
+}
+
+// add сохраняет копии событий, которых еще нет в хранилище, и возвращает их.
+// События старше допустимого возраста или старше всех событий заполненного хранилища
+// не сохраняются, а самые старые события
+// удаляются согласно ограничениям хранилища. Вызывается под блокировкой.
+func (i *InMemoryAuditStorage) add(events []*audit.AuditEvent) []*audit.AuditEvent {
+	now := i.now()
+
+	var added []*audit.AuditEvent
+	for _, event := range events {
+		if event.ID == "" || i.retention.expired(event.Timestamp, now) {
+			continue
+		}
+		if _, ok := i.ids[event.ID]; ok {
+			continue
+		}
+		if i.retention.full(len(i.events)) && event.Timestamp < i.events[0].Timestamp {
+			continue // Событие было бы удалено первым
+		}
+
+		eventCopy := *event
+		i.events = append(i.events, &eventCopy)
+		i.ids[event.ID] = struct{}{}
+		added = append(added, &eventCopy)
+	}
+
+	drop := 0
+	for drop < len(i.events) && i.retention.expired(i.events[drop].Timestamp, now) {
+		drop++
+	}
+	if i.retention.MaxEvents > 0 && len(i.events)-drop > i.retention.MaxEvents {
+		drop = len(i.events) - i.retention.MaxEvents
+	}
+
+	for j := 0; j < drop; j++ {
+		delete(i.ids, i.events[j].ID)
+		i.events[j] = nil
+	}
+	i.events = i.events[drop:]
+
+	return added
+}
+
+func (i *InMemoryAuditStorage) SearchEvents(ctx context.Context, query audit.Query) ([]*audit.AuditEvent, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		now := i.now()
+
+		i.mu.RLock()
+		events := make([]*audit.AuditEvent, 0)
+		for j := len(i.events) - 1; j >= 0; j-- {
+			if query.Match(i.events[j]) && !i.retention.expired(i.events[j].Timestamp, now) {
+				eventCopy := *i.events[j]
+				events = append(events, &eventCopy)
+			}
+		}
+		i.mu.RUnlock()
+
+		// События с одинаковым временем остаются в обратном порядке сохранения
+		sort.SliceStable(events, func(a, b int) bool {
+			return events[a].Timestamp > events[b].Timestamp
+		})
+
+		return query.Page(events), nil
+	}
+}
@@ -0,0 +1,108 @@
+package storages
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// InMemoryBanStorage представляет хранилище блокировок пользователей в памяти.
+type InMemoryBanStorage struct {
+	bans map[string]*models.Ban // Блокировки по идентификатору пользователя
+	mu   sync.RWMutex           // Мьютекс для обеспечения потокобезопасности
+}
+
+// NewInMemoryBanStorage создает новый экземпляр хранилища блокировок в памяти.
+func NewInMemoryBanStorage() BanStorage {
+	return &InMemoryBanStorage{
+		bans: make(map[string]*models.Ban),
+	}
+}
+
+func (i *InMemoryBanStorage) BanUser(ctx context.Context, ban *models.Ban) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if strings.TrimSpace(ban.UserID) == "" {
+			return ErrEmptyKey
+		}
+
+		banCopy := *ban
+
+		i.mu.Lock()
+		i.bans[ban.UserID] = &banCopy
+		i.mu.Unlock()
+
+		return nil
+	}
+}
+
+func (i *InMemoryBanStorage) UnbanUser(ctx context.Context, userID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		if _, ok := i.bans[userID]; !ok {
+			return ErrKeyNotFound
+		}
+
+		delete(i.bans, userID)
+
+		return nil
+	}
+}
+
+func (i *InMemoryBanStorage) GetBan(ctx context.Context, userID string) (*models.Ban, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+
+		ban, ok := i.bans[userID]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+
+		banCopy := *ban
+		return &banCopy, nil
+	}
+}
+
+func (i *InMemoryBanStorage) CountBans(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+
+		return len(i.bans), nil
+	}
+}
+
+// snapshot возвращает копии всех блокировок.
+func (i *InMemoryBanStorage) snapshot() []*models.Ban {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	bans := make([]*models.Ban, 0, len(i.bans))
+	for _, ban := range i.bans {
+		banCopy := *ban
+		bans = append(bans, &banCopy)
+	}
+
+	sort.Slice(bans, func(a, b int) bool {
+		return bans[a].CreatedAt.Before(bans[b].CreatedAt)
+	})
+
+	return bans
+}
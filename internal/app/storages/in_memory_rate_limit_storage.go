@@ -0,0 +1,73 @@
+package storages
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// rateLimitCleanupInterval определяет, как часто из памяти удаляются восполненные корзины.
+const rateLimitCleanupInterval = time.Minute
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	full      time.Time // Момент, к которому корзина восполнится полностью
+}
+
+// InMemoryRateLimitStorage представляет хранилище корзин токенов в памяти.
+// Ограничения действуют в пределах одного экземпляра сервиса.
+type InMemoryRateLimitStorage struct {
+	buckets     map[string]*bucket // Корзины по ключу
+	lastCleanup time.Time          // Время последней очистки
+	mu          sync.Mutex         // Мьютекс для обеспечения потокобезопасности
+}
+
+// NewInMemoryRateLimitStorage создает новый экземпляр хранилища корзин токенов в памяти.
+func NewInMemoryRateLimitStorage() RateLimitStorage {
+	return &InMemoryRateLimitStorage{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (i *InMemoryRateLimitStorage) Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error) {
+	select {
+	case <-ctx.Done():
+		return models.RateLimitResult{}, ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		i.cleanup(now)
+
+		b, ok := i.buckets[key]
+		if !ok {
+			b = &bucket{tokens: float64(limit.Limit), updatedAt: now}
+			i.buckets[key] = b
+		}
+
+		tokens, result := limit.Take(b.tokens, now.Sub(b.updatedAt))
+		b.tokens = tokens
+		b.updatedAt = now
+		b.full = now.Add(result.Reset)
+
+		return result, nil
+	}
+}
+
+// cleanup удаляет полностью восполненные корзины: они ничем не отличаются от отсутствующих.
+func (i *InMemoryRateLimitStorage) cleanup(now time.Time) {
+	if now.Sub(i.lastCleanup) < rateLimitCleanupInterval {
+		return
+	}
+
+	for key, b := range i.buckets {
+		if !now.Before(b.full) {
+			delete(i.buckets, key)
+		}
+	}
+
+	i.lastCleanup = now
+}
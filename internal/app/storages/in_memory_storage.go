@@ -2,6 +2,7 @@ package storages
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 
@@ -34,14 +35,7 @@ func (i *InMemoryStorage) Save(ctx context.Context, link *models.Link) (*models.
 	default:
 		i.mu.Lock()
 		// Создаем копию ссылки для хранения
-		linkCopy := &models.Link{
-			ID:          link.ID,
-			ShortCode:   link.ShortCode,
-			ShortURL:    link.ShortURL,
-			OriginalURL: link.OriginalURL,
-			UserID:      link.UserID,
-			IsDeleted:   link.IsDeleted,
-		}
+		linkCopy := link.Clone()
 		i.store[link.ID] = linkCopy
 		i.mu.Unlock()
 		return linkCopy, nil
@@ -63,14 +57,7 @@ func (i *InMemoryStorage) BatchSave(ctx context.Context, links []*models.Link) e
 				return ErrEmptyKey
 			}
 			// Создаем копию ссылки для хранения
-			linkCopy := &models.Link{
-				ID:          link.ID,
-				ShortCode:   link.ShortCode,
-				ShortURL:    link.ShortURL,
-				OriginalURL: link.OriginalURL,
-				UserID:      link.UserID,
-				IsDeleted:   link.IsDeleted,
-			}
+			linkCopy := link.Clone()
 			i.store[link.ID] = linkCopy
 		}
 		i.mu.Unlock()
@@ -97,14 +84,7 @@ func (i *InMemoryStorage) Get(ctx context.Context, shortCode string) (*models.Li
 		}
 
 		// Возвращаем копию ссылки
-		return &models.Link{
-			ID:          link.ID,
-			ShortCode:   link.ShortCode,
-			ShortURL:    link.ShortURL,
-			OriginalURL: link.OriginalURL,
-			UserID:      link.UserID,
-			IsDeleted:   link.IsDeleted,
-		}, nil
+		return link.Clone(), nil
 	}
 }
 
@@ -120,14 +100,7 @@ func (i *InMemoryStorage) GetUserLinks(ctx context.Context, userID string) ([]*m
 			// Проверяем, что ссылка принадлежит пользователю и не удалена
 			if link.UserID == userID && !link.IsDeleted {
 				// Создаем копию ссылки
-				linkCopy := &models.Link{
-					ID:          link.ID,
-					ShortCode:   link.ShortCode,
-					ShortURL:    link.ShortURL,
-					OriginalURL: link.OriginalURL,
-					UserID:      link.UserID,
-					IsDeleted:   link.IsDeleted,
-				}
+				linkCopy := link.Clone()
 				userLinks = append(userLinks, linkCopy)
 			}
 		}
@@ -137,27 +110,166 @@ func (i *InMemoryStorage) GetUserLinks(ctx context.Context, userID string) ([]*m
 	}
 }
 
-func (i *InMemoryStorage) Delete(ctx context.Context, IDs []string, userID string) error {
+func (i *InMemoryStorage) Delete(ctx context.Context, IDs []string, userID string) ([]string, error) {
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return nil, ctx.Err()
 	default:
+		var deleted []string
 		if len(IDs) == 0 {
-			return nil
+			return deleted, nil
 		}
 
 		i.mu.Lock()
 		for _, id := range IDs {
 			if link, exists := i.store[id]; exists {
-				// Проверяем, что ссылка принадлежит пользователю
-				if link.UserID == userID {
+				// Проверяем, что ссылка принадлежит пользователю и еще не удалена
+				if link.UserID == userID && !link.IsDeleted {
 					// Помечаем ссылку как удаленную (soft delete)
 					link.IsDeleted = true
+					deleted = append(deleted, id)
 				}
 			}
 		}
 		i.mu.Unlock()
 
+		return deleted, nil
+	}
+}
+
+func (i *InMemoryStorage) IncrementVariantClicks(ctx context.Context, shortCode string, variant int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		link, ok := i.store[shortCode]
+		if !ok || variant < 0 || variant >= len(link.Destinations) {
+			return ErrKeyNotFound
+		}
+
+		link.Destinations[variant].Clicks++
+
+		return nil
+	}
+}
+
+func (i *InMemoryStorage) ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if strings.TrimSpace(fromUserID) == "" {
+			return ErrEmptyKey
+		}
+
+		i.mu.Lock()
+		for _, link := range i.store {
+			if link.UserID == fromUserID {
+				link.UserID = toUserID
+			}
+		}
+		i.mu.Unlock()
+
+		return nil
+	}
+}
+
+func (i *InMemoryStorage) SearchLinks(ctx context.Context, filter models.LinkFilter) ([]*models.Link, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		links := make([]*models.Link, 0)
+
+		i.mu.RLock()
+		for _, link := range i.store {
+			if filter.Match(link) {
+				links = append(links, link.Clone())
+			}
+		}
+		i.mu.RUnlock()
+
+		sort.Slice(links, func(a, b int) bool {
+			return links[a].ID < links[b].ID
+		})
+
+		return filter.Page(links), nil
+	}
+}
+
+func (i *InMemoryStorage) SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) error {
+	return i.updateLink(ctx, shortCode, func(link *models.Link) {
+		link.IsDisabled = disabled
+	})
+}
+
+func (i *InMemoryStorage) DeleteLink(ctx context.Context, shortCode string) error {
+	return i.updateLink(ctx, shortCode, func(link *models.Link) {
+		link.IsDeleted = true
+	})
+}
+
+func (i *InMemoryStorage) CountUserLinks(ctx context.Context, userID string) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		count := 0
+
+		i.mu.RLock()
+		for _, link := range i.store {
+			if link.UserID == userID && !link.IsDeleted {
+				count++
+			}
+		}
+		i.mu.RUnlock()
+
+		return count, nil
+	}
+}
+
+func (i *InMemoryStorage) Stats(ctx context.Context) (*models.LinkStats, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		stats := &models.LinkStats{}
+		users := make(map[string]struct{})
+
+		i.mu.RLock()
+		for _, link := range i.store {
+			stats.Add(link)
+			if link.UserID != "" {
+				users[link.UserID] = struct{}{}
+			}
+		}
+		i.mu.RUnlock()
+
+		stats.Users = len(users)
+
+		return stats, nil
+	}
+}
+
+// updateLink применяет update к ссылке с указанным коротким кодом.
+func (i *InMemoryStorage) updateLink(ctx context.Context, shortCode string, update func(link *models.Link)) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		link, ok := i.store[shortCode]
+		if !ok {
+			return ErrKeyNotFound
+		}
+
+		update(link)
+
 		return nil
 	}
 }
@@ -0,0 +1,136 @@
+package storages
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// InMemoryTokenStorage представляет хранилище API-токенов в памяти.
+type InMemoryTokenStorage struct {
+	tokens map[string]*models.APIToken // Токены по идентификатору
+	mu     sync.RWMutex                // Мьютекс для обеспечения потокобезопасности
+}
+
+// NewInMemoryTokenStorage создает новый экземпляр хранилища API-токенов в памяти.
+func NewInMemoryTokenStorage() TokenStorage {
+	return &InMemoryTokenStorage{
+		tokens: make(map[string]*models.APIToken),
+	}
+}
+
+func (i *InMemoryTokenStorage) CreateToken(ctx context.Context, token *models.APIToken) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if strings.TrimSpace(token.ID) == "" || strings.TrimSpace(token.Hash) == "" {
+			return ErrEmptyKey
+		}
+
+		i.mu.Lock()
+		i.tokens[token.ID] = token.Clone()
+		i.mu.Unlock()
+
+		return nil
+	}
+}
+
+func (i *InMemoryTokenStorage) GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+
+		for _, token := range i.tokens {
+			if token.Hash == hash {
+				return token.Clone(), nil
+			}
+		}
+
+		return nil, ErrKeyNotFound
+	}
+}
+
+func (i *InMemoryTokenStorage) GetUserTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		i.mu.RLock()
+		tokens := make([]*models.APIToken, 0)
+		for _, token := range i.tokens {
+			if token.UserID == userID {
+				tokens = append(tokens, token.Clone())
+			}
+		}
+		i.mu.RUnlock()
+
+		sort.Slice(tokens, func(a, b int) bool {
+			return tokens[a].CreatedAt.Before(tokens[b].CreatedAt)
+		})
+
+		return tokens, nil
+	}
+}
+
+func (i *InMemoryTokenStorage) RevokeToken(ctx context.Context, id, userID string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		token, ok := i.tokens[id]
+		if !ok || token.UserID != userID {
+			return ErrKeyNotFound
+		}
+
+		delete(i.tokens, id)
+
+		return nil
+	}
+}
+
+func (i *InMemoryTokenStorage) TouchToken(ctx context.Context, id string, lastUsedAt time.Time) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		token, ok := i.tokens[id]
+		if !ok {
+			return ErrKeyNotFound
+		}
+
+		token.LastUsedAt = lastUsedAt
+
+		return nil
+	}
+}
+
+// snapshot возвращает копии всех токенов.
+func (i *InMemoryTokenStorage) snapshot() []*models.APIToken {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	tokens := make([]*models.APIToken, 0, len(i.tokens))
+	for _, token := range i.tokens {
+		tokens = append(tokens, token.Clone())
+	}
+
+	sort.Slice(tokens, func(a, b int) bool {
+		return tokens[a].CreatedAt.Before(tokens[b].CreatedAt)
+	})
+
+	return tokens
+}
@@ -0,0 +1,79 @@
+package storages
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// InMemoryUserStorage представляет хранилище пользователей в памяти.
+// Используется, когда не настроены ни база данных, ни файловое хранилище.
+type InMemoryUserStorage struct {
+	byID    map[string]*models.User // Пользователи по идентификатору
+	byEmail map[string]*models.User // Пользователи по email
+	mu      sync.RWMutex            // Мьютекс для обеспечения потокобезопасности
+}
+
+// NewInMemoryUserStorage создает новый экземпляр хранилища пользователей в памяти.
+func NewInMemoryUserStorage() UserStorage {
+	return &InMemoryUserStorage{
+		byID:    make(map[string]*models.User),
+		byEmail: make(map[string]*models.User),
+	}
+}
+
+func (i *InMemoryUserStorage) CreateUser(ctx context.Context, user *models.User) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		if strings.TrimSpace(user.ID) == "" || strings.TrimSpace(user.Email) == "" {
+			return ErrEmptyKey
+		}
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+
+		if _, exists := i.byEmail[user.Email]; exists {
+			return ErrUserAlreadyExists
+		}
+
+		if _, exists := i.byID[user.ID]; exists {
+			return ErrUserAlreadyExists
+		}
+
+		userCopy := *user
+		i.byID[user.ID] = &userCopy
+		i.byEmail[user.Email] = &userCopy
+
+		return nil
+	}
+}
+
+func (i *InMemoryUserStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return i.get(ctx, i.byEmail, email)
+}
+
+func (i *InMemoryUserStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return i.get(ctx, i.byID, id)
+}
+
+func (i *InMemoryUserStorage) get(ctx context.Context, index map[string]*models.User, key string) (*models.User, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		i.mu.RLock()
+		defer i.mu.RUnlock()
+
+		user, ok := index[key]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+
+		userCopy := *user
+		return &userCopy, nil
+	}
+}
@@ -50,18 +50,48 @@ func (mr *MockURLStorageMockRecorder) BatchSave(ctx, links interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchSave", reflect.TypeOf((*MockURLStorage)(nil).BatchSave), ctx, links)
 }
 
+// CountUserLinks mocks base method.
+func (m *MockURLStorage) CountUserLinks(ctx context.Context, userID string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserLinks", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserLinks indicates an expected call of CountUserLinks.
+func (mr *MockURLStorageMockRecorder) CountUserLinks(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserLinks", reflect.TypeOf((*MockURLStorage)(nil).CountUserLinks), ctx, userID)
+}
+
 // Delete mocks base method.
-func (m *MockURLStorage) Delete(ctx context.Context, IDs []string, userID string) error {
+func (m *MockURLStorage) Delete(ctx context.Context, IDs []string, userID string) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, IDs)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Delete", ctx, IDs, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
 func (mr *MockURLStorageMockRecorder) Delete(ctx, IDs, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockURLStorage)(nil).Delete), ctx, IDs)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockURLStorage)(nil).Delete), ctx, IDs, userID)
+}
+
+// DeleteLink mocks base method.
+func (m *MockURLStorage) DeleteLink(ctx context.Context, shortCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLink", ctx, shortCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLink indicates an expected call of DeleteLink.
+func (mr *MockURLStorageMockRecorder) DeleteLink(ctx, shortCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLink", reflect.TypeOf((*MockURLStorage)(nil).DeleteLink), ctx, shortCode)
 }
 
 // Get mocks base method.
@@ -94,6 +124,34 @@ func (mr *MockURLStorageMockRecorder) GetUserLinks(ctx, userID interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLinks", reflect.TypeOf((*MockURLStorage)(nil).GetUserLinks), ctx, userID)
 }
 
+// IncrementVariantClicks mocks base method.
+func (m *MockURLStorage) IncrementVariantClicks(ctx context.Context, shortCode string, variant int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementVariantClicks", ctx, shortCode, variant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementVariantClicks indicates an expected call of IncrementVariantClicks.
+func (mr *MockURLStorageMockRecorder) IncrementVariantClicks(ctx, shortCode, variant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementVariantClicks", reflect.TypeOf((*MockURLStorage)(nil).IncrementVariantClicks), ctx, shortCode, variant)
+}
+
+// ReassignUserLinks mocks base method.
+func (m *MockURLStorage) ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignUserLinks", ctx, fromUserID, toUserID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignUserLinks indicates an expected call of ReassignUserLinks.
+func (mr *MockURLStorageMockRecorder) ReassignUserLinks(ctx, fromUserID, toUserID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignUserLinks", reflect.TypeOf((*MockURLStorage)(nil).ReassignUserLinks), ctx, fromUserID, toUserID)
+}
+
 // Save mocks base method.
 func (m *MockURLStorage) Save(ctx context.Context, link *models.Link) (*models.Link, error) {
 	m.ctrl.T.Helper()
@@ -108,3 +166,47 @@ func (mr *MockURLStorageMockRecorder) Save(ctx, link interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockURLStorage)(nil).Save), ctx, link)
 }
+
+// SearchLinks mocks base method.
+func (m *MockURLStorage) SearchLinks(ctx context.Context, filter models.LinkFilter) ([]*models.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchLinks", ctx, filter)
+	ret0, _ := ret[0].([]*models.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchLinks indicates an expected call of SearchLinks.
+func (mr *MockURLStorageMockRecorder) SearchLinks(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchLinks", reflect.TypeOf((*MockURLStorage)(nil).SearchLinks), ctx, filter)
+}
+
+// SetLinkDisabled mocks base method.
+func (m *MockURLStorage) SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLinkDisabled", ctx, shortCode, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLinkDisabled indicates an expected call of SetLinkDisabled.
+func (mr *MockURLStorageMockRecorder) SetLinkDisabled(ctx, shortCode, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLinkDisabled", reflect.TypeOf((*MockURLStorage)(nil).SetLinkDisabled), ctx, shortCode, disabled)
+}
+
+// Stats mocks base method.
+func (m *MockURLStorage) Stats(ctx context.Context) (*models.LinkStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx)
+	ret0, _ := ret[0].(*models.LinkStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockURLStorageMockRecorder) Stats(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockURLStorage)(nil).Stats), ctx)
+}
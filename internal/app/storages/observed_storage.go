@@ -0,0 +1,180 @@
+package storages
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+// OperationObserver получает длительность и результат операций хранилища.
+type OperationObserver interface {
+	ObserveOperation(backend, operation string, duration time.Duration, err error)
+}
+
+// ObservedStorage передает длительность каждой операции хранилища ссылок наблюдателю,
+// записывает операцию отдельным span, если запрос трассируется, и журналирует ошибки.
+type ObservedStorage struct {
+	storage  URLStorage
+	backend  string
+	observer OperationObserver
+}
+
+// NewObservedStorage оборачивает storage; backend - имя хранилища в показателях и трассах
+// (например, memory, file или postgres). observer может быть nil.
+func NewObservedStorage(storage URLStorage, backend string, observer OperationObserver) URLStorage {
+	return &ObservedStorage{
+		storage:  storage,
+		backend:  backend,
+		observer: observer,
+	}
+}
+
+// start начинает операцию operation. Возвращаемая функция завершает span и передает
+// наблюдателю длительность и результат *err; вызывается через defer.
+// Неожиданные ошибки записываются в журнал логгером запроса; ожидаемые результаты
+// (ссылка не найдена, URL уже сокращен) не считаются ошибками ни в журнале, ни в span.
+func (o *ObservedStorage) start(ctx context.Context, operation string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "storage."+operation,
+		tracing.String("storage.backend", o.backend),
+		tracing.String("storage.operation", operation),
+	)
+
+	return ctx, func(err *error) {
+		duration := time.Since(start)
+		if *err != nil && !errors.Is(*err, ErrKeyNotFound) && !errors.Is(*err, ErrOriginalURLAlreadyExists) {
+			span.RecordError(*err)
+			logger.FromContext(ctx).Errorw("Storage operation failed",
+				"backend", o.backend, "operation", operation, "duration", duration, "error", *err)
+		}
+		span.End()
+
+		if o.observer != nil {
+			o.observer.ObserveOperation(o.backend, operation, duration, *err)
+		}
+	}
+}
+
+func (o *ObservedStorage) Save(ctx context.Context, link *models.Link) (saved *models.Link, err error) {
+	ctx, end := o.start(ctx, "save")
+	defer end(&err)
+
+	return o.storage.Save(ctx, link)
+}
+
+func (o *ObservedStorage) BatchSave(ctx context.Context, links []*models.Link) (err error) {
+	ctx, end := o.start(ctx, "batch_save")
+	defer end(&err)
+
+	return o.storage.BatchSave(ctx, links)
+}
+
+func (o *ObservedStorage) Get(ctx context.Context, shortCode string) (link *models.Link, err error) {
+	ctx, end := o.start(ctx, "get")
+	defer end(&err)
+
+	return o.storage.Get(ctx, shortCode)
+}
+
+func (o *ObservedStorage) GetUserLinks(ctx context.Context, userID string) (links []*models.Link, err error) {
+	ctx, end := o.start(ctx, "get_user_links")
+	defer end(&err)
+
+	return o.storage.GetUserLinks(ctx, userID)
+}
+
+func (o *ObservedStorage) Delete(ctx context.Context, IDs []string, userID string) (_ []string, err error) {
+	ctx, end := o.start(ctx, "delete")
+	defer end(&err)
+
+	return o.storage.Delete(ctx, IDs, userID)
+}
+
+func (o *ObservedStorage) IncrementVariantClicks(ctx context.Context, shortCode string, variant int) (err error) {
+	ctx, end := o.start(ctx, "increment_variant_clicks")
+	defer end(&err)
+
+	return o.storage.IncrementVariantClicks(ctx, shortCode, variant)
+}
+
+func (o *ObservedStorage) ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) (err error) {
+	ctx, end := o.start(ctx, "reassign_user_links")
+	defer end(&err)
+
+	return o.storage.ReassignUserLinks(ctx, fromUserID, toUserID)
+}
+
+func (o *ObservedStorage) SearchLinks(ctx context.Context, filter models.LinkFilter) (links []*models.Link, err error) {
+	ctx, end := o.start(ctx, "search_links")
+	defer end(&err)
+
+	return o.storage.SearchLinks(ctx, filter)
+}
+
+func (o *ObservedStorage) SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) (err error) {
+	ctx, end := o.start(ctx, "set_link_disabled")
+	defer end(&err)
+
+	return o.storage.SetLinkDisabled(ctx, shortCode, disabled)
+}
+
+func (o *ObservedStorage) DeleteLink(ctx context.Context, shortCode string) (err error) {
+	ctx, end := o.start(ctx, "delete_link")
+	defer end(&err)
+
+	return o.storage.DeleteLink(ctx, shortCode)
+}
+
+func (o *ObservedStorage) CountUserLinks(ctx context.Context, userID string) (count int, err error) {
+	ctx, end := o.start(ctx, "count_user_links")
+	defer end(&err)
+
+	return o.storage.CountUserLinks(ctx, userID)
+}
+
+func (o *ObservedStorage) Stats(ctx context.Context) (stats *models.LinkStats, err error) {
+	ctx, end := o.start(ctx, "stats")
+	defer end(&err)
+
+	return o.storage.Stats(ctx)
+}
+
+// CheckHealth проверяет хранилище, если оно реализует HealthChecker.
+func (o *ObservedStorage) CheckHealth(ctx context.Context) error {
+	if checker, ok := o.storage.(HealthChecker); ok {
+		return checker.CheckHealth(ctx)
+	}
+
+	return nil
+}
+
+// FlushClicks записывает накопленные счетчики переходов, если хранилище реализует ClickFlusher.
+func (o *ObservedStorage) FlushClicks(ctx context.Context) error {
+	if flusher, ok := o.storage.(ClickFlusher); ok {
+		return flusher.FlushClicks(ctx)
+	}
+
+	return nil
+}
+
+// GetByOriginalURL ищет ссылку по оригинальному URL, если хранилище реализует OriginalURLFinder.
+func (o *ObservedStorage) GetByOriginalURL(ctx context.Context, originalURL string) *models.Link {
+	if finder, ok := o.storage.(OriginalURLFinder); ok {
+		return finder.GetByOriginalURL(ctx, originalURL)
+	}
+
+	return nil
+}
+
+// CheckMigrations проверяет схему хранилища, если оно реализует MigrationChecker.
+func (o *ObservedStorage) CheckMigrations(ctx context.Context) error {
+	if checker, ok := o.storage.(MigrationChecker); ok {
+		return checker.CheckMigrations(ctx)
+	}
+
+	return nil
+}
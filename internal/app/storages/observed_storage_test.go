@@ -0,0 +1,59 @@
+package storages
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/sviatilnik/url-shortener/internal/app/logger"
+)
+
+type operation struct {
+	backend   string
+	operation string
+	err       error
+}
+
+type operationRecorder []operation
+
+func (r *operationRecorder) ObserveOperation(backend, op string, _ time.Duration, err error) {
+	*r = append(*r, operation{backend: backend, operation: op, err: err})
+}
+
+func TestObservedStorage(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := logger.WithContext(context.Background(), zap.New(core).Sugar().With("request_id", "req-1"))
+
+	var operations operationRecorder
+	storage := NewObservedStorage(NewInMemoryStorage(), "memory", &operations)
+
+	// Ожидаемый результат не считается ошибкой
+	_, err := storage.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	err = storage.BatchSave(ctx, nil)
+	assert.ErrorIs(t, err, ErrBatchIsEmpty)
+
+	assert.Equal(t, operationRecorder{
+		{backend: "memory", operation: "get", err: ErrKeyNotFound},
+		{backend: "memory", operation: "batch_save", err: ErrBatchIsEmpty},
+	}, operations)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Storage operation failed", entries[0].Message)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, "batch_save", fields["operation"])
+	assert.Equal(t, ErrBatchIsEmpty.Error(), fields["error"])
+
+	// Наблюдатель необязателен
+	_, err = NewObservedStorage(NewInMemoryStorage(), "memory", nil).Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
@@ -0,0 +1,179 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+)
+
+// PostgresAuditStorage представляет хранилище событий аудита в PostgreSQL.
+// Поля, по которым выполняется поиск, хранятся в отдельных столбцах,
+// событие целиком - в столбце "event".
+type PostgresAuditStorage struct {
+	db        *sql.DB
+	tableName string
+	retention AuditRetention
+}
+
+// NewPostgresAuditStorage создает хранилище событий аудита в указанной таблице.
+// Если имя таблицы не задано, используется "audit_events".
+// Устаревшие по retention события удаляются при сохранении новых.
+func NewPostgresAuditStorage(db *sql.DB, tableName string, retention AuditRetention) *PostgresAuditStorage {
+	if strings.TrimSpace(tableName) != "" {
+		tableName = strings.TrimSpace(tableName)
+	} else {
+		tableName = "audit_events"
+	}
+
+	return &PostgresAuditStorage{
+		db:        db,
+		tableName: tableName,
+		retention: retention,
+	}
+}
+
+func (p *PostgresAuditStorage) SaveEvents(ctx context.Context, events []*audit.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			`INSERT INTO `+p.tableName+` ("id", "ts", "action", "userID", "shortCode", "url", "event")
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT("id") DO NOTHING`,
+			event.ID, time.UnixMilli(event.Timestamp).UTC(), event.Action, event.UserID, event.ShortCode, event.URL, string(data))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err = p.prune(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// prune удаляет самые старые события согласно ограничениям хранилища.
+func (p *PostgresAuditStorage) prune(ctx context.Context, tx *sql.Tx) error {
+	if p.retention.MaxAge > 0 {
+		_, err := tx.ExecContext(ctx, `DELETE FROM `+p.tableName+` WHERE "ts" < $1`, time.Now().Add(-p.retention.MaxAge).UTC())
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.retention.MaxEvents > 0 {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM `+p.tableName+` WHERE "id" IN (
+				SELECT "id" FROM `+p.tableName+` ORDER BY "ts" DESC, "id" DESC OFFSET $1)`,
+			p.retention.MaxEvents)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *PostgresAuditStorage) SearchEvents(ctx context.Context, query audit.Query) ([]*audit.AuditEvent, error) {
+	events := make([]*audit.AuditEvent, 0)
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if query.UserID != "" {
+		addCondition(`"userID" = ?`, query.UserID)
+	}
+	if query.Action != "" {
+		addCondition(`"action" = ?`, query.Action)
+	}
+	if query.ShortCode != "" {
+		addCondition(`"shortCode" = ?`, query.ShortCode)
+	}
+	if query.URL != "" {
+		addCondition(`strpos(lower("url"), lower(?)) > 0`, query.URL)
+	}
+	if !query.From.IsZero() {
+		addCondition(`"ts" >= ?`, query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		addCondition(`"ts" < ?`, query.To.UTC())
+	}
+
+	statement := `SELECT "event" FROM ` + p.tableName
+	if len(conditions) > 0 {
+		statement += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	statement += ` ORDER BY "ts" DESC, "id" DESC`
+	if query.Limit > 0 {
+		statement += ` LIMIT ` + strconv.Itoa(query.Limit)
+	}
+	if query.Offset > 0 {
+		statement += ` OFFSET ` + strconv.Itoa(query.Offset)
+	}
+
+	rows, err := p.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return events, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return events, err
+		}
+
+		event := &audit.AuditEvent{}
+		if err := json.Unmarshal(data, event); err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Init создает таблицу событий аудита и индексы для поиска, если они не существуют.
+func (p *PostgresAuditStorage) Init(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
+    "id" character varying(64) NOT NULL,
+    "ts" timestamp with time zone NOT NULL,
+    "action" character varying(32) NOT NULL,
+    "userID" character varying(255) NOT NULL DEFAULT '',
+    "shortCode" character varying(255) NOT NULL DEFAULT '',
+    "url" text NOT NULL DEFAULT '',
+    "event" jsonb NOT NULL,
+    PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS `+p.tableName+`_ts_idx ON `+p.tableName+` ("ts");
+CREATE INDEX IF NOT EXISTS `+p.tableName+`_user_ts_idx ON `+p.tableName+` ("userID", "ts");
+CREATE INDEX IF NOT EXISTS `+p.tableName+`_action_ts_idx ON `+p.tableName+` ("action", "ts");
+CREATE INDEX IF NOT EXISTS `+p.tableName+`_short_code_idx ON `+p.tableName+` ("shortCode");`)
+	return err
+}
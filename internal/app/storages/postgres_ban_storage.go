@@ -0,0 +1,97 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// PostgresBanStorage представляет хранилище блокировок пользователей в PostgreSQL.
+type PostgresBanStorage struct {
+	db        *sql.DB
+	tableName string
+}
+
+// NewPostgresBanStorage создает хранилище блокировок в указанной таблице.
+// Если имя таблицы не задано, используется "user_bans".
+func NewPostgresBanStorage(db *sql.DB, tableName string) *PostgresBanStorage {
+	if strings.TrimSpace(tableName) != "" {
+		tableName = strings.TrimSpace(tableName)
+	} else {
+		tableName = "user_bans"
+	}
+
+	return &PostgresBanStorage{
+		db:        db,
+		tableName: tableName,
+	}
+}
+
+func (p *PostgresBanStorage) BanUser(ctx context.Context, ban *models.Ban) error {
+	if strings.TrimSpace(ban.UserID) == "" {
+		return ErrEmptyKey
+	}
+
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO `+p.tableName+` ("userID", "reason", "bannedBy", "createdAt") 
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT("userID") DO UPDATE SET "reason" = $2, "bannedBy" = $3, "createdAt" = $4`,
+		ban.UserID, ban.Reason, ban.BannedBy, ban.CreatedAt)
+
+	return err
+}
+
+func (p *PostgresBanStorage) UnbanUser(ctx context.Context, userID string) error {
+	res, err := p.db.ExecContext(ctx, `DELETE FROM `+p.tableName+` WHERE "userID"=$1`, userID)
+	if err != nil {
+		return err
+	}
+
+	if c, _ := res.RowsAffected(); c != 1 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func (p *PostgresBanStorage) GetBan(ctx context.Context, userID string) (*models.Ban, error) {
+	ban := &models.Ban{}
+
+	err := p.db.QueryRowContext(
+		ctx,
+		`SELECT "userID", "reason", "bannedBy", "createdAt"
+				FROM `+p.tableName+` 
+				WHERE "userID"=$1`, userID).Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &ban.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ban, nil
+}
+
+func (p *PostgresBanStorage) CountBans(ctx context.Context) (int, error) {
+	var count int
+	err := p.db.QueryRowContext(ctx, `SELECT count(*) FROM `+p.tableName).Scan(&count)
+
+	return count, err
+}
+
+// Init создает таблицу блокировок, если она не существует.
+func (p *PostgresBanStorage) Init(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
+    "userID" character varying(255) NOT NULL,
+    "reason" text NOT NULL DEFAULT '',
+    "bannedBy" character varying(255) NOT NULL,
+    "createdAt" timestamp with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("userID"));`)
+	return err
+}
@@ -0,0 +1,88 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// PostgresRateLimitStorage представляет хранилище корзин токенов в PostgreSQL.
+// Позволяет нескольким экземплярам сервиса применять общие ограничения.
+type PostgresRateLimitStorage struct {
+	db        *sql.DB
+	tableName string
+}
+
+// NewPostgresRateLimitStorage создает хранилище корзин токенов в указанной таблице.
+// Если имя таблицы не задано, используется "rate_limits".
+func NewPostgresRateLimitStorage(db *sql.DB, tableName string) *PostgresRateLimitStorage {
+	if strings.TrimSpace(tableName) != "" {
+		tableName = strings.TrimSpace(tableName)
+	} else {
+		tableName = "rate_limits"
+	}
+
+	return &PostgresRateLimitStorage{
+		db:        db,
+		tableName: tableName,
+	}
+}
+
+func (p *PostgresRateLimitStorage) Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RateLimitResult{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO `+p.tableName+` ("key", "tokens", "updatedAt") VALUES ($1, $2, $3) ON CONFLICT("key") DO NOTHING`,
+		key, float64(limit.Limit), now)
+	if err != nil {
+		return models.RateLimitResult{}, err
+	}
+
+	var tokens float64
+	var updatedAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT "tokens", "updatedAt" FROM `+p.tableName+` WHERE "key"=$1 FOR UPDATE`, key).Scan(&tokens, &updatedAt)
+	if err != nil {
+		return models.RateLimitResult{}, err
+	}
+
+	// Часы экземпляров могут расходиться: время в прошлом не восполняет корзину
+	elapsed := max(now.Sub(updatedAt), 0)
+	tokens, result := limit.Take(tokens, elapsed)
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE `+p.tableName+` SET "tokens"=$2, "updatedAt"=$3, "expiresAt"=$4 WHERE "key"=$1`,
+		key, tokens, now, now.Add(result.Reset))
+	if err != nil {
+		return models.RateLimitResult{}, err
+	}
+
+	return result, tx.Commit()
+}
+
+// Cleanup удаляет полностью восполненные корзины.
+func (p *PostgresRateLimitStorage) Cleanup(ctx context.Context, now time.Time) error {
+	_, err := p.db.ExecContext(ctx, `DELETE FROM `+p.tableName+` WHERE "expiresAt" <= $1`, now)
+
+	return err
+}
+
+// Init создает таблицу корзин токенов, если она не существует.
+func (p *PostgresRateLimitStorage) Init(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
+    "key" character varying(255) NOT NULL,
+    "tokens" double precision NOT NULL,
+    "updatedAt" timestamp with time zone NOT NULL,
+    "expiresAt" timestamp with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("key"));
+	CREATE INDEX IF NOT EXISTS "idx_rate_limit_expiresAt" ON `+p.tableName+` ("expiresAt");`)
+	return err
+}
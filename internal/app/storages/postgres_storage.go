@@ -3,20 +3,22 @@ package storages
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/sviatilnik/url-shortener/internal/app/models"
 )
 
 type PostgresStorage struct {
-	db        *sql.DB
+	db        *tracedDB
 	tableName string
 }
 
 func NewPostgresStorageStorage(db *sql.DB, tableName string) InitableStorage {
 	postgresStorage := new(PostgresStorage)
-	postgresStorage.db = db
 
 	if strings.TrimSpace(tableName) != "" {
 		tableName = strings.TrimSpace(tableName)
@@ -24,21 +26,34 @@ func NewPostgresStorageStorage(db *sql.DB, tableName string) InitableStorage {
 		tableName = "links"
 	}
 	postgresStorage.tableName = tableName
+	postgresStorage.db = newTracedDB(db, tableName)
 
 	return postgresStorage
 }
 
+// Save сохраняет ссылку. Если ссылка без вариантов назначения с таким оригинальным URL уже существует,
+// возвращается существующая ссылка и ErrOriginalURLAlreadyExists. A/B-ссылки не участвуют в дедупликации.
 func (p *PostgresStorage) Save(ctx context.Context, link *models.Link) (*models.Link, error) {
 	if strings.TrimSpace(link.ID) == "" {
 		return nil, ErrEmptyKey
 	}
 
+	utm, err := encodeUTM(link.UTM)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := encodeDestinations(link.Destinations)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := p.db.ExecContext(
 		ctx,
-		`INSERT INTO `+p.tableName+` ("uuid", "originalURL", "shortCode", "userID") 
-				VALUES ($1, $2, $3, $4) 
-				ON CONFLICT("originalURL") DO NOTHING`,
-		link.ID, link.OriginalURL, link.ShortCode, link.UserID)
+		`INSERT INTO `+p.tableName+` ("uuid", "originalURL", "shortCode", "userID", "queryPolicy", "utm", "destinations", "rawURL") 
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+				ON CONFLICT("originalURL") WHERE "destinations" IS NULL DO NOTHING`,
+		link.ID, link.OriginalURL, link.ShortCode, link.UserID, string(link.QueryPolicy), utm, destinations, link.RawURL)
 
 	if err != nil {
 		return nil, err
@@ -62,12 +77,24 @@ func (p *PostgresStorage) BatchSave(ctx context.Context, links []*models.Link) e
 	}
 
 	for _, link := range links {
+		utm, err := encodeUTM(link.UTM)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		destinations, err := encodeDestinations(link.Destinations)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		_, err = tx.ExecContext(
 			ctx,
-			`INSERT INTO `+p.tableName+` ("uuid", "originalURL", "shortCode", "userID") 
-				    VALUES ($1, $2, $3, $4) 
-                    ON CONFLICT("uuid") DO UPDATE SET "originalURL" = $2, "shortCode" = $3, "userID" = $4`,
-			link.ID, link.OriginalURL, link.ShortCode, link.UserID)
+			`INSERT INTO `+p.tableName+` ("uuid", "originalURL", "shortCode", "userID", "queryPolicy", "utm", "destinations", "rawURL") 
+				    VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+                    ON CONFLICT("uuid") DO UPDATE SET "originalURL" = $2, "shortCode" = $3, "userID" = $4, "queryPolicy" = $5, "utm" = $6, "destinations" = $7, "rawURL" = $8`,
+			link.ID, link.OriginalURL, link.ShortCode, link.UserID, string(link.QueryPolicy), utm, destinations, link.RawURL)
 		if err != nil {
 			tx.Rollback()
 			return err
@@ -84,18 +111,24 @@ func (p *PostgresStorage) BatchSave(ctx context.Context, links []*models.Link) e
 
 func (p *PostgresStorage) Get(ctx context.Context, shortCode string) (*models.Link, error) {
 	var row struct {
-		uuid        string
-		originalURL string
-		shortCode   string
-		userID      string
-		isDeleted   bool
+		uuid         string
+		originalURL  string
+		rawURL       string
+		shortCode    string
+		userID       string
+		isDeleted    bool
+		isDisabled   bool
+		queryPolicy  string
+		utm          sql.NullString
+		destinations sql.NullString
 	}
 
 	err := p.db.QueryRowContext(
 		ctx,
-		`SELECT "uuid", "originalURL",  "shortCode", "userID", "isDeleted"
+		`SELECT "uuid", "originalURL",  "shortCode", "userID", "isDeleted", "queryPolicy", "utm", "destinations", "rawURL", "isDisabled"
 				FROM `+p.tableName+` 
-				WHERE "shortCode"=$1`, shortCode).Scan(&row.uuid, &row.originalURL, &row.shortCode, &row.userID, &row.isDeleted)
+				WHERE "shortCode"=$1`, shortCode).Scan(&row.uuid, &row.originalURL, &row.shortCode, &row.userID, &row.isDeleted,
+		&row.queryPolicy, &row.utm, &row.destinations, &row.rawURL, &row.isDisabled)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrKeyNotFound
 	}
@@ -104,15 +137,41 @@ func (p *PostgresStorage) Get(ctx context.Context, shortCode string) (*models.Li
 		return nil, err
 	}
 
+	utm, err := decodeUTM(row.utm)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := decodeDestinations(row.destinations)
+	if err != nil {
+		return nil, err
+	}
+
 	return &models.Link{
-		ID:          row.uuid,
-		OriginalURL: row.originalURL,
-		ShortCode:   row.shortCode,
-		UserID:      row.userID,
-		IsDeleted:   row.isDeleted,
+		ID:           row.uuid,
+		OriginalURL:  row.originalURL,
+		RawURL:       row.rawURL,
+		ShortCode:    row.shortCode,
+		UserID:       row.userID,
+		IsDeleted:    row.isDeleted,
+		IsDisabled:   row.isDisabled,
+		QueryPolicy:  models.QueryPolicy(row.queryPolicy),
+		UTM:          utm,
+		Destinations: destinations,
 	}, nil
 }
 
+func (p *PostgresStorage) ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) error {
+	if strings.TrimSpace(fromUserID) == "" {
+		return ErrEmptyKey
+	}
+
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE `+p.tableName+` SET "userID" = $2 WHERE "userID" = $1`, fromUserID, toUserID)
+
+	return err
+}
+
 func (p *PostgresStorage) Init(ctx context.Context) error {
 	_, err := p.db.ExecContext(ctx,
 		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
@@ -125,10 +184,32 @@ func (p *PostgresStorage) Init(ctx context.Context) error {
     PRIMARY KEY ("uuid"));
     CREATE INDEX IF NOT EXISTS "idx_link_shortCode" ON `+p.tableName+` ("shortCode");
     CREATE INDEX IF NOT EXISTS "idx_link_userID" ON `+p.tableName+` ("userID");
-	CREATE UNIQUE INDEX IF NOT EXISTS  "idx_link_originalUrl" ON `+p.tableName+` ("originalURL");`)
+	ALTER TABLE `+p.tableName+` ADD COLUMN IF NOT EXISTS "queryPolicy" character varying(16) NOT NULL DEFAULT '';
+	ALTER TABLE `+p.tableName+` ADD COLUMN IF NOT EXISTS "utm" text;
+	ALTER TABLE `+p.tableName+` ADD COLUMN IF NOT EXISTS "destinations" jsonb;
+	ALTER TABLE `+p.tableName+` ADD COLUMN IF NOT EXISTS "rawURL" text NOT NULL DEFAULT '';
+	ALTER TABLE `+p.tableName+` ADD COLUMN IF NOT EXISTS "isDisabled" boolean NOT NULL DEFAULT FALSE;
+	DROP INDEX IF EXISTS "idx_link_originalUrl";
+	CREATE UNIQUE INDEX IF NOT EXISTS "idx_link_originalUrl_single" ON `+p.tableName+` ("originalURL") WHERE "destinations" IS NULL;`)
 	return err
 }
 
+// CheckHealth проверяет соединение с базой данных.
+func (p *PostgresStorage) CheckHealth(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// CheckMigrations проверяет, что таблица ссылок содержит столбцы, добавленные последней миграцией Init.
+func (p *PostgresStorage) CheckMigrations(ctx context.Context) error {
+	rows, err := p.db.QueryContext(ctx, `SELECT "rawURL", "isDisabled" FROM `+p.tableName+` LIMIT 0`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMigrationsNotApplied, err)
+	}
+	defer rows.Close()
+
+	return rows.Err()
+}
+
 func (p *PostgresStorage) Drop(ctx context.Context) error {
 	_, err := p.db.ExecContext(ctx, `DROP TABLE IF EXISTS `+p.tableName+`;`)
 	return err
@@ -146,7 +227,7 @@ func (p *PostgresStorage) GetByOriginalURL(ctx context.Context, originalURL stri
 		ctx,
 		`SELECT "uuid", "originalURL",  "shortCode", "userID"
 				FROM `+p.tableName+` 
-				WHERE "originalURL"=$1 
+				WHERE "originalURL"=$1 AND "destinations" IS NULL
 				ORDER BY "createdAt" DESC LIMIT 1`,
 		originalURL).Scan(&row.uuid, &row.originalURL, &row.shortCode, &row.userID)
 
@@ -167,7 +248,7 @@ func (p *PostgresStorage) GetUserLinks(ctx context.Context, userID string) ([]*m
 
 	rows, err := p.db.QueryContext(
 		ctx,
-		`SELECT "uuid", "originalURL",  "shortCode", "userID"
+		`SELECT "uuid", "originalURL",  "shortCode", "userID", "queryPolicy", "utm", "destinations", "rawURL"
 				FROM `+p.tableName+` 
 				WHERE "userID"=$1`, userID)
 	if err != nil {
@@ -180,7 +261,18 @@ func (p *PostgresStorage) GetUserLinks(ctx context.Context, userID string) ([]*m
 
 	for rows.Next() {
 		link := &models.Link{}
-		if err := rows.Scan(&link.ID, &link.OriginalURL, &link.ShortCode, &link.UserID); err != nil {
+		var queryPolicy string
+		var utm, destinations sql.NullString
+		if err := rows.Scan(&link.ID, &link.OriginalURL, &link.ShortCode, &link.UserID, &queryPolicy, &utm, &destinations, &link.RawURL); err != nil {
+			return nil, err
+		}
+
+		link.QueryPolicy = models.QueryPolicy(queryPolicy)
+		if link.UTM, err = decodeUTM(utm); err != nil {
+			return nil, err
+		}
+
+		if link.Destinations, err = decodeDestinations(destinations); err != nil {
 			return nil, err
 		}
 
@@ -190,21 +282,221 @@ func (p *PostgresStorage) GetUserLinks(ctx context.Context, userID string) ([]*m
 	return links, nil
 }
 
-func (p *PostgresStorage) Delete(ctx context.Context, IDs []string, userID string) error {
-	tx, err := p.db.Begin()
-	if err != nil {
-		return err
+func (p *PostgresStorage) Delete(ctx context.Context, IDs []string, userID string) ([]string, error) {
+	var deleted []string
+	if len(IDs) == 0 {
+		return deleted, nil
 	}
 
-	_, err = tx.ExecContext(
+	rows, err := p.db.QueryContext(
 		ctx,
-		`UPDATE `+p.tableName+` SET "isDeleted"=true WHERE "uuid" = any('{"`+strings.Join(IDs, "\", \"")+`"}'::text[])`, // and "userID"='`+userID+`'`,
+		`UPDATE `+p.tableName+` SET "isDeleted"=true
+				WHERE "uuid" = ANY($1) AND "userID"=$2 AND NOT "isDeleted"
+				RETURNING "uuid"`,
+		IDs, userID,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+
+	return deleted, rows.Err()
+}
+
+func (p *PostgresStorage) IncrementVariantClicks(ctx context.Context, shortCode string, variant int) error {
+	if variant < 0 {
+		return ErrKeyNotFound
+	}
 
+	res, err := p.db.ExecContext(
+		ctx,
+		`UPDATE `+p.tableName+` 
+				SET "destinations" = jsonb_set("destinations", ARRAY[$2::text, 'clicks'],
+					to_jsonb(COALESCE(("destinations"->($3::int)->>'clicks')::bigint, 0) + 1))
+				WHERE "shortCode"=$1 AND jsonb_array_length("destinations") > $3::int`,
+		shortCode, strconv.Itoa(variant), variant)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	return tx.Commit()
+	if c, _ := res.RowsAffected(); c == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// linkHostExpr извлекает хост из оригинального URL для поиска по домену.
+const linkHostExpr = `lower(substring("originalURL" from '^[^:]+://(?:[^/?#@]*@)?([^/?#:]+)'))`
+
+func (p *PostgresStorage) SearchLinks(ctx context.Context, filter models.LinkFilter) ([]*models.Link, error) {
+	links := make([]*models.Link, 0)
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.UserID != "" {
+		addCondition(`"userID" = ?`, filter.UserID)
+	}
+	if filter.URL != "" {
+		addCondition(`strpos(lower("originalURL"), lower(?)) > 0`, filter.URL)
+	}
+	if filter.Domain != "" {
+		addCondition(`(`+linkHostExpr+` = lower(?) OR `+linkHostExpr+` LIKE '%.' || lower(?))`, strings.TrimPrefix(filter.Domain, "."))
+	}
+
+	query := `SELECT "uuid", "originalURL",  "shortCode", "userID", "isDeleted", "isDisabled", "queryPolicy", "utm", "destinations", "rawURL"
+				FROM ` + p.tableName
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY "uuid"`
+	if filter.Limit > 0 {
+		query += ` LIMIT ` + strconv.Itoa(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query += ` OFFSET ` + strconv.Itoa(filter.Offset)
+	}
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return links, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link := &models.Link{}
+		var queryPolicy string
+		var utm, destinations sql.NullString
+		err := rows.Scan(&link.ID, &link.OriginalURL, &link.ShortCode, &link.UserID, &link.IsDeleted, &link.IsDisabled,
+			&queryPolicy, &utm, &destinations, &link.RawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		link.QueryPolicy = models.QueryPolicy(queryPolicy)
+		if link.UTM, err = decodeUTM(utm); err != nil {
+			return nil, err
+		}
+
+		if link.Destinations, err = decodeDestinations(destinations); err != nil {
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (p *PostgresStorage) SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) error {
+	return p.updateLink(ctx, `"isDisabled"=$2`, shortCode, disabled)
+}
+
+func (p *PostgresStorage) DeleteLink(ctx context.Context, shortCode string) error {
+	return p.updateLink(ctx, `"isDeleted"=true`, shortCode)
+}
+
+func (p *PostgresStorage) CountUserLinks(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := p.db.QueryRowContext(ctx,
+		`SELECT count(*) FROM `+p.tableName+` WHERE "userID"=$1 AND NOT "isDeleted"`, userID).Scan(&count)
+
+	return count, err
+}
+
+func (p *PostgresStorage) Stats(ctx context.Context) (*models.LinkStats, error) {
+	stats := &models.LinkStats{}
+
+	err := p.db.QueryRowContext(ctx,
+		`SELECT count(*),
+					count(*) FILTER (WHERE NOT "isDeleted" AND NOT "isDisabled"),
+					count(*) FILTER (WHERE "isDeleted"),
+					count(*) FILTER (WHERE "isDisabled" AND NOT "isDeleted"),
+					count(DISTINCT NULLIF("userID", ''))
+				FROM `+p.tableName).Scan(&stats.Total, &stats.Active, &stats.Deleted, &stats.Disabled, &stats.Users)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// updateLink выполняет UPDATE с выражением set для ссылки с указанным коротким кодом ($1).
+func (p *PostgresStorage) updateLink(ctx context.Context, set, shortCode string, args ...any) error {
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE `+p.tableName+` SET `+set+` WHERE "shortCode"=$1`, append([]any{shortCode}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	if c, _ := res.RowsAffected(); c == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func encodeUTM(utm map[string]string) (sql.NullString, error) {
+	if len(utm) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(utm)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func decodeUTM(raw sql.NullString) (map[string]string, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	utm := make(map[string]string)
+	if err := json.Unmarshal([]byte(raw.String), &utm); err != nil {
+		return nil, err
+	}
+
+	return utm, nil
+}
+
+func encodeDestinations(destinations []models.Destination) (sql.NullString, error) {
+	if len(destinations) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(destinations)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func decodeDestinations(raw sql.NullString) ([]models.Destination, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+
+	destinations := make([]models.Destination, 0)
+	if err := json.Unmarshal([]byte(raw.String), &destinations); err != nil {
+		return nil, err
+	}
+
+	return destinations, nil
 }
@@ -94,7 +94,7 @@ func TestPostgresStorage_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &PostgresStorage{
-				db: tt.fields.db,
+				db: newTracedDB(tt.fields.db, "links"),
 			}
 			got, err := p.Get(context.Background(), tt.args.shortCode)
 			if !tt.wantErr(t, err, fmt.Sprintf("Get(%v)", tt.args.shortCode)) {
@@ -123,7 +123,7 @@ func TestPostgresStorage_GetByOriginalURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &PostgresStorage{
-				db: tt.fields.db,
+				db: newTracedDB(tt.fields.db, "links"),
 			}
 			assert.Equalf(t, tt.want, p.GetByOriginalURL(context.Background(), tt.args.originalURL), "GetByOriginalURL(%v)", tt.args.originalURL)
 		})
@@ -149,7 +149,7 @@ func TestPostgresStorage_Save(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := &PostgresStorage{
-				db: tt.fields.db,
+				db: newTracedDB(tt.fields.db, "links"),
 			}
 			got, err := p.Save(context.Background(), tt.args.link)
 			if !tt.wantErr(t, err, fmt.Sprintf("Save(%v)", tt.args.link)) {
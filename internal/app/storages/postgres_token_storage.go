@@ -0,0 +1,148 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// PostgresTokenStorage представляет хранилище API-токенов в PostgreSQL.
+type PostgresTokenStorage struct {
+	db        *sql.DB
+	tableName string
+}
+
+// NewPostgresTokenStorage создает хранилище API-токенов в указанной таблице.
+// Если имя таблицы не задано, используется "api_tokens".
+func NewPostgresTokenStorage(db *sql.DB, tableName string) *PostgresTokenStorage {
+	if strings.TrimSpace(tableName) != "" {
+		tableName = strings.TrimSpace(tableName)
+	} else {
+		tableName = "api_tokens"
+	}
+
+	return &PostgresTokenStorage{
+		db:        db,
+		tableName: tableName,
+	}
+}
+
+func (p *PostgresTokenStorage) CreateToken(ctx context.Context, token *models.APIToken) error {
+	if strings.TrimSpace(token.ID) == "" || strings.TrimSpace(token.Hash) == "" {
+		return ErrEmptyKey
+	}
+
+	_, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO `+p.tableName+` ("id", "userID", "name", "hash", "scopes", "createdAt") 
+				VALUES ($1, $2, $3, $4, $5, $6)`,
+		token.ID, token.UserID, token.Name, token.Hash, strings.Join(token.Scopes, " "), token.CreatedAt)
+
+	return err
+}
+
+func (p *PostgresTokenStorage) GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	row := p.db.QueryRowContext(
+		ctx,
+		`SELECT "id", "userID", "name", "hash", "scopes", "createdAt", "lastUsedAt"
+				FROM `+p.tableName+` 
+				WHERE "hash"=$1`, hash)
+
+	token, err := scanToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+
+	return token, err
+}
+
+func (p *PostgresTokenStorage) GetUserTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	tokens := make([]*models.APIToken, 0)
+
+	rows, err := p.db.QueryContext(
+		ctx,
+		`SELECT "id", "userID", "name", "hash", "scopes", "createdAt", "lastUsedAt"
+				FROM `+p.tableName+` 
+				WHERE "userID"=$1
+				ORDER BY "createdAt"`, userID)
+	if err != nil {
+		return tokens, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (p *PostgresTokenStorage) RevokeToken(ctx context.Context, id, userID string) error {
+	res, err := p.db.ExecContext(ctx,
+		`DELETE FROM `+p.tableName+` WHERE "id"=$1 AND "userID"=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if c, _ := res.RowsAffected(); c != 1 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
+
+func (p *PostgresTokenStorage) TouchToken(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, err := p.db.ExecContext(ctx,
+		`UPDATE `+p.tableName+` SET "lastUsedAt"=$2 WHERE "id"=$1`, id, lastUsedAt)
+
+	return err
+}
+
+// Init создает таблицу API-токенов и индексы, если они не существуют.
+func (p *PostgresTokenStorage) Init(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
+    "id" character varying(255) NOT NULL,
+    "userID" character varying(255) NOT NULL,
+    "name" character varying(255) NOT NULL,
+    "hash" character varying(64) NOT NULL,
+    "scopes" text NOT NULL DEFAULT '',
+    "createdAt" timestamp with time zone NOT NULL DEFAULT NOW(),
+    "lastUsedAt" timestamp with time zone,
+    PRIMARY KEY ("id"));
+	CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_token_hash" ON `+p.tableName+` ("hash");
+	CREATE INDEX IF NOT EXISTS "idx_api_token_userID" ON `+p.tableName+` ("userID");`)
+	return err
+}
+
+// rowScanner объединяет *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	var scopes string
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &scopes, &token.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		token.LastUsedAt = lastUsedAt.Time
+	}
+
+	return token, nil
+}
@@ -0,0 +1,92 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// PostgresUserStorage представляет хранилище пользователей в PostgreSQL.
+type PostgresUserStorage struct {
+	db        *sql.DB
+	tableName string
+}
+
+// NewPostgresUserStorage создает хранилище пользователей в указанной таблице.
+// Если имя таблицы не задано, используется "users".
+func NewPostgresUserStorage(db *sql.DB, tableName string) *PostgresUserStorage {
+	if strings.TrimSpace(tableName) != "" {
+		tableName = strings.TrimSpace(tableName)
+	} else {
+		tableName = "users"
+	}
+
+	return &PostgresUserStorage{
+		db:        db,
+		tableName: tableName,
+	}
+}
+
+func (p *PostgresUserStorage) CreateUser(ctx context.Context, user *models.User) error {
+	if strings.TrimSpace(user.ID) == "" || strings.TrimSpace(user.Email) == "" {
+		return ErrEmptyKey
+	}
+
+	res, err := p.db.ExecContext(
+		ctx,
+		`INSERT INTO `+p.tableName+` ("id", "email", "passwordHash", "createdAt") 
+				VALUES ($1, $2, $3, $4) 
+				ON CONFLICT DO NOTHING`,
+		user.ID, user.Email, user.PasswordHash, user.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if c, _ := res.RowsAffected(); c != 1 {
+		return ErrUserAlreadyExists
+	}
+
+	return nil
+}
+
+func (p *PostgresUserStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return p.get(ctx, `"email"=$1`, email)
+}
+
+func (p *PostgresUserStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return p.get(ctx, `"id"=$1`, id)
+}
+
+func (p *PostgresUserStorage) get(ctx context.Context, condition string, value string) (*models.User, error) {
+	user := &models.User{}
+	err := p.db.QueryRowContext(
+		ctx,
+		`SELECT "id", "email", "passwordHash", "createdAt"
+				FROM `+p.tableName+` 
+				WHERE `+condition, value).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Init создает таблицу пользователей и индексы, если они не существуют.
+func (p *PostgresUserStorage) Init(ctx context.Context) error {
+	_, err := p.db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS `+p.tableName+` (
+    "id" character varying(255) NOT NULL,
+    "email" character varying(320) NOT NULL,
+    "passwordHash" character varying(255) NOT NULL,
+    "createdAt" timestamp with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("id"));
+	CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_email" ON `+p.tableName+` ("email");`)
+	return err
+}
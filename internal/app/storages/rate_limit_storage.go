@@ -0,0 +1,15 @@
+package storages
+
+import (
+	"context"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// RateLimitStorage определяет интерфейс для хранения корзин токенов ограничения частоты запросов.
+type RateLimitStorage interface {
+	// Take забирает токен из корзины key с ограничением limit на момент now.
+	// Отсутствующая корзина считается полной.
+	Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error)
+}
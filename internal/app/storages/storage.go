@@ -10,7 +10,8 @@ import (
 // Интерфейс поддерживает операции создания, получения, пакетного сохранения и удаления ссылок.
 type URLStorage interface {
 	// Save сохраняет ссылку в хранилище.
-	// Возвращает сохраненную ссылку или ошибку.
+	// Возвращает сохраненную ссылку или ошибку. Хранилище может возвращать ErrOriginalURLAlreadyExists
+	// вместе с существующей ссылкой, но не для A/B-ссылок (с вариантами назначения).
 	Save(ctx context.Context, link *models.Link) (*models.Link, error)
 
 	// BatchSave сохраняет массив ссылок в хранилище.
@@ -27,5 +28,46 @@ type URLStorage interface {
 
 	// Delete помечает указанные ссылки как удаленные (soft delete).
 	// Удаление выполняется только для ссылок, принадлежащих указанному пользователю.
-	Delete(ctx context.Context, IDs []string, userID string) error
+	// Возвращает идентификаторы ссылок, которые были помечены как удаленные этим вызовом.
+	Delete(ctx context.Context, IDs []string, userID string) ([]string, error)
+
+	// IncrementVariantClicks увеличивает счетчик переходов варианта назначения ссылки.
+	// Возвращает ErrKeyNotFound, если ссылка или вариант не найдены.
+	IncrementVariantClicks(ctx context.Context, shortCode string, variant int) error
+
+	// ReassignUserLinks передает все ссылки пользователя fromUserID пользователю toUserID.
+	// Используется для привязки ссылок, созданных анонимно, к учетной записи.
+	ReassignUserLinks(ctx context.Context, fromUserID, toUserID string) error
+
+	// SearchLinks ищет ссылки всех пользователей, включая удаленные и заблокированные.
+	// Ссылки упорядочены по идентификатору; Limit и Offset фильтра задают страницу.
+	SearchLinks(ctx context.Context, filter models.LinkFilter) ([]*models.Link, error)
+
+	// SetLinkDisabled блокирует или разблокирует ссылку независимо от владельца.
+	// Возвращает ErrKeyNotFound, если ссылка не найдена.
+	SetLinkDisabled(ctx context.Context, shortCode string, disabled bool) error
+
+	// DeleteLink помечает ссылку как удаленную независимо от владельца.
+	// Возвращает ErrKeyNotFound, если ссылка не найдена.
+	DeleteLink(ctx context.Context, shortCode string) error
+
+	// CountUserLinks возвращает количество не удаленных ссылок пользователя.
+	CountUserLinks(ctx context.Context, userID string) (int, error)
+
+	// Stats возвращает общие показатели хранилища.
+	Stats(ctx context.Context) (*models.LinkStats, error)
+}
+
+// OriginalURLFinder реализуется хранилищами, которые не создают повторную ссылку
+// на уже сохраненный оригинальный URL, а возвращают ErrOriginalURLAlreadyExists.
+type OriginalURLFinder interface {
+	// GetByOriginalURL возвращает ссылку без вариантов назначения с указанным оригинальным URL
+	// или nil, если ее нет.
+	GetByOriginalURL(ctx context.Context, originalURL string) *models.Link
+}
+
+// ClickFlusher реализуется хранилищами, которые накапливают счетчики переходов в памяти.
+type ClickFlusher interface {
+	// FlushClicks записывает накопленные счетчики переходов вариантов назначения.
+	FlushClicks(ctx context.Context) error
 }
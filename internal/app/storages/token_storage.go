@@ -0,0 +1,28 @@
+package storages
+
+import (
+	"context"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// TokenStorage определяет интерфейс для хранения персональных API-токенов.
+type TokenStorage interface {
+	// CreateToken сохраняет новый токен.
+	CreateToken(ctx context.Context, token *models.APIToken) error
+
+	// GetTokenByHash получает токен по хэшу.
+	// Возвращает ErrKeyNotFound, если токен не найден или отозван.
+	GetTokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+
+	// GetUserTokens получает все действующие токены пользователя.
+	GetUserTokens(ctx context.Context, userID string) ([]*models.APIToken, error)
+
+	// RevokeToken отзывает (удаляет) токен пользователя.
+	// Возвращает ErrKeyNotFound, если у пользователя нет токена с таким идентификатором.
+	RevokeToken(ctx context.Context, id, userID string) error
+
+	// TouchToken обновляет время последнего использования токена.
+	TouchToken(ctx context.Context, id string, lastUsedAt time.Time) error
+}
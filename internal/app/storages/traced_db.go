@@ -0,0 +1,89 @@
+package storages
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/sviatilnik/url-shortener/internal/app/tracing"
+)
+
+// tracedDB записывает каждый SQL-запрос отдельным span с текстом запроса, если запрос
+// трассируется. Значения параметров в span не попадают.
+type tracedDB struct {
+	*sql.DB
+	table string
+}
+
+func newTracedDB(db *sql.DB, table string) *tracedDB {
+	return &tracedDB{DB: db, table: table}
+}
+
+func (d *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	ctx, end := traceQuery(ctx, d.table, query)
+	defer end(&err)
+
+	return d.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext записывает в span только выполнение запроса, без чтения строк результата.
+func (d *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (rows *sql.Rows, err error) {
+	ctx, end := traceQuery(ctx, d.table, query)
+	defer end(&err)
+
+	return d.DB.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext записывает в span выполнение запроса; ошибка запроса
+// возвращается при чтении строки и в span не отмечается.
+func (d *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuery(ctx, d.table, query)
+	defer span.End()
+
+	return d.DB.QueryRowContext(ctx, query, args...)
+}
+
+func (d *tracedDB) Begin() (*tracedTx, error) {
+	tx, err := d.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedTx{Tx: tx, table: d.table}, nil
+}
+
+// tracedTx - транзакция, записывающая запросы так же, как tracedDB.
+type tracedTx struct {
+	*sql.Tx
+	table string
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...any) (res sql.Result, err error) {
+	ctx, end := traceQuery(ctx, t.table, query)
+	defer end(&err)
+
+	return t.Tx.ExecContext(ctx, query, args...)
+}
+
+// startQuery начинает span SQL-запроса с названием вида "postgres INSERT links".
+func startQuery(ctx context.Context, table, query string) (context.Context, *tracing.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+
+	return tracing.StartClient(ctx, "postgres "+operation+" "+table,
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.operation", operation),
+		tracing.String("db.sql.table", table),
+		tracing.String("db.statement", statement),
+	)
+}
+
+func traceQuery(ctx context.Context, table, query string) (context.Context, func(err *error)) {
+	ctx, span := startQuery(ctx, table, query)
+
+	return ctx, func(err *error) {
+		span.RecordError(*err)
+		span.End()
+	}
+}
@@ -0,0 +1,22 @@
+package storages
+
+import (
+	"context"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+)
+
+// UserStorage определяет интерфейс для хранения зарегистрированных пользователей.
+type UserStorage interface {
+	// CreateUser сохраняет нового пользователя.
+	// Возвращает ErrUserAlreadyExists, если пользователь с таким email уже существует.
+	CreateUser(ctx context.Context, user *models.User) error
+
+	// GetUserByEmail получает пользователя по email.
+	// Возвращает ErrKeyNotFound, если пользователь не найден.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+
+	// GetUserByID получает пользователя по идентификатору.
+	// Возвращает ErrKeyNotFound, если пользователь не найден.
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+}
@@ -0,0 +1,10 @@
+package tokens
+
+import "errors"
+
+var (
+	ErrInvalidToken = errors.New("invalid api token")
+	ErrInvalidName  = errors.New("invalid token name")
+	ErrInvalidScope = errors.New("invalid token scope")
+	ErrNotFound     = errors.New("token not found")
+)
@@ -0,0 +1,173 @@
+// Package tokens реализует персональные API-токены пользователей.
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+const (
+	// Prefix предшествует каждому API-токену и позволяет отличить его от JWT.
+	Prefix = "pat_"
+	// MaxNameLength задает максимальную длину названия токена.
+	MaxNameLength = 100
+	// lastUsedResolution ограничивает частоту обновления времени последнего использования.
+	lastUsedResolution = time.Minute
+)
+
+// Scopes содержит все допустимые разрешения токенов.
+var Scopes = []string{models.ScopeLinksRead, models.ScopeLinksWrite, models.ScopeAdmin}
+
+// Service управляет персональными API-токенами.
+type Service struct {
+	storage storages.TokenStorage
+	now     func() time.Time
+}
+
+// NewService создает сервис API-токенов.
+func NewService(storage storages.TokenStorage) *Service {
+	return &Service{
+		storage: storage,
+		now:     time.Now,
+	}
+}
+
+// Create выпускает новый токен пользователя с указанными названием и разрешениями.
+// Возвращает сам токен (он показывается только один раз) и его сохраненное описание.
+// Возможные ошибки:
+//   - ErrInvalidName - пустое или слишком длинное название
+//   - ErrInvalidScope - не указано ни одного разрешения или указано неизвестное
+func (s *Service) Create(ctx context.Context, userID, name string, scopes []string) (string, *models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxNameLength {
+		return "", nil, ErrInvalidName
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, err := randomBytes(8)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret, err := randomBytes(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plain := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+	token := &models.APIToken{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		Name:      name,
+		Hash:      Hash(plain),
+		Scopes:    scopes,
+		CreatedAt: s.now().UTC(),
+	}
+
+	if err = s.storage.CreateToken(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	return plain, token, nil
+}
+
+// Authenticate находит токен по его значению и обновляет время последнего использования.
+// Возвращает ErrInvalidToken, если токен не найден или отозван.
+func (s *Service) Authenticate(ctx context.Context, plain string) (*models.APIToken, error) {
+	if !IsAPIToken(plain) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := s.storage.GetTokenByHash(ctx, Hash(plain))
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	if now.Sub(token.LastUsedAt) >= lastUsedResolution {
+		if err = s.storage.TouchToken(ctx, token.ID, now); err != nil {
+			return nil, err
+		}
+		token.LastUsedAt = now
+	}
+
+	return token, nil
+}
+
+// List возвращает действующие токены пользователя.
+func (s *Service) List(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	return s.storage.GetUserTokens(ctx, userID)
+}
+
+// Revoke отзывает токен пользователя.
+// Возвращает ErrNotFound, если у пользователя нет такого токена.
+func (s *Service) Revoke(ctx context.Context, userID, id string) error {
+	err := s.storage.RevokeToken(ctx, id, userID)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// IsAPIToken проверяет, имеет ли значение формат API-токена.
+func IsAPIToken(value string) bool {
+	return strings.HasPrefix(value, Prefix) && len(value) > len(Prefix)
+}
+
+// Hash возвращает SHA-256 хэш токена в шестнадцатеричном виде.
+func Hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes проверяет разрешения и приводит их к порядку Scopes без повторов.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return nil, ErrInvalidScope
+		}
+		requested[scope] = true
+	}
+
+	result := make([]string, 0, len(requested))
+	for _, scope := range Scopes {
+		if requested[scope] {
+			result = append(result, scope)
+		}
+	}
+
+	return result, nil
+}
+
+func randomBytes(size int) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,95 @@
+package tokens
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+func TestService_Create(t *testing.T) {
+	s := NewService(storages.NewInMemoryTokenStorage())
+
+	tests := []struct {
+		name       string
+		tokenName  string
+		scopes     []string
+		wantScopes []string
+		wantErr    error
+	}{
+		{
+			name:       "#1",
+			tokenName:  "ci",
+			scopes:     []string{models.ScopeLinksWrite, models.ScopeLinksRead, models.ScopeLinksWrite},
+			wantScopes: []string{models.ScopeLinksRead, models.ScopeLinksWrite},
+		},
+		{
+			name:      "#2 empty name",
+			tokenName: " ",
+			scopes:    []string{models.ScopeLinksRead},
+			wantErr:   ErrInvalidName,
+		},
+		{
+			name:      "#3 no scopes",
+			tokenName: "ci",
+			wantErr:   ErrInvalidScope,
+		},
+		{
+			name:      "#4 unknown scope",
+			tokenName: "ci",
+			scopes:    []string{"links:delete"},
+			wantErr:   ErrInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, token, err := s.Create(context.Background(), "user", tt.tokenName, tt.scopes)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, IsAPIToken(plain))
+			assert.Equal(t, Hash(plain), token.Hash)
+			assert.NotContains(t, token.Hash, plain)
+			assert.Equal(t, tt.wantScopes, token.Scopes)
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	s := NewService(storages.NewInMemoryTokenStorage())
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	plain, created, err := s.Create(context.Background(), "user", "ci", []string{models.ScopeLinksRead})
+	require.NoError(t, err)
+
+	token, err := s.Authenticate(context.Background(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, "user", token.UserID)
+	assert.Equal(t, now, token.LastUsedAt)
+
+	list, err := s.List(context.Background(), "user")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, now, list[0].LastUsedAt)
+
+	_, err = s.Authenticate(context.Background(), plain+"x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = s.Authenticate(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	assert.ErrorIs(t, s.Revoke(context.Background(), "other", created.ID), ErrNotFound)
+	require.NoError(t, s.Revoke(context.Background(), "user", created.ID))
+
+	_, err = s.Authenticate(context.Background(), plain)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
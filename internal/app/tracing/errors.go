@@ -0,0 +1,9 @@
+package tracing
+
+import "errors"
+
+var (
+	ErrInvalidTraceparent = errors.New("invalid traceparent header")
+	ErrUnknownExporter    = errors.New("unknown trace exporter")
+	ErrInvalidSampleRatio = errors.New("trace sample ratio must be between 0 and 1")
+)
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Exporter отправляет завершенные span во внешнюю систему.
+// Export не должен сохранять срез spans после возврата.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// spanRecord - представление span в формате JSON Lines.
+type spanRecord struct {
+	Service      string         `json:"service"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	DurationMS   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status"`
+	Message      string         `json:"status_message,omitempty"`
+}
+
+// WriterExporter записывает span в формате JSON Lines: по одному объекту в строке.
+// Используется для просмотра трасс без коллектора.
+type WriterExporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewWriterExporter создает экспортер, записывающий span в writer.
+func NewWriterExporter(writer io.Writer) *WriterExporter {
+	return &WriterExporter{writer: writer}
+}
+
+// NewStdoutExporter создает экспортер, записывающий span в стандартный вывод.
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewFileExporter создает экспортер, дописывающий span в файл path.
+// Файл закрывается в Shutdown.
+func NewFileExporter(path string) (*WriterExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия файла трасс: %w", err)
+	}
+
+	return &WriterExporter{writer: file, closer: file}, nil
+}
+
+// Export записывает spans.
+func (e *WriterExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	encoder := json.NewEncoder(e.writer)
+	for _, span := range spans {
+		if err := encoder.Encode(newSpanRecord(span)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Shutdown закрывает файл, если экспортер создан NewFileExporter.
+func (e *WriterExporter) Shutdown(context.Context) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+
+	err := e.closer.Close()
+	e.closer = nil
+
+	return err
+}
+
+func newSpanRecord(span SpanData) spanRecord {
+	record := spanRecord{
+		Service:    span.Service,
+		TraceID:    span.Context.TraceID.String(),
+		SpanID:     span.Context.SpanID.String(),
+		Name:       span.Name,
+		Kind:       span.Kind.String(),
+		Start:      span.Start,
+		End:        span.End,
+		DurationMS: float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+		Status:     span.Status.String(),
+		Message:    span.StatusMessage,
+	}
+	if span.ParentSpanID.IsValid() {
+		record.ParentSpanID = span.ParentSpanID.String()
+	}
+	if len(span.Attributes) > 0 {
+		record.Attributes = make(map[string]any, len(span.Attributes))
+		for _, attribute := range span.Attributes {
+			record.Attributes[attribute.Key] = attribute.Value
+		}
+	}
+
+	return record
+}
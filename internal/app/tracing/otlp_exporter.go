@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpTracesPath - путь приема трасс коллектором OTLP/HTTP.
+const otlpTracesPath = "/v1/traces"
+
+// instrumentationScope - имя библиотеки инструментирования в OTLP.
+const instrumentationScope = "github.com/sviatilnik/url-shortener/internal/app/tracing"
+
+// OTLPOptions задает параметры отправки трасс коллектору.
+type OTLPOptions struct {
+	Timeout time.Duration     // Ограничение времени одного запроса (0 - без ограничения)
+	Headers map[string]string // Дополнительные заголовки запроса (например, токен доступа)
+}
+
+// OTLPExporter отправляет span коллектору OpenTelemetry по протоколу OTLP/HTTP
+// в кодировке JSON.
+type OTLPExporter struct {
+	url    string
+	opts   OTLPOptions
+	client *http.Client
+}
+
+// NewOTLPExporter создает экспортер для коллектора endpoint (например, "http://localhost:4318").
+// Если в endpoint не указан путь, используется стандартный "/v1/traces".
+func NewOTLPExporter(endpoint string, opts OTLPOptions) (*OTLPExporter, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("некорректный адрес коллектора трасс %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpTracesPath
+	}
+
+	return &OTLPExporter{
+		url:    u.String(),
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+// Export отправляет spans одним запросом.
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	data, err := json.Marshal(newOTLPRequest(spans))
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации трасс: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("ошибка создания HTTP запроса: %w", err)
+	}
+	for name, value := range e.opts.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки трасс: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("коллектор трасс вернул статус %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Shutdown ничего не делает: соединения закрываются вместе с HTTP-клиентом.
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// Структуры запроса ExportTraceServiceRequest в JSON-кодировке OTLP.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue - значение AnyValue; int64 в JSON-кодировке OTLP передается строкой.
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// newOTLPRequest группирует spans по сервисам.
+func newOTLPRequest(spans []SpanData) otlpRequest {
+	request := otlpRequest{}
+	index := make(map[string]int)
+	for _, span := range spans {
+		i, ok := index[span.Service]
+		if !ok {
+			i = len(request.ResourceSpans)
+			index[span.Service] = i
+			request.ResourceSpans = append(request.ResourceSpans, otlpResourceSpans{
+				Resource:   otlpResource{Attributes: []otlpAttribute{newOTLPAttribute(String("service.name", span.Service))}},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}}},
+			})
+		}
+
+		scope := &request.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, newOTLPSpan(span))
+	}
+
+	return request
+}
+
+func newOTLPSpan(span SpanData) otlpSpan {
+	result := otlpSpan{
+		TraceID:           span.Context.TraceID.String(),
+		SpanID:            span.Context.SpanID.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.Status, Message: span.StatusMessage},
+	}
+	if span.ParentSpanID.IsValid() {
+		result.ParentSpanID = span.ParentSpanID.String()
+	}
+	for _, attribute := range span.Attributes {
+		result.Attributes = append(result.Attributes, newOTLPAttribute(attribute))
+	}
+
+	return result
+}
+
+func newOTLPAttribute(attribute Attribute) otlpAttribute {
+	var value otlpValue
+	switch v := attribute.Value.(type) {
+	case string:
+		value.StringValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		value.IntValue = &s
+	case float64:
+		value.DoubleValue = &v
+	case bool:
+		value.BoolValue = &v
+	default:
+		s := fmt.Sprint(v)
+		value.StringValue = &s
+	}
+
+	return otlpAttribute{Key: attribute.Key, Value: value}
+}
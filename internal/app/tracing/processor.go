@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// processor накапливает завершенные span и экспортирует их пакетами в фоне.
+// Трассировка не должна замедлять запросы, поэтому при переполнении очереди
+// span отбрасываются, а неудачный экспорт не повторяется.
+type processor struct {
+	exporter Exporter
+	opts     Options
+	log      *zap.SugaredLogger
+
+	queue  chan SpanData
+	mutex  sync.RWMutex
+	closed bool
+	abort  chan struct{}
+	done   chan struct{}
+
+	dropped atomic.Uint64
+}
+
+func newProcessor(exporter Exporter, opts Options, log *zap.SugaredLogger) *processor {
+	p := &processor{
+		exporter: exporter,
+		opts:     opts,
+		log:      log,
+		queue:    make(chan SpanData, opts.QueueSize),
+		abort:    make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go p.run()
+
+	return p
+}
+
+func (p *processor) enqueue(span SpanData) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.closed {
+		p.dropped.Add(1)
+		return
+	}
+
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+func (p *processor) shutdown(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		<-p.done
+		return nil
+	}
+	p.closed = true
+	close(p.queue)
+	p.mutex.Unlock()
+
+	var err error
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		close(p.abort)
+		<-p.done
+		err = ctx.Err()
+	}
+
+	if shutdownErr := p.exporter.Shutdown(ctx); err == nil {
+		err = shutdownErr
+	}
+
+	return err
+}
+
+func (p *processor) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, p.opts.BatchSize)
+	for {
+		select {
+		case span, ok := <-p.queue:
+			if !ok {
+				p.export(batch)
+				return
+			}
+
+			batch = append(batch, span)
+			if len(batch) >= p.opts.BatchSize {
+				p.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.export(batch)
+			batch = batch[:0]
+		case <-p.abort:
+			p.dropped.Add(uint64(len(batch) + len(p.queue)))
+			return
+		}
+	}
+}
+
+func (p *processor) export(batch []SpanData) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := p.exporter.Export(ctx, batch); err != nil {
+		p.dropped.Add(uint64(len(batch)))
+		p.log.Warnw("Не удалось экспортировать трассы", "spans", len(batch), "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader - заголовок W3C Trace Context с контекстом вызывающего span.
+const TraceparentHeader = "traceparent"
+
+const sampledFlag = 0x01
+
+// Extract разбирает заголовок traceparent запроса.
+// Формат: "<версия>-<trace-id>-<parent-id>-<флаги>", например
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func Extract(header http.Header) (SpanContext, error) {
+	return ParseTraceparent(header.Get(TraceparentHeader))
+}
+
+// ParseTraceparent разбирает значение заголовка traceparent.
+// Поля после флагов, добавленные в будущих версиях формата, игнорируются.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+
+	var version, flags [1]byte
+	var spanContext SpanContext
+	if !decodeHex(version[:], parts[0]) ||
+		!decodeHex(spanContext.TraceID[:], parts[1]) ||
+		!decodeHex(spanContext.SpanID[:], parts[2]) ||
+		!decodeHex(flags[:], parts[3]) ||
+		!spanContext.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	spanContext.Sampled = flags[0]&sampledFlag != 0
+
+	return spanContext, nil
+}
+
+// Inject добавляет в заголовки исходящего запроса traceparent текущего span из ctx.
+// Без span заголовки не меняются.
+func Inject(ctx context.Context, header http.Header) {
+	spanContext := SpanFromContext(ctx).SpanContext()
+	if !spanContext.IsValid() {
+		return
+	}
+
+	header.Set(TraceparentHeader, FormatTraceparent(spanContext))
+}
+
+// FormatTraceparent возвращает значение заголовка traceparent для spanContext.
+func FormatTraceparent(spanContext SpanContext) string {
+	var flags byte
+	if spanContext.Sampled {
+		flags |= sampledFlag
+	}
+
+	return fmt.Sprintf("00-%s-%s-%02x", spanContext.TraceID, spanContext.SpanID, flags)
+}
+
+// decodeHex декодирует строчные шестнадцатеричные цифры ровно в dst.
+func decodeHex(dst []byte, value string) bool {
+	if len(value) != hex.EncodedLen(len(dst)) || strings.ToLower(value) != value {
+		return false
+	}
+
+	_, err := hex.Decode(dst, []byte(value))
+	return err == nil
+}
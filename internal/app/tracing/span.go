@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID - идентификатор трассы (16 байт, как в W3C Trace Context).
+type TraceID [16]byte
+
+// IsValid сообщает, что идентификатор не состоит из одних нулей.
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String возвращает идентификатор в шестнадцатеричном виде.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID - идентификатор операции (span) внутри трассы (8 байт).
+type SpanID [8]byte
+
+// IsValid сообщает, что идентификатор не состоит из одних нулей.
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String возвращает идентификатор в шестнадцатеричном виде.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext - часть span, передаваемая между процессами.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool // Трасса записывается и экспортируется
+}
+
+// IsValid сообщает, что контекст содержит идентификаторы трассы и span.
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// SpanKind - роль span во взаимодействии. Значения совпадают с OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1 // Внутренняя операция сервиса
+	SpanKindServer   SpanKind = 2 // Обработка входящего запроса
+	SpanKindClient   SpanKind = 3 // Исходящий запрос (например, к базе данных)
+)
+
+// String возвращает название роли для экспорта в файл.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+// StatusCode - результат операции. Значения совпадают с OTLP.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0 // Результат не указан
+	StatusOK    StatusCode = 1 // Операция завершилась успешно
+	StatusError StatusCode = 2 // Операция завершилась ошибкой
+)
+
+// String возвращает название результата для экспорта в файл.
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
+// Attribute - атрибут span. Value может быть строкой, целым числом, числом с плавающей
+// точкой или логическим значением.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String создает строковый атрибут.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int создает целочисленный атрибут.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool создает логический атрибут.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData - завершенный span, передаваемый экспортеру.
+type SpanData struct {
+	Service       string
+	Name          string
+	Context       SpanContext
+	ParentSpanID  SpanID // Пустой у корневого span
+	Kind          SpanKind
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span - выполняемая операция трассы.
+// Все методы безопасно вызывать у nil: так код сервисов не зависит от того,
+// включена ли трассировка. Span незаписываемой трассы (Sampled = false) только
+// передает контекст дочерним операциям.
+type Span struct {
+	tracer *Tracer
+
+	mutex sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext возвращает контекст span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+
+	return s.data.Context
+}
+
+// IsRecording сообщает, что span записывается и будет экспортирован.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.data.Context.Sampled
+}
+
+// SetName заменяет название span.
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Name = name
+}
+
+// SetAttributes добавляет атрибуты; атрибут с уже существующим ключом заменяется.
+func (s *Span) SetAttributes(attributes ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+next:
+	for _, attribute := range attributes {
+		for i := range s.data.Attributes {
+			if s.data.Attributes[i].Key == attribute.Key {
+				s.data.Attributes[i].Value = attribute.Value
+				continue next
+			}
+		}
+		s.data.Attributes = append(s.data.Attributes, attribute)
+	}
+}
+
+// SetStatus устанавливает результат операции.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+}
+
+// RecordError отмечает span как завершившийся ошибкой err. nil игнорируется.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End завершает span и передает его на экспорт. Повторные вызовы игнорируются.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mutex.Unlock()
+
+	s.tracer.processor.enqueue(data)
+}
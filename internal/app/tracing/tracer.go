@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type contextKey string
+
+const (
+	spanKey   contextKey = "tracing_span"
+	remoteKey contextKey = "tracing_remote"
+)
+
+// Options задает параметры трассировки.
+type Options struct {
+	SampleRatio   float64       // Доля записываемых трасс от 0 до 1; решение родителя из traceparent имеет приоритет
+	QueueSize     int           // Размер очереди завершенных span
+	BatchSize     int           // Максимальное количество span в одном экспорте
+	FlushInterval time.Duration // Как часто экспортируется неполный пакет
+}
+
+// DefaultOptions возвращает параметры трассировки по умолчанию.
+func DefaultOptions() Options {
+	return Options{
+		SampleRatio:   1,
+		QueueSize:     2048,
+		BatchSize:     256,
+		FlushInterval: 5 * time.Second,
+	}
+}
+
+// Tracer создает span и передает завершенные span экспортеру в фоне.
+type Tracer struct {
+	service   string
+	threshold uint64 // Трасса записывается, если первые 8 байт ее идентификатора меньше порога
+	always    bool
+	processor *processor
+}
+
+// NewTracer создает трассировщик сервиса service и запускает фоновый экспорт в exporter.
+func NewTracer(service string, exporter Exporter, opts Options, log *zap.SugaredLogger) (*Tracer, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, ErrInvalidSampleRatio
+	}
+
+	defaults := DefaultOptions()
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaults.QueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaults.BatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaults.FlushInterval
+	}
+
+	return &Tracer{
+		service:   service,
+		threshold: uint64(opts.SampleRatio * math.MaxUint64),
+		always:    opts.SampleRatio == 1,
+		processor: newProcessor(exporter, opts, log),
+	}, nil
+}
+
+// Start начинает span с ролью kind. Родителем становится span из ctx, а если его нет -
+// контекст, полученный из входящего запроса (ContextWithRemote). Без родителя начинается
+// новая трасса. Возвращает контекст с новым span.
+// У nil трассировщика возвращает исходный контекст и nil span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attributes ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanFromContext(ctx).SpanContext()
+	if !parent.IsValid() {
+		parent, _ = ctx.Value(remoteKey).(SpanContext)
+	}
+
+	spanContext := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		spanContext.TraceID = parent.TraceID
+		spanContext.Sampled = parent.Sampled
+	} else {
+		spanContext.TraceID = newTraceID()
+		spanContext.Sampled = t.sample(spanContext.TraceID)
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Service:      t.service,
+			Name:         name,
+			Context:      spanContext,
+			ParentSpanID: parent.SpanID,
+			Kind:         kind,
+			Start:        time.Now(),
+		},
+	}
+	if span.IsRecording() {
+		span.data.Attributes = append(span.data.Attributes, attributes...)
+	}
+
+	return context.WithValue(ctx, spanKey, span), span
+}
+
+// Shutdown экспортирует накопленные span и закрывает экспортер.
+// Если ctx завершается раньше, оставшиеся span отбрасываются.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	return t.processor.shutdown(ctx)
+}
+
+// Dropped возвращает количество span, отброшенных из-за переполнения очереди или ошибок экспорта.
+func (t *Tracer) Dropped() uint64 {
+	if t == nil {
+		return 0
+	}
+
+	return t.processor.dropped.Load()
+}
+
+// sample принимает решение о записи новой трассы по ее идентификатору,
+// поэтому решение одинаково во всех экземплярах сервиса.
+func (t *Tracer) sample(traceID TraceID) bool {
+	if t.always {
+		return true
+	}
+
+	return binary.BigEndian.Uint64(traceID[:8]) < t.threshold
+}
+
+// Start начинает внутренний span, дочерний к span из ctx.
+// Если в ctx нет span, трассировка для запроса не ведется: возвращается исходный
+// контекст и nil span.
+func Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindInternal, attributes)
+}
+
+// StartClient начинает span исходящего запроса (например, к базе данных),
+// дочерний к span из ctx.
+func StartClient(ctx context.Context, name string, attributes ...Attribute) (context.Context, *Span) {
+	return start(ctx, name, SpanKindClient, attributes)
+}
+
+func start(ctx context.Context, name string, kind SpanKind, attributes []Attribute) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+
+	return parent.tracer.Start(ctx, name, kind, attributes...)
+}
+
+// SpanFromContext возвращает текущий span из контекста или nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey).(*Span)
+	return span
+}
+
+// ContextWithRemote сохраняет в контексте span вызывающего процесса,
+// который станет родителем следующего span.
+func ContextWithRemote(ctx context.Context, parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey, parent)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+
+	return id
+}
@@ -0,0 +1,233 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    SpanContext
+		wantErr bool
+	}{
+		{
+			name:  "#1",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				Sampled: true,
+			},
+		},
+		{
+			name:  "#2",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			want: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			},
+		},
+		{
+			// Будущие версии могут добавлять поля
+			name:  "#3",
+			value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			want: SpanContext{
+				TraceID: TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+				SpanID:  SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				Sampled: true,
+			},
+		},
+		{name: "#4", value: "", wantErr: true},
+		{name: "#5", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "#6", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "#7", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "#8", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{name: "#9", value: "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", wantErr: true},
+		{name: "#10", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTraceparent(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTraceparent)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if tt.want.Sampled {
+				assert.Equal(t, "00"+tt.value[2:55], FormatTraceparent(got))
+			}
+		})
+	}
+}
+
+func newTestTracer(t *testing.T, ratio float64) (*Tracer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	opts := DefaultOptions()
+	opts.SampleRatio = ratio
+
+	tracer, err := NewTracer("test", NewWriterExporter(&buf), opts, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	return tracer, &buf
+}
+
+func readRecords(t *testing.T, buf *bytes.Buffer) map[string]spanRecord {
+	records := make(map[string]spanRecord)
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record spanRecord
+		require.NoError(t, decoder.Decode(&record))
+		records[record.Name] = record
+	}
+
+	return records
+}
+
+func TestTracer(t *testing.T) {
+	tracer, buf := newTestTracer(t, 1)
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+
+	ctx, server := tracer.Start(ContextWithRemote(context.Background(), parent), "GET", SpanKindServer)
+	server.SetName("GET /{short_code}")
+
+	childCtx, child := Start(ctx, "Shortener.GetFullLinkByShortCode", String("link.short_code", "abc"))
+	_, query := StartClient(childCtx, "postgres SELECT links", Int("rows", 1), Bool("cached", false))
+	query.RecordError(errors.New("connection refused"))
+	query.End()
+	child.End()
+	child.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Equal(t, FormatTraceparent(server.SpanContext()), header.Get(TraceparentHeader))
+
+	server.End()
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	records := readRecords(t, buf)
+	require.Len(t, records, 3)
+
+	serverRecord := records["GET /{short_code}"]
+	childRecord := records["Shortener.GetFullLinkByShortCode"]
+	queryRecord := records["postgres SELECT links"]
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverRecord.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", serverRecord.ParentSpanID)
+	assert.Equal(t, "server", serverRecord.Kind)
+	assert.Equal(t, "test", serverRecord.Service)
+
+	assert.Equal(t, serverRecord.TraceID, childRecord.TraceID)
+	assert.Equal(t, serverRecord.SpanID, childRecord.ParentSpanID)
+	assert.Equal(t, "internal", childRecord.Kind)
+	assert.Equal(t, map[string]any{"link.short_code": "abc"}, childRecord.Attributes)
+	assert.Equal(t, "unset", childRecord.Status)
+
+	assert.Equal(t, childRecord.SpanID, queryRecord.ParentSpanID)
+	assert.Equal(t, "client", queryRecord.Kind)
+	assert.Equal(t, "error", queryRecord.Status)
+	assert.Equal(t, "connection refused", queryRecord.Message)
+	assert.Equal(t, map[string]any{"rows": float64(1), "cached": false}, queryRecord.Attributes)
+}
+
+func TestTracer_Sampling(t *testing.T) {
+	tracer, buf := newTestTracer(t, 0)
+
+	// Без span в контексте трассировка не ведется
+	ctx, span := Start(context.Background(), "orphan")
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	// Незаписываемая трасса передает контекст, но не экспортируется
+	ctx, root := tracer.Start(context.Background(), "root", SpanKindServer)
+	assert.False(t, root.IsRecording())
+	_, child := Start(ctx, "child")
+	assert.Equal(t, root.SpanContext().TraceID, child.SpanContext().TraceID)
+	child.End()
+	root.End()
+
+	header := http.Header{}
+	Inject(ctx, header)
+	assert.Equal(t, FormatTraceparent(root.SpanContext()), header.Get(TraceparentHeader))
+	assert.Equal(t, "00", header.Get(TraceparentHeader)[53:])
+
+	// Решение вызывающей стороны имеет приоритет над долей трасс
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	_, sampled := tracer.Start(ContextWithRemote(context.Background(), parent), "sampled", SpanKindServer)
+	assert.True(t, sampled.IsRecording())
+	sampled.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	records := readRecords(t, buf)
+	assert.Len(t, records, 1)
+	assert.Contains(t, records, "sampled")
+
+	_, err = NewTracer("test", NewWriterExporter(&bytes.Buffer{}), Options{SampleRatio: 2}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, err, ErrInvalidSampleRatio)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got otlpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	exporter, err := NewOTLPExporter(server.URL, OTLPOptions{Headers: map[string]string{"Authorization": "secret"}})
+	require.NoError(t, err)
+
+	tracer, err := NewTracer("shortener", exporter, DefaultOptions(), zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	ctx, root := tracer.Start(context.Background(), "GET /{short_code}", SpanKindServer, Int("http.response.status_code", 307))
+	_, child := Start(ctx, "storage.get")
+	child.RecordError(errors.New("timeout"))
+	child.End()
+	root.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+
+	require.Len(t, got.ResourceSpans, 1)
+	resource := got.ResourceSpans[0]
+	require.Len(t, resource.Resource.Attributes, 1)
+	assert.Equal(t, "service.name", resource.Resource.Attributes[0].Key)
+	assert.Equal(t, "shortener", *resource.Resource.Attributes[0].Value.StringValue)
+
+	require.Len(t, resource.ScopeSpans, 1)
+	spans := resource.ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "storage.get", spans[0].Name)
+	assert.Equal(t, root.SpanContext().SpanID.String(), spans[0].ParentSpanID)
+	assert.Equal(t, StatusError, spans[0].Status.Code)
+	assert.Equal(t, "timeout", spans[0].Status.Message)
+
+	assert.Equal(t, root.SpanContext().TraceID.String(), spans[1].TraceID)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, SpanKindServer, spans[1].Kind)
+	require.Len(t, spans[1].Attributes, 1)
+	assert.Equal(t, "307", *spans[1].Attributes[0].Value.IntValue)
+
+	_, err = NewOTLPExporter("localhost:4318", OTLPOptions{})
+	assert.Error(t, err)
+}
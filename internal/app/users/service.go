@@ -0,0 +1,196 @@
+// Package users реализует регистрацию и вход зарегистрированных пользователей.
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+const (
+	// MinPasswordLength задает минимальную длину пароля.
+	MinPasswordLength = 8
+	// MaxPasswordLength задает максимальную длину пароля (ограничение bcrypt).
+	MaxPasswordLength = 72
+)
+
+// LinkClaimer передает ссылки одного пользователя другому.
+type LinkClaimer interface {
+	ClaimUserLinks(ctx context.Context, fromUserID, toUserID string) error
+}
+
+// Auditor записывает события аудита.
+type Auditor interface {
+	Emit(ctx context.Context, event *audit.AuditEvent)
+}
+
+// Service управляет учетными записями пользователей.
+type Service struct {
+	storage  storages.UserStorage
+	claimer  LinkClaimer
+	auditor  Auditor
+	hashCost int
+
+	dummyHashOnce sync.Once
+	dummyHash     []byte // Хеш для сравнения при входе с неизвестным email, выравнивает время ответа
+}
+
+// NewService создает сервис учетных записей.
+// claimer используется для привязки анонимных ссылок к учетной записи и может быть nil.
+// auditor получает события входа и может быть nil.
+func NewService(storage storages.UserStorage, claimer LinkClaimer, auditor Auditor) *Service {
+	return &Service{
+		storage:  storage,
+		claimer:  claimer,
+		auditor:  auditor,
+		hashCost: bcrypt.DefaultCost,
+	}
+}
+
+// Register создает нового пользователя с указанными email и паролем.
+// Возможные ошибки:
+//   - ErrInvalidEmail - неверный формат email
+//   - ErrInvalidPassword - пароль короче MinPasswordLength или длиннее MaxPasswordLength байт
+//   - ErrUserAlreadyExists - пользователь с таким email уже зарегистрирован
+func (s *Service) Register(ctx context.Context, email, password string) (*models.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.hashCost)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := generateUserID()
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		ID:           id,
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	err = s.storage.CreateUser(ctx, user)
+	if errors.Is(err, storages.ErrUserAlreadyExists) {
+		return nil, ErrUserAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login проверяет email и пароль пользователя.
+// Возвращает ErrInvalidCredentials, если пользователь не найден или пароль не совпадает.
+// Успешные и неудачные попытки входа записываются в аудит как события ActionLogin.
+func (s *Service) Login(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.login(ctx, email, password)
+
+	if s.auditor != nil {
+		event := audit.NewAuditEvent(audit.ActionLogin, "", "")
+		event.Target = strings.ToLower(strings.TrimSpace(email))
+		if user != nil {
+			event.UserID = user.ID
+		}
+		if err != nil {
+			event.Fail(err)
+		}
+		s.auditor.Emit(ctx, event)
+	}
+
+	return user, err
+}
+
+func (s *Service) login(ctx context.Context, email, password string) (*models.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.storage.GetUserByEmail(ctx, email)
+	if errors.Is(err, storages.ErrKeyNotFound) {
+		// Сравниваем пароль с фиктивным хешем, чтобы по времени ответа
+		// нельзя было определить, зарегистрирован ли email
+		_ = bcrypt.CompareHashAndPassword(s.getDummyHash(), []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// getDummyHash возвращает фиктивный хеш пароля той же стоимости, что и хеши учетных записей.
+// Хеш вычисляется при первом обращении.
+func (s *Service) getDummyHash() []byte {
+	s.dummyHashOnce.Do(func() {
+		// Ошибка возможна только при некорректной стоимости, тогда сравнение просто завершится ошибкой
+		s.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), s.hashCost)
+	})
+
+	return s.dummyHash
+}
+
+// ClaimAnonymousLinks привязывает ссылки, созданные под анонимным идентификатором, к учетной записи.
+// Ссылки другого зарегистрированного пользователя не передаются.
+func (s *Service) ClaimAnonymousLinks(ctx context.Context, anonymousID string, user *models.User) error {
+	if s.claimer == nil || strings.TrimSpace(anonymousID) == "" || anonymousID == user.ID {
+		return nil
+	}
+
+	_, err := s.storage.GetUserByID(ctx, anonymousID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storages.ErrKeyNotFound) {
+		return err
+	}
+
+	return s.claimer.ClaimUserLinks(ctx, anonymousID, user.ID)
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
+
+func generateUserID() (string, error) {
+	userID := make([]byte, 16)
+	if _, err := rand.Read(userID); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(userID), nil
+}
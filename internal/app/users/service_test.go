@@ -0,0 +1,141 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sviatilnik/url-shortener/internal/app/audit"
+	"github.com/sviatilnik/url-shortener/internal/app/generators"
+	"github.com/sviatilnik/url-shortener/internal/app/models"
+	"github.com/sviatilnik/url-shortener/internal/app/shortener"
+	"github.com/sviatilnik/url-shortener/internal/app/storages"
+)
+
+func newTestService(claimer LinkClaimer) *Service {
+	s := NewService(storages.NewInMemoryUserStorage(), claimer, nil)
+	s.hashCost = bcrypt.MinCost
+
+	return s
+}
+
+func TestService_Register(t *testing.T) {
+	s := newTestService(nil)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "#1",
+			email:    " User@Example.com ",
+			password: "password123",
+		},
+		{
+			name:     "#2 duplicate email",
+			email:    "user@example.com",
+			password: "password456",
+			wantErr:  ErrUserAlreadyExists,
+		},
+		{
+			name:     "#3 invalid email",
+			email:    "not an email",
+			password: "password123",
+			wantErr:  ErrInvalidEmail,
+		},
+		{
+			name:     "#4 short password",
+			email:    "other@example.com",
+			password: "short",
+			wantErr:  ErrInvalidPassword,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := s.Register(context.Background(), tt.email, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "user@example.com", user.Email)
+			assert.NotEmpty(t, user.ID)
+			assert.NotEqual(t, tt.password, user.PasswordHash)
+		})
+	}
+}
+
+type fakeAuditor struct {
+	events []*audit.AuditEvent
+}
+
+func (f *fakeAuditor) Emit(_ context.Context, event *audit.AuditEvent) {
+	f.events = append(f.events, event)
+}
+
+func TestService_Login(t *testing.T) {
+	s := newTestService(nil)
+	auditor := &fakeAuditor{}
+	s.auditor = auditor
+
+	registered, err := s.Register(context.Background(), "user@example.com", "password123")
+	require.NoError(t, err)
+
+	user, err := s.Login(context.Background(), "USER@example.com", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, user.ID)
+
+	_, err = s.Login(context.Background(), "user@example.com", "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = s.Login(context.Background(), "unknown@example.com", "password123")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// Для неизвестного email пароль сравнивается с хешем той же стоимости
+	cost, err := bcrypt.Cost(s.dummyHash)
+	require.NoError(t, err)
+	assert.Equal(t, s.hashCost, cost)
+
+	require.Len(t, auditor.events, 3)
+	assert.Equal(t, audit.ActionLogin, auditor.events[0].Action)
+	assert.Equal(t, registered.ID, auditor.events[0].UserID)
+	assert.Equal(t, "user@example.com", auditor.events[0].Target)
+	assert.Equal(t, audit.ResultSuccess, auditor.events[0].Result)
+	assert.Equal(t, audit.ResultFailure, auditor.events[1].Result)
+	assert.Equal(t, ErrInvalidCredentials.Error(), auditor.events[1].Error)
+	assert.Equal(t, "unknown@example.com", auditor.events[2].Target)
+}
+
+func TestService_ClaimAnonymousLinks(t *testing.T) {
+	linkStorage := storages.NewInMemoryStorage()
+	shorter := shortener.NewShortener(linkStorage, generators.NewRandomGenerator(10), shortener.NewShortenerConfig("http://localhost"))
+	s := newTestService(shorter)
+
+	ctx := context.WithValue(context.Background(), models.ContextUserID, "anonymous")
+	_, err := shorter.GenerateShortLink(ctx, "https://example.com")
+	require.NoError(t, err)
+
+	owner, err := s.Register(context.Background(), "owner@example.com", "password123")
+	require.NoError(t, err)
+	other, err := s.Register(context.Background(), "other@example.com", "password123")
+	require.NoError(t, err)
+
+	require.NoError(t, s.ClaimAnonymousLinks(context.Background(), "anonymous", owner))
+
+	links, err := linkStorage.GetUserLinks(context.Background(), owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	// Ссылки зарегистрированного пользователя не передаются другой учетной записи
+	require.NoError(t, s.ClaimAnonymousLinks(context.Background(), owner.ID, other))
+
+	links, err = linkStorage.GetUserLinks(context.Background(), owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, links, 1)
+}
@@ -0,0 +1,136 @@
+package validators
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BlocklistValidator запрещает URL, домен которых (или любой родительский домен)
+// указан в файле блок-листа.
+// Файл содержит по одному домену в строке; пустые строки и строки,
+// начинающиеся с #, игнорируются. Файл перечитывается при изменении (см. Watch).
+type BlocklistValidator struct {
+	filePath string              // Путь к файлу блок-листа
+	domains  map[string]struct{} // Заблокированные домены
+	modTime  time.Time           // Время изменения загруженной версии файла
+	mu       sync.RWMutex        // Мьютекс для обеспечения потокобезопасности
+	log      *zap.SugaredLogger
+}
+
+// NewBlocklistValidator создает валидатор и загружает блок-лист из файла.
+// Отсутствующий файл не считается ошибкой: блок-лист будет пустым до его появления.
+func NewBlocklistValidator(filePath string, log *zap.SugaredLogger) (*BlocklistValidator, error) {
+	v := &BlocklistValidator{
+		filePath: filePath,
+		domains:  make(map[string]struct{}),
+		log:      log,
+	}
+
+	if err := v.Reload(); err != nil {
+		return v, err
+	}
+
+	return v, nil
+}
+
+// Validate возвращает ErrDomainBlocked, если домен URL или его родитель заблокирован.
+func (v *BlocklistValidator) Validate(_ context.Context, u *url.URL) error {
+	host := normalizeHost(u.Hostname())
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	for host != "" {
+		if _, ok := v.domains[host]; ok {
+			return ErrDomainBlocked
+		}
+
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			break
+		}
+		host = host[dot+1:]
+	}
+
+	return nil
+}
+
+// Reload перечитывает файл блок-листа, если он изменился с момента последней загрузки.
+func (v *BlocklistValidator) Reload() error {
+	info, err := os.Stat(v.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		v.mu.Lock()
+		v.domains = make(map[string]struct{})
+		v.modTime = time.Time{}
+		v.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	v.mu.RLock()
+	unchanged := info.ModTime().Equal(v.modTime)
+	v.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	domains, err := readBlocklist(v.filePath)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.domains = domains
+	v.modTime = info.ModTime()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// Watch периодически перечитывает файл блок-листа до отмены контекста.
+func (v *BlocklistValidator) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := v.Reload(); err != nil && v.log != nil {
+				v.log.Errorw("Failed to reload blocklist", "file", v.filePath, "error", err)
+			}
+		}
+	}
+}
+
+func readBlocklist(filePath string) (map[string]struct{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		domains[normalizeHost(strings.TrimPrefix(line, "*."))] = struct{}{}
+	}
+
+	return domains, scanner.Err()
+}
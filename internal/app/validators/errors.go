@@ -0,0 +1,11 @@
+package validators
+
+import "errors"
+
+var (
+	ErrMalformedURL     = errors.New("malformed url")
+	ErrSchemeNotAllowed = errors.New("url scheme is not allowed")
+	ErrPrivateNetwork   = errors.New("url points to a private or loopback network")
+	ErrRedirectLoop     = errors.New("url points to the shortener itself")
+	ErrDomainBlocked    = errors.New("url domain is blocked")
+)
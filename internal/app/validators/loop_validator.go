@@ -0,0 +1,39 @@
+package validators
+
+import (
+	"context"
+	"net/url"
+)
+
+// LoopValidator запрещает URL, указывающие на домен самого сервиса сокращения,
+// чтобы короткие ссылки не могли перенаправлять друг на друга по кругу.
+// Сравниваются только имена хостов: любой порт домена сервиса считается циклом.
+type LoopValidator struct {
+	hosts map[string]struct{} // Множество имен хостов сервиса
+}
+
+// NewLoopValidator создает валидатор циклов для переданных базовых URL сервиса.
+// Невалидные URL игнорируются.
+func NewLoopValidator(baseURLs ...string) *LoopValidator {
+	hosts := make(map[string]struct{}, len(baseURLs))
+	for _, baseURL := range baseURLs {
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		hosts[normalizeHost(u.Hostname())] = struct{}{}
+	}
+
+	return &LoopValidator{
+		hosts: hosts,
+	}
+}
+
+// Validate возвращает ErrRedirectLoop, если URL указывает на сервис сокращения.
+func (v *LoopValidator) Validate(_ context.Context, u *url.URL) error {
+	if _, ok := v.hosts[normalizeHost(u.Hostname())]; ok {
+		return ErrRedirectLoop
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package validators
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Resolver определяет интерфейс разрешения имен хостов в IP-адреса.
+// Совместим с *net.Resolver.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// sharedAddressSpace - диапазон адресов CGNAT (RFC 6598), недоступный из интернета.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NetworkValidator запрещает URL, указывающие на localhost, loopback, частные,
+// link-local и другие внутренние сети.
+// Если задан resolver, имена хостов дополнительно разрешаются в IP-адреса,
+// и проверяется каждый из них.
+type NetworkValidator struct {
+	resolver Resolver // Необязательный резолвер имен хостов
+}
+
+// NewNetworkValidator создает валидатор сети назначения.
+// resolver может быть nil - тогда проверяются только IP-адреса и имена вида localhost.
+func NewNetworkValidator(resolver Resolver) *NetworkValidator {
+	return &NetworkValidator{
+		resolver: resolver,
+	}
+}
+
+// Validate возвращает ErrPrivateNetwork, если URL указывает на внутреннюю сеть.
+func (v *NetworkValidator) Validate(ctx context.Context, u *url.URL) error {
+	host := normalizeHost(u.Hostname())
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateNetwork
+	}
+
+	if addr, ok := parseIP(host); ok {
+		if isInternalAddr(addr) {
+			return ErrPrivateNetwork
+		}
+		return nil
+	}
+
+	// Хост с числовой последней меткой браузер разберет как IPv4-адрес, поэтому
+	// неразобранный такой хост нельзя проверять как обычное имя
+	if endsInNumber(host) {
+		return ErrMalformedURL
+	}
+
+	if v.resolver == nil {
+		return nil
+	}
+
+	addrs, err := v.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// Неразрешимое имя не считается внутренним: переход по нему просто не удастся
+		return nil
+	}
+
+	for _, ipAddr := range addrs {
+		if addr, ok := netip.AddrFromSlice(ipAddr.IP); ok && isInternalAddr(addr) {
+			return ErrPrivateNetwork
+		}
+	}
+
+	return nil
+}
+
+// parseIP разбирает IP-адрес хоста. Для IPv4 понимаются те же записи, что и у
+// inet_aton и браузеров: одно число (http://2130706433/), сокращенная запись
+// (http://127.1/) и шестнадцатеричные или восьмеричные части (http://0x7f.0.0.1/,
+// http://0177.0.0.1/).
+func parseIP(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap(), true
+	}
+
+	if !endsInNumber(host) {
+		return netip.Addr{}, false
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+
+	var n uint64
+	for i, part := range parts {
+		value, ok := parseIPv4Part(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+
+		if i < len(parts)-1 {
+			if value > 0xff {
+				return netip.Addr{}, false
+			}
+			n |= value << (8 * (3 - i))
+			continue
+		}
+
+		// Последняя часть занимает все оставшиеся байты адреса
+		if value >= 1<<(8*(4-i)) {
+			return netip.Addr{}, false
+		}
+		n |= value
+	}
+
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}), true
+}
+
+// endsInNumber сообщает, является ли последняя метка хоста числом. Такой хост
+// браузеры считают IPv4-адресом, а не именем.
+func endsInNumber(host string) bool {
+	label := host[strings.LastIndexByte(host, '.')+1:]
+	if label == "" {
+		return false
+	}
+
+	digits := "0123456789"
+	if strings.HasPrefix(label, "0x") || strings.HasPrefix(label, "0X") {
+		digits, label = "0123456789abcdefABCDEF", label[2:]
+	}
+
+	for _, r := range label {
+		if !strings.ContainsRune(digits, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIPv4Part разбирает часть IPv4-адреса: десятичную, шестнадцатеричную
+// с префиксом 0x или восьмеричную с ведущим нулем.
+func parseIPv4Part(part string) (uint64, bool) {
+	if part == "" {
+		return 0, false
+	}
+
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		base, part = 16, part[2:]
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		base, part = 8, part[1:]
+	}
+
+	value, err := strconv.ParseUint(part, base, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+func isInternalAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		sharedAddressSpace.Contains(addr)
+}
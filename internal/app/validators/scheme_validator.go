@@ -0,0 +1,37 @@
+package validators
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// SchemeValidator допускает только URL с разрешенными схемами (например, http и https).
+// Это исключает javascript:, data:, file: и подобные схемы.
+type SchemeValidator struct {
+	allowed map[string]struct{} // Множество разрешенных схем в нижнем регистре
+}
+
+// NewSchemeValidator создает валидатор схем. Пустые значения игнорируются.
+func NewSchemeValidator(schemes ...string) *SchemeValidator {
+	allowed := make(map[string]struct{}, len(schemes))
+	for _, scheme := range schemes {
+		scheme = strings.ToLower(strings.TrimSpace(scheme))
+		if scheme != "" {
+			allowed[scheme] = struct{}{}
+		}
+	}
+
+	return &SchemeValidator{
+		allowed: allowed,
+	}
+}
+
+// Validate возвращает ErrSchemeNotAllowed, если схема URL не входит в список разрешенных.
+func (v *SchemeValidator) Validate(_ context.Context, u *url.URL) error {
+	if _, ok := v.allowed[strings.ToLower(u.Scheme)]; !ok {
+		return ErrSchemeNotAllowed
+	}
+
+	return nil
+}
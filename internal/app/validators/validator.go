@@ -0,0 +1,56 @@
+package validators
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// Validator определяет интерфейс проверки безопасности URL назначения.
+// Различные реализации проверяют схему, сеть назначения, циклы перенаправлений и т.д.
+type Validator interface {
+	// Validate проверяет URL и возвращает ошибку, если он не может быть сокращен.
+	Validate(ctx context.Context, u *url.URL) error
+}
+
+// Chain объединяет несколько валидаторов. URL считается допустимым,
+// только если его приняли все валидаторы цепочки.
+type Chain []Validator
+
+// NewChain создает цепочку валидаторов. Пустые (nil) валидаторы пропускаются.
+func NewChain(validators ...Validator) Chain {
+	chain := make(Chain, 0, len(validators))
+	for _, validator := range validators {
+		if validator != nil {
+			chain = append(chain, validator)
+		}
+	}
+
+	return chain
+}
+
+// Validate последовательно применяет валидаторы цепочки и возвращает первую ошибку.
+func (c Chain) Validate(ctx context.Context, u *url.URL) error {
+	for _, validator := range c {
+		if err := validator.Validate(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateString разбирает rawURL и проверяет его указанным валидатором.
+func ValidateString(ctx context.Context, validator Validator, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ErrMalformedURL
+	}
+
+	return validator.Validate(ctx, u)
+}
+
+// normalizeHost приводит имя хоста к нижнему регистру и убирает завершающую точку.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,127 @@
+package validators
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticResolver map[string][]net.IPAddr
+
+func (r staticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+func TestChain(t *testing.T) {
+	resolver := staticResolver{
+		"internal.example.com": {{IP: net.ParseIP("10.0.0.5")}},
+		"public.example.com":   {{IP: net.ParseIP("93.184.216.34")}},
+	}
+
+	chain := NewChain(
+		NewSchemeValidator("http", "https"),
+		NewNetworkValidator(resolver),
+		NewLoopValidator("http://short.ly:8080"),
+		nil,
+	)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "#1 public url", url: "https://example.com/page"},
+		{name: "#2 javascript scheme", url: "javascript://example.com/%0Aalert(1)", wantErr: ErrSchemeNotAllowed},
+		{name: "#3 ftp scheme", url: "ftp://example.com/file", wantErr: ErrSchemeNotAllowed},
+		{name: "#4 localhost", url: "http://localhost:8080/admin", wantErr: ErrPrivateNetwork},
+		{name: "#5 localhost subdomain", url: "http://api.LOCALHOST./", wantErr: ErrPrivateNetwork},
+		{name: "#6 loopback ip", url: "http://127.0.0.1/", wantErr: ErrPrivateNetwork},
+		{name: "#7 private ip", url: "http://192.168.1.10/", wantErr: ErrPrivateNetwork},
+		{name: "#8 ipv6 loopback", url: "http://[::1]:8080/", wantErr: ErrPrivateNetwork},
+		{name: "#9 mapped ipv4 loopback", url: "http://[::ffff:127.0.0.1]/", wantErr: ErrPrivateNetwork},
+		{name: "#10 decimal ipv4", url: "http://2130706433/", wantErr: ErrPrivateNetwork},
+		{name: "#11 link local metadata", url: "http://169.254.169.254/latest", wantErr: ErrPrivateNetwork},
+		{name: "#12 resolved to private", url: "https://internal.example.com/", wantErr: ErrPrivateNetwork},
+		{name: "#13 resolved to public", url: "https://public.example.com/"},
+		{name: "#14 unresolvable host", url: "https://unknown.example.com/"},
+		{name: "#15 own domain", url: "https://SHORT.ly/abc", wantErr: ErrRedirectLoop},
+		{name: "#16 public ip", url: "http://8.8.8.8/"},
+		{name: "#17 malformed", url: "http//example.com", wantErr: ErrMalformedURL},
+		{name: "#18 shorthand ipv4", url: "http://127.1/", wantErr: ErrPrivateNetwork},
+		{name: "#19 dotted hex ipv4", url: "http://0x7f.0.0.1/", wantErr: ErrPrivateNetwork},
+		{name: "#20 dotted octal ipv4", url: "http://0177.0.0.1/", wantErr: ErrPrivateNetwork},
+		{name: "#21 hex shorthand ipv4", url: "http://0x7f.1/", wantErr: ErrPrivateNetwork},
+		{name: "#22 hex integer ipv4", url: "http://0x7f000001/", wantErr: ErrPrivateNetwork},
+		{name: "#23 private shorthand ipv4", url: "http://10.1/", wantErr: ErrPrivateNetwork},
+		{name: "#24 public shorthand ipv4", url: "http://8.8.2056/"},
+		{name: "#25 numeric host out of range", url: "http://256.0.0.1/", wantErr: ErrMalformedURL},
+		{name: "#26 numeric host too many parts", url: "http://1.2.3.4.5/", wantErr: ErrMalformedURL},
+		{name: "#27 name with numeric label", url: "https://1.example.com/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateString(context.Background(), chain, tt.url)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBlocklistValidator(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "blocklist.txt")
+
+	v, err := NewBlocklistValidator(filePath, nil)
+	require.NoError(t, err)
+	assert.NoError(t, ValidateString(context.Background(), v, "https://evil.com/"))
+
+	require.NoError(t, os.WriteFile(filePath, []byte("# phishing\nevil.com\n\n*.bad.org\n"), 0644))
+	require.NoError(t, v.Reload())
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "#1 blocked domain", url: "https://evil.com/", wantErr: true},
+		{name: "#2 blocked subdomain", url: "https://login.EVIL.com./", wantErr: true},
+		{name: "#3 wildcard entry", url: "https://x.bad.org/", wantErr: true},
+		{name: "#4 similar domain", url: "https://notevil.com/"},
+		{name: "#5 other domain", url: "https://example.com/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateString(context.Background(), v, tt.url)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDomainBlocked)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Горячая перезагрузка подхватывает изменения файла
+	require.NoError(t, os.WriteFile(filePath, []byte("example.com\n"), 0644))
+	require.NoError(t, os.Chtimes(filePath, time.Now(), time.Now().Add(time.Minute)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.Watch(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return ValidateString(context.Background(), v, "https://example.com/") != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, ValidateString(context.Background(), v, "https://evil.com/"))
+}